@@ -0,0 +1,41 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	DefaultTimeout          = time.Duration(0)
+	DefaultStatementTimeout = time.Duration(0)
+	DefaultLockTimeout      = 5 * time.Second
+)
+
+// commandContext bounds the command by the timeout, zero meaning none, on
+// top of the cancellation on Ctrl-C set up by the root command.
+func commandContext(cmd *cobra.Command, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// contextError tells apart the errors caused by the command being
+// interrupted or running out of time.
+func contextError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return fmt.Errorf("timed out: %w", err)
+	case context.Canceled:
+		return fmt.Errorf("interrupted: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/registry"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+)
+
+var DiffCmd = &cobra.Command{
+	Use:     "diff [flags] old new",
+	Short:   "Diff two schema directories",
+	Long:    "This command is used to show or generate the migration between two sets of JSON schemas without a database",
+	Args:    cobra.ExactArgs(2),
+	Run:     Diff,
+	Example: "diff -o users_registrations old/db/schemas db/schemas",
+}
+
+var (
+	diffDialect,
+	diffDir,
+	diffOutput,
+	diffFormat,
+	diffPlanFormat string
+
+	diffSkipDropTable,
+	diffAllowDestructive,
+	diffOnline,
+	diffAnnotateLocks bool
+
+	diffLockTimeout time.Duration
+
+	diffBackfillBatchSize int
+)
+
+func init() {
+	DiffCmd.Flags().StringVar(&diffDialect, "dialect", registry.Postgres, "set SQL dialect")
+	DiffCmd.Flags().StringVarP(&diffDir, "dir", "d", DefaultOutputDirectory, "set migration directory")
+	DiffCmd.Flags().StringVarP(&diffOutput, "output", "o", DefaultOutputName, "set output name, prints a report instead of writing migration files when empty")
+	DiffCmd.Flags().StringVar(&diffFormat, "format", sqlgen.FormatGolangMigrate, fmt.Sprintf("write the migrations in the layout of the tool, one of %s", strings.Join(sqlgen.Formats(), ", ")))
+	DiffCmd.Flags().StringVar(&diffPlanFormat, "plan-format", "", "print the migration plan in the format, json, instead of the report or migration files")
+	DiffCmd.Flags().BoolVar(&diffSkipDropTable, "skip-drop-table", DefaultSkipTable, "skip drop table generation query")
+	DiffCmd.Flags().BoolVar(&diffAllowDestructive, "allow-destructive", false, "write changes losing data which are not acknowledged by the schemas")
+	DiffCmd.Flags().BoolVar(&diffOnline, "online", false, "split the migration into phases which avoid long locks, building indices concurrently, postgres only")
+	DiffCmd.Flags().BoolVar(&diffAnnotateLocks, "annotate-locks", false, "comment every statement with the lock it takes and whether it rewrites the table, postgres only")
+	DiffCmd.Flags().DurationVar(&diffLockTimeout, "lock-timeout", DefaultLockTimeout, "give up waiting for a lock after the duration in online migrations, no timeout when 0")
+	DiffCmd.Flags().IntVar(&diffBackfillBatchSize, "backfill-batch-size", sqlgen.DefaultBackfillBatchSize, "number of rows updated per transaction when backfilling a column renamed or retyped with renamed_from")
+}
+
+func Diff(cmd *cobra.Command, args []string) {
+	oldSchemas, err := config.Parse(args[0])
+	if err != nil {
+		fmt.Println(color.RedString("Failed to parse old schemas"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", err)
+		os.Exit(1)
+	}
+
+	newSchemas, err := config.Parse(args[1])
+	if err != nil {
+		fmt.Println(color.RedString("Failed to parse new schemas"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", err)
+		os.Exit(1)
+	}
+
+	dialect, err := registry.Get(diffDialect)
+	if err != nil {
+		fmt.Println(color.RedString("Unsupported dialect"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", err)
+		os.Exit(1)
+	}
+
+	flag, err := sqlgen.NewFlag(diffDir, diffOutput, diffSkipDropTable)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	_, err = sqlgen.NewMigrationWriter(diffFormat)
+	if err != nil {
+		fmt.Println(color.RedString("Unsupported migration format"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", err)
+		os.Exit(1)
+	}
+
+	flag.Dialect = dialect.Name
+	flag.Format = diffFormat
+	flag.AllowDestructive = diffAllowDestructive
+	flag.Online = diffOnline
+	flag.LockTimeout = diffLockTimeout
+	flag.BackfillBatchSize = diffBackfillBatchSize
+	flag.AnnotateLocks = diffAnnotateLocks
+
+	gen := sqlgen.NewGenerator(schema.NewStaticSchema(oldSchemas), newSchemas, flag)
+	switch {
+	case diffPlanFormat != "":
+		err = gen.WritePlan(cmd.Context(), os.Stdout, diffPlanFormat)
+	case diffOutput == "":
+		err = gen.Report(cmd.Context(), os.Stdout)
+	default:
+		err = gen.Generate(cmd.Context())
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dir"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/json"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/registry"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
 )
 
@@ -24,22 +26,36 @@ var (
 
 func init() {
 	DumpDbCmd.Flags().StringVarP(&migrationConnString, "connection", "c", "", "(Required) Set connection string")
+	DumpDbCmd.Flags().StringVar(&migrationDialect, "dialect", "", "Set SQL dialect, defaults to the dialect of the connection string")
 	DumpDbCmd.Flags().StringVarP(&outputDir, "output", "o", "", "Output schemas path")
+	DumpDbCmd.Flags().StringArrayVar(&migrationIncludeTables, "include-table", nil, "Only dump the tables matching the glob, or the regular expression wrapped in slashes, can be repeated")
+	DumpDbCmd.Flags().StringArrayVar(&migrationExcludeTables, "exclude-table", nil, "Skip the tables matching the glob, or the regular expression wrapped in slashes, can be repeated")
+	DumpDbCmd.Flags().StringVar(&migrationTable, "migration-table", filter.DefaultMigrationTable, "Set the migration tracking table, which is never dumped")
+	DumpDbCmd.Flags().DurationVar(&migrationTimeout, "timeout", DefaultTimeout, "Abort the command after the duration, e.g. 30s, no timeout when 0")
+	DumpDbCmd.Flags().DurationVar(&migrationStatementTimeout, "statement-timeout", DefaultStatementTimeout, "Abort any database query running longer than the duration, no timeout when 0")
+	DumpDbCmd.Flags().IntVar(&migrationConcurrency, "concurrency", DefaultConcurrency, "Run up to the number of catalog queries at the same time, bounded by pool_max_conns of the connection string")
 	DumpDbCmd.MarkFlagRequired("connection")
 	DumpDbCmd.MarkFlagRequired("output")
 }
 
 func DumpDb(cmd *cobra.Command, args []string) {
 	fmt.Println("🚀 Dumping database")
-	crawler, err := schema.NewSchema(migrationConnString)
+	dialect, err := registry.Resolve(migrationDialect, migrationConnString)
 	if err != nil {
-		fmt.Println(color.RedString("Failed to connect to datasource"))
+		fmt.Println(color.RedString("Unsupported dialect"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", err)
+		os.Exit(1)
+	}
+
+	tableFilter, err := filter.NewTableFilter(migrationIncludeTables, migrationExcludeTables, migrationTable)
+	if err != nil {
+		fmt.Println(color.RedString("Invalid table filter"))
 		fmt.Println("Please see error details below:")
 		fmt.Printf("\t%s\n", err)
 		os.Exit(1)
 	}
 
-	gen := json.NewSchemasGenerator(crawler)
 	override, err := dir.CheckDirExists(outputDir)
 	if err != nil {
 		fmt.Println(color.RedString("Check output path failed"))
@@ -49,11 +65,28 @@ func DumpDb(cmd *cobra.Command, args []string) {
 	}
 
 	if override {
-		err = gen.GenerateBySchemas(outputDir)
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		crawler, err := dialect.NewCrawler(ctx, migrationConnString, schema.ConnectOptions{
+			StatementTimeout: migrationStatementTimeout,
+			TableFilter:      tableFilter,
+			Concurrency:      migrationConcurrency,
+		})
+		if err != nil {
+			fmt.Println(color.RedString("Failed to connect to datasource"))
+			fmt.Println("Please see error details below:")
+			fmt.Printf("\t%s\n", contextError(ctx, err))
+			os.Exit(1)
+		}
+
+		gen := json.NewSchemasGenerator(crawler)
+		err = gen.GenerateBySchemas(ctx, outputDir)
+		crawler.Close()
 		if err != nil {
 			fmt.Println(color.RedString("Failed to generate schemas to JSON"))
 			fmt.Println("Please see error details below:")
-			fmt.Printf("\t%s\n", err)
+			fmt.Printf("\t%s\n", contextError(ctx, err))
 			os.Exit(1)
 		}
 	}
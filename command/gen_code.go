@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/cobra"
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
 	"gitlab.com/wartek-id/core/tools/dbgen/generator"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/registry"
 )
 
 const (
@@ -21,6 +22,7 @@ const (
 	DefaultPackageName      = "db"
 	DefaultPackagePath      = "internal/db"
 	DefaultSqlPackage       = "pgx/v4"
+	DefaultCodeDialect      = "postgres"
 	SqlcPath                = "./sqlc.yaml"
 	TemporaryPath           = "./temp"
 	OutputQueriesPath       = TemporaryPath + "/query/"
@@ -31,6 +33,7 @@ type SqlcConfig struct {
 	PackageName string
 	PackagePath string
 	SqlPackage  string
+	Engine      string
 }
 
 var (
@@ -38,6 +41,7 @@ var (
 	packagePath string
 	sqlPackage  string
 	inputPath   string
+	codeDialect string
 	GenCode     = &cobra.Command{
 		Use:     "gen:code",
 		Short:   "Generate code",
@@ -53,14 +57,34 @@ func init() {
 	GenCode.Flags().StringVar(&packageName, "packageName", DefaultPackageName, "(Optional) Package name for database in Go")
 	GenCode.Flags().StringVar(&packagePath, "packagePath", DefaultPackagePath, "(Optional) Output path for database in Go")
 	GenCode.Flags().StringVar(&sqlPackage, "sqlPackage", DefaultSqlPackage, "(Optional) SQL Package to specify which library to use")
+	GenCode.Flags().StringVar(&codeDialect, "dialect", DefaultCodeDialect, "(Optional) SQL dialect of the generated queries")
 	GenCode.Flags().StringVarP(&inputPath, "inputPath", "i", "", "(Required) Path to JSON schemas")
 	GenCode.MarkFlagRequired("inputPath")
 }
 
 func GenerateCode(cmd *cobra.Command, args []string) {
-	CreateSqlcConfigFile()
+	dialect, err := registry.Get(codeDialect)
+	if err == nil && dialect.SqlcEngine == "" {
+		err = fmt.Errorf("sqlc does not support %s dialect", dialect.Name)
+	}
+	if err != nil {
+		fmt.Println(color.RedString("Unsupported dialect"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", err)
+		os.Exit(1)
+	}
+
+	CreateSqlcConfigFile(dialect.SqlcEngine)
 	schemas, err := config.Parse(inputPath)
-	function := generator.GenerateQueries(schemas)
+	if err == nil {
+		err = dialect.Validate(schemas)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	function := generator.GenerateQueriesWithDialect(dialect.QueryDialect, schemas)
 	err = SaveQueriesToFile(function, OutputQueriesPath)
 	if err != nil {
 		fmt.Println(err)
@@ -107,11 +131,12 @@ func DownloadSqlc() {
 	}
 }
 
-func CreateSqlcConfigFile() error {
+func CreateSqlcConfigFile(engine string) error {
 	sqlcConfig := &SqlcConfig{
 		PackageName: packageName,
 		PackagePath: packagePath,
 		SqlPackage:  sqlPackage,
+		Engine:      engine,
 	}
 
 	template, err := template.ParseFS(sqlcTemplate, SqlcTemplatePath)
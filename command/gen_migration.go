@@ -1,13 +1,18 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/registry"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
 )
 
@@ -16,6 +21,10 @@ const (
 	DefaultOutputDirectory  = "db/migration"
 	DefaultOutputName       = ""
 	DefaultSkipTable        = false
+	DefaultConcurrency      = 1
+
+	// DryRunChangesExitCode is the exit code of a dry run finding changes.
+	DryRunChangesExitCode = 2
 )
 
 var GenMigration = &cobra.Command{
@@ -28,17 +37,59 @@ var GenMigration = &cobra.Command{
 
 var (
 	migrationConnString,
+	migrationDialect,
+	migrationFromDDL,
 	migrationDir,
-	migrationOutput string
+	migrationOutput,
+	migrationTable,
+	migrationFormat,
+	migrationPlanFormat string
+
+	skipDropTable,
+	allowDestructive,
+	migrationOnline,
+	migrationDryRun,
+	migrationAnnotateLocks,
+	migrationFromHistory,
+	migrationFromSnapshot bool
+
+	migrationConcurrency int
+
+	defaultGrants,
+	migrationIncludeTables,
+	migrationExcludeTables []string
+
+	migrationTimeout,
+	migrationStatementTimeout,
+	migrationLockTimeout time.Duration
 
-	skipDropTable bool
+	migrationBackfillBatchSize int
 )
 
 func init() {
 	GenMigration.Flags().StringVarP(&migrationConnString, "connection", "c", DefaultConnectionString, "set connection string")
+	GenMigration.Flags().StringVar(&migrationDialect, "dialect", "", "set SQL dialect, defaults to the dialect of the connection string")
+	GenMigration.Flags().StringVar(&migrationFromDDL, "from-ddl", "", "diff against a DDL file, e.g. pg_dump --schema-only output, instead of a database")
+	GenMigration.Flags().BoolVar(&migrationFromHistory, "from-migrations", false, "diff against the replayed up migrations of the migration directory instead of a database")
+	GenMigration.Flags().BoolVar(&migrationFromSnapshot, "from-snapshot", false, "diff against the latest schema snapshot of the migration directory instead of a database")
 	GenMigration.Flags().StringVarP(&migrationDir, "dir", "d", DefaultOutputDirectory, "set migration directory")
 	GenMigration.Flags().StringVarP(&migrationOutput, "output", "o", DefaultOutputName, "set output name")
 	GenMigration.Flags().BoolVar(&skipDropTable, "skip-drop-table", DefaultSkipTable, "skip drop table generation query")
+	GenMigration.Flags().BoolVar(&allowDestructive, "allow-destructive", false, "write changes losing data, such as dropped columns or narrowed types, which are not acknowledged by the schemas")
+	GenMigration.Flags().BoolVar(&migrationOnline, "online", false, "split the migration into phases which avoid long locks, building indices concurrently, postgres only")
+	GenMigration.Flags().DurationVar(&migrationLockTimeout, "lock-timeout", DefaultLockTimeout, "give up waiting for a lock after the duration in online migrations, no timeout when 0")
+	GenMigration.Flags().IntVar(&migrationBackfillBatchSize, "backfill-batch-size", sqlgen.DefaultBackfillBatchSize, "number of rows updated per transaction when backfilling a column renamed or retyped with renamed_from")
+	GenMigration.Flags().BoolVar(&migrationAnnotateLocks, "annotate-locks", false, "comment every statement with the lock it takes and whether it rewrites the table, postgres only")
+	GenMigration.Flags().BoolVar(&migrationDryRun, "dry-run", false, fmt.Sprintf("print a preview of the changes and the migrations instead of writing files, exiting with %d when there are changes", DryRunChangesExitCode))
+	GenMigration.Flags().StringVar(&migrationFormat, "format", sqlgen.FormatGolangMigrate, fmt.Sprintf("write the migrations in the layout of the tool, one of %s", strings.Join(sqlgen.Formats(), ", ")))
+	GenMigration.Flags().StringVar(&migrationPlanFormat, "plan-format", "", "print the migration plan in the format, json, instead of writing migration files")
+	GenMigration.Flags().StringArrayVar(&defaultGrants, "default-grant", nil, "grant applied to every table, in form of role=PRIVILEGE[,PRIVILEGE]")
+	GenMigration.Flags().StringArrayVar(&migrationIncludeTables, "include-table", nil, "only manage the tables matching the glob, or the regular expression wrapped in slashes, can be repeated")
+	GenMigration.Flags().StringArrayVar(&migrationExcludeTables, "exclude-table", nil, "never create, alter or drop the tables matching the glob, or the regular expression wrapped in slashes, can be repeated")
+	GenMigration.Flags().StringVar(&migrationTable, "migration-table", filter.DefaultMigrationTable, "set the migration tracking table, which is never managed")
+	GenMigration.Flags().DurationVar(&migrationTimeout, "timeout", DefaultTimeout, "abort the command after the duration, e.g. 30s, no timeout when 0")
+	GenMigration.Flags().DurationVar(&migrationStatementTimeout, "statement-timeout", DefaultStatementTimeout, "abort any database query running longer than the duration, no timeout when 0")
+	GenMigration.Flags().IntVar(&migrationConcurrency, "concurrency", DefaultConcurrency, "run up to the number of catalog queries at the same time, bounded by pool_max_conns of the connection string")
 }
 
 func GenerateMigration(cmd *cobra.Command, args []string) {
@@ -56,9 +107,28 @@ func GenerateMigration(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	crawler, err := schema.NewSchema(migrationConnString)
+	for _, value := range defaultGrants {
+		grant, err := config.ParseGrant(value)
+		if err != nil {
+			fmt.Println(color.RedString("Invalid default grant"))
+			fmt.Println("Please see error details below:")
+			fmt.Printf("\t%s\n", err)
+			os.Exit(1)
+		}
+
+		for _, sc := range schemas {
+			sc.AddGrants(grant)
+		}
+	}
+
+	dialectName := migrationDialect
+	if dialectName == "" && (migrationFromDDL != "" || migrationFromHistory || migrationFromSnapshot) {
+		dialectName = registry.Postgres
+	}
+
+	dialect, err := registry.Resolve(dialectName, migrationConnString)
 	if err != nil {
-		fmt.Println(color.RedString("Failed to connect to datasource"))
+		fmt.Println(color.RedString("Unsupported dialect"))
 		fmt.Println("Please see error details below:")
 		fmt.Printf("\t%s\n", err)
 		os.Exit(1)
@@ -70,10 +140,90 @@ func GenerateMigration(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	tableFilter, err := filter.NewTableFilter(migrationIncludeTables, migrationExcludeTables, migrationTable)
+	if err != nil {
+		fmt.Println(color.RedString("Invalid table filter"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", err)
+		os.Exit(1)
+	}
+
+	_, err = sqlgen.NewMigrationWriter(migrationFormat)
+	if err == nil && migrationFromHistory {
+		err = sqlgen.ValidateHistoryFormat(migrationFormat)
+	}
+	if err != nil {
+		fmt.Println(color.RedString("Unsupported migration format"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", err)
+		os.Exit(1)
+	}
+
+	flag.Format = migrationFormat
+	flag.Dialect = dialect.Name
+	flag.TableFilter = tableFilter
+	flag.AllowDestructive = allowDestructive
+	flag.Online = migrationOnline
+	flag.LockTimeout = migrationLockTimeout
+	flag.BackfillBatchSize = migrationBackfillBatchSize
+	flag.AnnotateLocks = migrationAnnotateLocks
+
+	ctx, cancel := commandContext(cmd, migrationTimeout)
+	defer cancel()
+
+	crawler, err := newMigrationCrawler(ctx, dialect, tableFilter)
+	if err != nil {
+		fmt.Println(color.RedString("Failed to connect to datasource"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", contextError(ctx, err))
+		os.Exit(1)
+	}
+
+	changed := false
 	gen := sqlgen.NewGenerator(crawler, schemas, flag)
-	err = gen.Generate()
+	switch {
+	case migrationPlanFormat != "":
+		err = gen.WritePlan(ctx, os.Stdout, migrationPlanFormat)
+	case migrationDryRun:
+		changed, err = gen.DryRun(ctx, os.Stdout)
+	default:
+		err = gen.Generate(ctx)
+	}
+	crawler.Close()
 	if err != nil {
-		fmt.Println(err)
+		fmt.Println(contextError(ctx, err))
 		os.Exit(1)
 	}
+	if changed {
+		os.Exit(DryRunChangesExitCode)
+	}
+}
+
+func newMigrationCrawler(ctx context.Context, dialect *registry.Dialect, tableFilter *filter.TableFilter) (schema.Schema, error) {
+	if migrationFromDDL != "" {
+		crawler, err := schema.NewDDLSchema(migrationFromDDL)
+		if err != nil {
+			return nil, err
+		}
+		return crawler.WithTableFilter(tableFilter), nil
+	}
+	if migrationFromHistory {
+		crawler, err := schema.NewMigrationSchema(migrationDir)
+		if err != nil {
+			return nil, err
+		}
+		return crawler.WithTableFilter(tableFilter), nil
+	}
+	if migrationFromSnapshot {
+		crawler, err := schema.NewSnapshotSchema(migrationDir)
+		if err != nil {
+			return nil, err
+		}
+		return crawler.WithTableFilter(tableFilter), nil
+	}
+	return dialect.NewCrawler(ctx, migrationConnString, schema.ConnectOptions{
+		StatementTimeout: migrationStatementTimeout,
+		TableFilter:      tableFilter,
+		Concurrency:      migrationConcurrency,
+	})
 }
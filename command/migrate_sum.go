@@ -0,0 +1,57 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/migrate"
+)
+
+var MigrateVerifyCmd = &cobra.Command{
+	Use:     "migrate:verify",
+	Short:   "Verify the migrations against the sum file",
+	Long:    "This command is used to detect the migrations edited, deleted or reordered since gen:migration recorded them in the sum file of the migration directory",
+	Args:    cobra.NoArgs,
+	Run:     MigrateVerify,
+	Example: "migrate:verify -d db/migration",
+}
+
+var MigrateHashCmd = &cobra.Command{
+	Use:     "migrate:hash",
+	Short:   "Rewrite the sum file",
+	Long:    "This command is used to record the current migrations in the sum file of the migration directory, after a reviewed edit or a migration written by hand",
+	Args:    cobra.NoArgs,
+	Run:     MigrateHash,
+	Example: "migrate:hash -d db/migration",
+}
+
+func init() {
+	MigrateVerifyCmd.Flags().StringVarP(&migrationDir, "dir", "d", DefaultOutputDirectory, "set migration directory")
+	MigrateHashCmd.Flags().StringVarP(&migrationDir, "dir", "d", DefaultOutputDirectory, "set migration directory")
+}
+
+func MigrateVerify(cmd *cobra.Command, args []string) {
+	err := migrate.Verify(migrationDir)
+	if err != nil {
+		fmt.Println(color.RedString("Verification Failed"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(color.GreenString("The migrations match %s.", migrate.SumFile))
+}
+
+func MigrateHash(cmd *cobra.Command, args []string) {
+	err := migrate.WriteSum(migrationDir)
+	if err != nil {
+		fmt.Println(color.RedString("Failed to write the sum file"))
+		fmt.Println("Please see error details below:")
+		fmt.Printf("\t%s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Target file: %s\n", color.HiBlueString(filepath.Join(migrationDir, migrate.SumFile)))
+	fmt.Println(color.GreenString("Succeeded"))
+}
@@ -6,12 +6,21 @@ import (
 )
 
 type Field struct {
-	Name    string                     `json:"name"`
-	Type    field_type.FieldType       `json:"type"`
-	Scale   int                        `json:"scale"`
-	Limit   int                        `json:"limit"`
-	Default interface{}                `json:"default"`
-	Options []field_option.FieldOption `json:"options"`
+	Name      string                     `json:"name"`
+	Type      field_type.FieldType       `json:"type"`
+	Scale     int                        `json:"scale"`
+	Limit     int                        `json:"limit"`
+	Default   interface{}                `json:"default"`
+	Options   []field_option.FieldOption `json:"options"`
+	Collation string                     `json:"collation,omitempty"`
+	// Using converts the existing values when the column type is changed.
+	Using string `json:"using,omitempty"`
+	// DownUsing converts the values back when the down migration restores
+	// the previous column type.
+	DownUsing string `json:"down_using,omitempty"`
+	// RenamedFrom is the existing column the field replaces, moved into it
+	// without downtime by an expand/contract migration.
+	RenamedFrom string `json:"renamed_from,omitempty"`
 }
 
 func (f *Field) GetName() string {
@@ -22,7 +31,7 @@ func (f *Field) IsNotNull() bool {
 	for _, opt := range f.Options {
 		switch opt {
 		case field_option.NotNull, field_option.PrimaryKey:
-		return true
+			return true
 		case field_option.Nullable:
 			return false
 		}
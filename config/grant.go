@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+type Grant struct {
+	Role       string                `json:"role"`
+	Privileges []privilege.Privilege `json:"privileges"`
+}
+
+func (g *Grant) GetName() string {
+	return g.Role
+}
+
+func (g *Grant) HasPrivilege(priv privilege.Privilege) bool {
+	for _, p := range g.Privileges {
+		if p == priv {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseGrant parses grant in form of "role=PRIVILEGE,PRIVILEGE".
+func ParseGrant(value string) (*Grant, error) {
+	role, privs, found := strings.Cut(value, "=")
+	role = strings.TrimSpace(role)
+	if !found || role == "" || strings.TrimSpace(privs) == "" {
+		return nil, fmt.Errorf("invalid \"%s\" as grant, expected role=PRIVILEGE[,PRIVILEGE]", value)
+	}
+
+	grant := &Grant{Role: role}
+	for _, p := range strings.Split(privs, ",") {
+		priv, err := privilege.Parse(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if !grant.HasPrivilege(priv) {
+			grant.Privileges = append(grant.Privileges, priv)
+		}
+	}
+
+	return grant, nil
+}
@@ -0,0 +1,71 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+func TestGrant_GetName(t *testing.T) {
+	grant := config.Grant{
+		Role: "reporting",
+	}
+
+	assert.Equal(t, "reporting", grant.GetName())
+}
+
+func TestGrant_HasPrivilege(t *testing.T) {
+	grant := config.Grant{
+		Role:       "reporting",
+		Privileges: []privilege.Privilege{privilege.Select},
+	}
+
+	assert.True(t, grant.HasPrivilege(privilege.Select))
+	assert.False(t, grant.HasPrivilege(privilege.Insert))
+}
+
+func TestParseGrant(t *testing.T) {
+	testCases := map[string]struct {
+		input  string
+		result *config.Grant
+		err    error
+	}{
+		"single privilege": {
+			input: "reporting=select",
+			result: &config.Grant{
+				Role:       "reporting",
+				Privileges: []privilege.Privilege{privilege.Select},
+			},
+		},
+		"multiple privileges": {
+			input: "app = SELECT, INSERT, select",
+			result: &config.Grant{
+				Role:       "app",
+				Privileges: []privilege.Privilege{privilege.Select, privilege.Insert},
+			},
+		},
+		"missing privileges": {
+			input: "reporting",
+			err:   errors.New("invalid \"reporting\" as grant, expected role=PRIVILEGE[,PRIVILEGE]"),
+		},
+		"invalid privilege": {
+			input: "reporting=connect",
+			err:   errors.New("invalid \"connect\" as privilege"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := config.ParseGrant(tc.input)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+}
@@ -10,15 +10,70 @@ import (
 )
 
 type Schema struct {
-	Name   string   `json:"name"`
-	Fields []*Field `json:"fields"`
-	Index  []*Index `json:"indexes"`
+	Name    string   `json:"name"`
+	Fields  []*Field `json:"fields"`
+	Index   []*Index `json:"indexes"`
+	Grants  []*Grant `json:"grants,omitempty"`
+	Storage *Storage `json:"storage,omitempty"`
+
+	// AllowDestructive lists the columns whose destructive changes, such as
+	// dropping the column or narrowing its type, are acknowledged.
+	AllowDestructive []string `json:"allow_destructive,omitempty"`
+
+	// Large tables refuse the migrations rewriting them or blocking their
+	// writes while scanning them.
+	Large bool `json:"large,omitempty"`
 }
 
 func (s *Schema) GetName() string {
 	return s.Name
 }
 
+// GetField returns the field with the given name, or nil when missing.
+func (s *Schema) GetField(name string) *Field {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// AllowsDestructive reports whether destructive changes of the column are acknowledged.
+func (s *Schema) AllowsDestructive(column string) bool {
+	for _, name := range s.AllowDestructive {
+		if name == column {
+			return true
+		}
+	}
+	return false
+}
+
+// AddGrants merges the given grants into the schema grants, appending
+// privileges to an existing role or adding the role when it is missing.
+func (s *Schema) AddGrants(grants ...*Grant) {
+	for _, grant := range grants {
+		var existing *Grant
+		for _, g := range s.Grants {
+			if g.Role == grant.Role {
+				existing = g
+				break
+			}
+		}
+
+		if existing == nil {
+			existing = &Grant{Role: grant.Role}
+			s.Grants = append(s.Grants, existing)
+		}
+
+		for _, priv := range grant.Privileges {
+			if !existing.HasPrivilege(priv) {
+				existing.Privileges = append(existing.Privileges, priv)
+			}
+		}
+	}
+}
+
 func ParseSchema(path string) (*Schema, error) {
 	var schema Schema
 	b, err := os.ReadFile(path)
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
 )
 
 func TestParseSchema(t *testing.T) {
@@ -44,3 +45,32 @@ func TestSchema_GetName(t *testing.T) {
 
 	assert.Equal(t, "users", schema.GetName())
 }
+
+func TestSchema_GetField(t *testing.T) {
+	schema := config.Schema{
+		Name:   "users",
+		Fields: []*config.Field{{Name: "id"}, {Name: "name"}},
+	}
+
+	assert.Equal(t, schema.Fields[1], schema.GetField("name"))
+	assert.Nil(t, schema.GetField("email"))
+}
+
+func TestSchema_AddGrants(t *testing.T) {
+	schema := config.Schema{
+		Name: "users",
+		Grants: []*config.Grant{
+			{Role: "app", Privileges: []privilege.Privilege{privilege.Select}},
+		},
+	}
+
+	schema.AddGrants(
+		&config.Grant{Role: "app", Privileges: []privilege.Privilege{privilege.Select, privilege.Insert}},
+		&config.Grant{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+	)
+
+	assert.Equal(t, []*config.Grant{
+		{Role: "app", Privileges: []privilege.Privilege{privilege.Select, privilege.Insert}},
+		{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+	}, schema.Grants)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const (
+	AutovacuumParameterPrefix = "autovacuum_"
+	// DefaultTablespace is where the tables are stored without a tablespace,
+	// crawled as no tablespace.
+	DefaultTablespace = "pg_default"
+)
+
+type Storage struct {
+	Fillfactor int                    `json:"fillfactor,omitempty"`
+	Autovacuum map[string]interface{} `json:"autovacuum,omitempty"`
+	Tablespace string                 `json:"tablespace,omitempty"`
+	Unlogged   bool                   `json:"unlogged,omitempty"`
+}
+
+type StorageParameter struct {
+	Name  string
+	Value string
+}
+
+// Parameters returns the storage parameters set through WITH (...) sorted by name,
+// autovacuum settings are prefixed with autovacuum_.
+func (s *Storage) Parameters() []StorageParameter {
+	params := make([]StorageParameter, 0)
+	if s == nil {
+		return params
+	}
+
+	if s.Fillfactor != 0 {
+		params = append(params, StorageParameter{Name: "fillfactor", Value: strconv.Itoa(s.Fillfactor)})
+	}
+
+	for name, value := range s.Autovacuum {
+		params = append(params, StorageParameter{
+			Name:  AutovacuumParameterPrefix + name,
+			Value: parameterValue(value),
+		})
+	}
+
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+	return params
+}
+
+// parameterValue formats the JSON value of a parameter the way the database
+// stores it, numbers without exponent.
+func parameterValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (s *Storage) GetParameter(name string) (string, bool) {
+	for _, param := range s.Parameters() {
+		if param.Name == name {
+			return param.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// GetTablespace returns the tablespace of the table, empty for the default
+// tablespace.
+func (s *Storage) GetTablespace() string {
+	if s == nil || s.Tablespace == DefaultTablespace {
+		return ""
+	}
+	return s.Tablespace
+}
+
+func (s *Storage) IsUnlogged() bool {
+	return s != nil && s.Unlogged
+}
@@ -0,0 +1,53 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+)
+
+func TestStorage_Parameters(t *testing.T) {
+	storage := &config.Storage{
+		Fillfactor: 70,
+		Autovacuum: map[string]interface{}{
+			"vacuum_scale_factor": 0.05,
+			"vacuum_threshold":    float64(1000000),
+			"enabled":             false,
+		},
+	}
+
+	assert.Equal(t, []config.StorageParameter{
+		{Name: "autovacuum_enabled", Value: "false"},
+		{Name: "autovacuum_vacuum_scale_factor", Value: "0.05"},
+		{Name: "autovacuum_vacuum_threshold", Value: "1000000"},
+		{Name: "fillfactor", Value: "70"},
+	}, storage.Parameters())
+
+	var empty *config.Storage
+	assert.Empty(t, empty.Parameters())
+}
+
+func TestStorage_GetParameter(t *testing.T) {
+	storage := &config.Storage{Fillfactor: 70}
+
+	value, ok := storage.GetParameter("fillfactor")
+	assert.True(t, ok)
+	assert.Equal(t, "70", value)
+
+	_, ok = storage.GetParameter("autovacuum_enabled")
+	assert.False(t, ok)
+}
+
+func TestStorage_GetTablespace(t *testing.T) {
+	var empty *config.Storage
+	assert.Equal(t, "", empty.GetTablespace())
+	assert.False(t, empty.IsUnlogged())
+
+	storage := &config.Storage{Tablespace: "fast", Unlogged: true}
+	assert.Equal(t, "fast", storage.GetTablespace())
+	assert.True(t, storage.IsUnlogged())
+
+	storage = &config.Storage{Tablespace: config.DefaultTablespace}
+	assert.Equal(t, "", storage.GetTablespace())
+}
@@ -7,13 +7,19 @@ import (
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
 
 	goqu "github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/mysql"
 	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	_ "github.com/doug-martin/goqu/v9/dialect/sqlite3"
 	"github.com/fatih/color"
 	"github.com/iancoleman/strcase"
 )
 
 func GenerateQueries(schemas []*config.Schema) []*config.Function {
-	dialect := goqu.Dialect("postgres")
+	return GenerateQueriesWithDialect("postgres", schemas)
+}
+
+func GenerateQueriesWithDialect(name string, schemas []*config.Schema) []*config.Function {
+	dialect := goqu.Dialect(name)
 	var function []*config.Function
 	for _, element := range schemas {
 		fmt.Printf("🚀 Generating query for table: %s\n", element.Name)
@@ -291,3 +291,44 @@ func TestGenerateRestoreQuery_ReturnNil(t *testing.T) {
 	res := generator.GenerateRestoreQuery(dialect, element)
 	assert.Nil(t, res)
 }
+
+func TestGenerateQueriesWithDialect(t *testing.T) {
+	element := []*config.Schema{
+		{
+			Name: "user",
+			Fields: []*config.Field{
+				{
+					Name: "name",
+				},
+			},
+		},
+	}
+	tc := []*config.Function{
+		{
+			Name:      "FindUserById",
+			Query:     "SELECT * FROM `user` WHERE (`id` = ?)",
+			TableName: "user",
+			SqlcType:  ":one",
+		},
+		{
+			Name:      "UpdateUser",
+			Query:     "UPDATE `user` SET `name`=? WHERE (`id` = ?)",
+			TableName: "user",
+			SqlcType:  ":exec",
+		},
+		{
+			Name:      "CreateUser",
+			Query:     "INSERT INTO `user` (`name`) VALUES (?)",
+			TableName: "user",
+			SqlcType:  ":exec",
+		},
+		{
+			Name:      "DestroyUser",
+			Query:     "DELETE `user` FROM `user` WHERE (`id` = ?)",
+			TableName: "user",
+			SqlcType:  ":exec",
+		},
+	}
+	res := generator.GenerateQueriesWithDialect("mysql", element)
+	assert.Equal(t, tc, res)
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"gitlab.com/wartek-id/core/tools/dbgen/command"
@@ -20,10 +24,23 @@ func init() {
 	rootCmd.AddCommand(command.GenCode)
 	rootCmd.AddCommand(command.GenMigration)
 	rootCmd.AddCommand(command.DumpDbCmd)
+	rootCmd.AddCommand(command.DiffCmd)
+	rootCmd.AddCommand(command.CheckCmd)
+	rootCmd.AddCommand(command.DbMigrateCmd)
+	rootCmd.AddCommand(command.MigrateVerifyCmd)
+	rootCmd.AddCommand(command.MigrateHashCmd)
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// the first Ctrl-C cancels the running queries, the second one
+	// terminates the process as usual
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		log.Fatal(err)
 	}
 }
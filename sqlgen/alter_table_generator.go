@@ -2,6 +2,7 @@ package sqlgen
 
 import (
 	"bytes"
+	"fmt"
 
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dialect"
@@ -22,6 +23,8 @@ type alterTableGenerator struct {
 	dialect        string
 	esg            exp.ExpressionSQLGenerator
 	dialectOptions *dialect.DialectOption
+	ctg            CreateTableGenerator
+	cig            CreateIndexGenerator
 }
 
 func NewAlterTableGenerator(dialect string, do *dialect.DialectOption) AlterTableGenerator {
@@ -29,6 +32,8 @@ func NewAlterTableGenerator(dialect string, do *dialect.DialectOption) AlterTabl
 		dialect:        dialect,
 		dialectOptions: do,
 		esg:            exp.NewExpressionSQLGenerator(dialect, do),
+		ctg:            NewCreateTableGenerator(dialect, do),
+		cig:            NewCreateIndexGenerator(dialect, do),
 	}
 }
 
@@ -45,79 +50,77 @@ func (atg *alterTableGenerator) ExpressionSQLGenerator() exp.ExpressionSQLGenera
 }
 
 func (atg *alterTableGenerator) Generate(b sb.SQLBuilder, at *step.AlterSchema) error {
-	if !at.FieldChanged() {
+	if !at.FieldChanged() && !atg.isStorageChanged(at) {
 		return nil
 	}
 
-	atg.alterTableTemplate(b, at.Name)
-	queries := make([][]byte, 0)
-
-	if at.IsColumnsAdded() {
-		buf := sb.NewSQLBuilder()
-		atg.generateColumns(buf, at.AddedColumns)
-		queries = append(queries, buf.Bytes())
-	}
-
-	if at.IsColumnsDropped() {
-		buf := sb.NewSQLBuilder()
-		atg.dropColumns(buf, at.DroppedColumns)
-		queries = append(queries, buf.Bytes())
+	if atg.needRebuildTable(at) {
+		atg.rebuildTable(b, at.LastSchema, at.Schema, at.AddedIndices)
+		return nil
 	}
 
-	if at.IsColumnsAltered() {
-		buf := sb.NewSQLBuilder()
-		atg.alterColumns(buf, at.AlteredColumns)
-		queries = append(queries, buf.Bytes())
+	actions := atg.addColumns(at.AddedColumns)
+	actions = append(actions, atg.dropColumns(at.DroppedColumns)...)
+	actions = append(actions, atg.alterColumns(at.AlteredColumns)...)
+	if atg.isStorageChanged(at) {
+		actions = append(actions, atg.alterStorage(at.Storage)...)
 	}
 
-	b.Write(bytes.Join(queries, atg.dialectOptions.CommaNewLineFragment))
-	b.WriteRunes(atg.dialectOptions.SemiColonRune)
+	atg.writeAlterTable(b, at.Name, actions)
 	return nil
 }
 
-func (atg *alterTableGenerator) generateColumns(b sb.SQLBuilder, fields []*config.Field) {
-	for i, field := range fields {
+// addColumns returns one action per added column.
+func (atg *alterTableGenerator) addColumns(fields []*config.Field) [][]byte {
+	actions := make([][]byte, 0, len(fields))
+	for _, field := range fields {
+		b := sb.NewSQLBuilder()
 		b.WriteRunes(atg.dialectOptions.TabRune)
 		b.Write(atg.dialectOptions.AddColumnTemplate())
 		atg.ExpressionSQLGenerator().LiteralExpression(b, field.Name)
 		b.WriteRunes(atg.dialectOptions.SpaceRune)
 		b.Write(atg.ExpressionSQLGenerator().GetTypeFragment(field))
+		b.Write(atg.ExpressionSQLGenerator().GetCollationFragment(field))
 		b.Write(atg.ExpressionSQLGenerator().GetOptionsFragment(field))
-
-		if i != len(fields)-1 {
-			b.WriteRunes(atg.dialectOptions.CommaRune)
-			b.WriteRunes(atg.dialectOptions.NewLineRune)
-		}
+		actions = append(actions, b.Bytes())
 	}
+	return actions
 }
 
-func (atg *alterTableGenerator) dropColumns(b sb.SQLBuilder, fields []*config.Field) {
-	for i, field := range fields {
+// dropColumns returns one action per dropped column.
+func (atg *alterTableGenerator) dropColumns(fields []*config.Field) [][]byte {
+	actions := make([][]byte, 0, len(fields))
+	for _, field := range fields {
+		b := sb.NewSQLBuilder()
 		b.WriteRunes(atg.dialectOptions.TabRune)
 		b.Write(atg.dialectOptions.DropColumnTemplate())
 		atg.ExpressionSQLGenerator().LiteralExpression(b, field.Name)
-
-		if i != len(fields)-1 {
-			b.WriteRunes(atg.dialectOptions.CommaRune)
-			b.WriteRunes(atg.dialectOptions.NewLineRune)
-		}
+		actions = append(actions, b.Bytes())
 	}
+	return actions
 }
 
-func (atg *alterTableGenerator) alterColumns(b sb.SQLBuilder, fields []*step.AlterColumn) {
-	for i, field := range fields {
-		atg.alterColumn(b, field)
-		if i != len(fields)-1 {
-			b.Write(atg.dialectOptions.CommaNewLineFragment)
-		}
+func (atg *alterTableGenerator) alterColumns(fields []*step.AlterColumn) [][]byte {
+	actions := [][]byte{}
+	for _, field := range fields {
+		actions = append(actions, atg.alterColumn(field)...)
 	}
+	return actions
 }
 
-func (atg *alterTableGenerator) alterColumn(b sb.SQLBuilder, field *step.AlterColumn) {
+// alterColumn returns the actions changing the column, one per changed
+// attribute unless the column is redefined as a whole.
+func (atg *alterTableGenerator) alterColumn(field *step.AlterColumn) [][]byte {
+	if atg.needModifyColumn(field) {
+		b := sb.NewSQLBuilder()
+		atg.modifyColumn(b, field.Field)
+		return [][]byte{b.Bytes()}
+	}
+
 	changes := [][]byte{}
-	if field.ChangedType {
+	if field.ChangedType || field.ChangedCollation {
 		buf := sb.NewSQLBuilder()
-		atg.changeColumnType(buf, field.Field)
+		atg.changeColumnType(buf, field.Field, field.LastField, field.Field.Using)
 		changes = append(changes, buf.Bytes())
 	}
 
@@ -132,15 +135,86 @@ func (atg *alterTableGenerator) alterColumn(b sb.SQLBuilder, field *step.AlterCo
 		atg.changeColumnDefault(buf, field.Field)
 		changes = append(changes, buf.Bytes())
 	}
-
-	b.Write(bytes.Join(changes, atg.dialectOptions.CommaNewLineFragment))
+	return changes
 }
 
-func (atg *alterTableGenerator) changeColumnType(b sb.SQLBuilder, field *config.Field) {
+func (atg *alterTableGenerator) changeColumnType(b sb.SQLBuilder, field, from *config.Field, using string) {
 	atg.alterColumnTemplate(b, field.Name)
 	b.Write(atg.dialectOptions.SetFragment)
 	b.Write(atg.dialectOptions.DataTypeFragment)
 	b.Write(atg.ExpressionSQLGenerator().GetTypeFragment(field))
+	b.Write(atg.ExpressionSQLGenerator().GetCollationFragment(field))
+
+	if using == "" {
+		using = atg.castExpression(field, from)
+	}
+	if using != "" {
+		b.Write(atg.dialectOptions.UsingFragment)
+		b.WriteString(using)
+	}
+}
+
+// castExpression converts the column from its previous type when the dialect
+// cannot do it implicitly. Impossible casts are refused when planning, in both
+// directions, unless the field gives its using and down_using expressions.
+func (atg *alterTableGenerator) castExpression(field, from *config.Field) string {
+	if from == nil {
+		return ""
+	}
+
+	column := sb.NewSQLBuilder()
+	atg.ExpressionSQLGenerator().LiteralExpression(column, field.Name)
+	cast := atg.dialectOptions.Cast(from.Type, field.Type)
+	if cast.Kind == dialect.CastImplicit {
+		return ""
+	}
+	return castValue(atg.ExpressionSQLGenerator(), cast, column.String(), field)
+}
+
+// castValue formats the cast of the value into the type of the field, with
+// the default cast when the cast has no expression.
+func castValue(esg exp.ExpressionSQLGenerator, cast dialect.Cast, value string, field *config.Field) string {
+	if cast.Kind == dialect.CastImplicit {
+		return value
+	}
+
+	expression := cast.Expression
+	if expression == "" {
+		expression = dialect.DefaultCastExpression
+	}
+
+	target := *field
+	target.Type = dialect.BaseType(field.Type)
+	return fmt.Sprintf(expression, value, esg.GetTypeFragment(&target))
+}
+
+// needModifyColumn reports whether the column has to be redefined as a whole,
+// for dialects which cannot alter the column type or nullability in place.
+func (atg *alterTableGenerator) needModifyColumn(field *step.AlterColumn) bool {
+	return !atg.dialectOptions.SupportAlterColumnType &&
+		(field.ChangedType || field.ChangedCollation || field.IsOptionsChanged())
+}
+
+func (atg *alterTableGenerator) modifyColumn(b sb.SQLBuilder, field *config.Field) {
+	b.WriteRunes(atg.dialectOptions.TabRune)
+	b.Write(atg.dialectOptions.ModifyColumnTemplate())
+	atg.ExpressionSQLGenerator().LiteralExpression(b, field.Name)
+	b.WriteRunes(atg.dialectOptions.SpaceRune)
+	b.Write(atg.ExpressionSQLGenerator().GetTypeFragment(field))
+	b.Write(atg.ExpressionSQLGenerator().GetCollationFragment(field))
+
+	b.WriteRunes(atg.dialectOptions.SpaceRune)
+	if field.IsNotNull() {
+		b.Write(atg.dialectOptions.NotNullFragment)
+	} else {
+		b.Write(atg.dialectOptions.NullableFragment)
+	}
+
+	if field.Default != nil {
+		b.WriteRunes(atg.dialectOptions.SpaceRune)
+		b.Write(atg.dialectOptions.DefaultFragment)
+		b.Write(atg.ExpressionSQLGenerator().GetDefaultValue(field.Default))
+	}
 }
 
 func (atg *alterTableGenerator) changeColumnDefault(b sb.SQLBuilder, field *config.Field) {
@@ -162,46 +236,165 @@ func (atg *alterTableGenerator) ChangeColumnOptions(b sb.SQLBuilder, field *step
 }
 
 func (atg *alterTableGenerator) Rollback(b sb.SQLBuilder, at *step.AlterSchema) error {
-	atg.alterTableTemplate(b, at.Name)
-	queries := make([][]byte, 0)
+	if !at.FieldChanged() && !atg.isStorageChanged(at) {
+		return nil
+	}
 
-	if at.IsColumnsAdded() {
-		buf := sb.NewSQLBuilder()
-		atg.dropColumns(buf, at.AddedColumns)
-		queries = append(queries, buf.Bytes())
+	if atg.needRebuildTable(at) {
+		atg.rebuildTable(b, at.Schema, at.LastSchema, at.DroppedIndices)
+		return nil
 	}
 
-	if at.IsColumnsDropped() {
-		buf := sb.NewSQLBuilder()
-		atg.generateColumns(buf, at.DroppedColumns)
-		queries = append(queries, buf.Bytes())
+	actions := atg.dropColumns(at.AddedColumns)
+	actions = append(actions, atg.addColumns(at.DroppedColumns)...)
+	actions = append(actions, atg.rollbackAlterColumns(at.AlteredColumns)...)
+	if atg.isStorageChanged(at) {
+		actions = append(actions, atg.alterStorage(at.Storage.Reverse())...)
 	}
 
-	if at.IsColumnsAltered() {
-		buf := sb.NewSQLBuilder()
-		atg.rollbackAlterColumns(buf, at.AlteredColumns)
-		queries = append(queries, buf.Bytes())
+	atg.writeAlterTable(b, at.Name, actions)
+	return nil
+}
+
+// writeAlterTable writes the actions into a single ALTER TABLE statement,
+// or into one statement per action for dialects which only allow one.
+func (atg *alterTableGenerator) writeAlterTable(b sb.SQLBuilder, name string, actions [][]byte) {
+	if atg.dialectOptions.SupportMultipleAlterActions {
+		atg.alterTableTemplate(b, name)
+		b.Write(bytes.Join(actions, atg.dialectOptions.CommaNewLineFragment))
+		b.WriteRunes(atg.dialectOptions.SemiColonRune)
+		return
+	}
+
+	for i, action := range actions {
+		atg.alterTableTemplate(b, name)
+		b.Write(bytes.TrimSpace(action))
+		b.WriteRunes(atg.dialectOptions.SemiColonRune)
+		if i != len(actions)-1 {
+			b.WriteRunes(atg.dialectOptions.NewLineRune)
+		}
 	}
+}
+
+func (atg *alterTableGenerator) isStorageChanged(at *step.AlterSchema) bool {
+	return atg.dialectOptions.SupportStorage && at.IsStorageChanged()
+}
+
+// needRebuildTable reports whether the table has to be recreated, for dialects
+// which cannot alter an existing column at all.
+func (atg *alterTableGenerator) needRebuildTable(at *step.AlterSchema) bool {
+	return atg.dialectOptions.RebuildTableOnAlter &&
+		at.IsColumnsAltered() &&
+		at.Schema != nil && at.LastSchema != nil
+}
+
+// rebuildTable creates a new table with the target definition, copies the
+// shared columns from the existing table, drops it and renames the new one.
+// Indices are dropped along with the existing table, so the target indices are
+// recreated except the ones created separately by the migration.
+func (atg *alterTableGenerator) rebuildTable(b sb.SQLBuilder, from, target *config.Schema, skipIndices []*config.Index) {
+	newTable := *target
+	newTable.Name = atg.dialectOptions.RebuildTablePrefix + target.Name
+	atg.ctg.Generate(b, &newTable)
+	b.WriteRunes(atg.dialectOptions.NewLineRune)
 
-	b.Write(bytes.Join(queries, atg.dialectOptions.CommaNewLineFragment))
+	columns := make([]string, 0, len(target.Fields))
+	for _, field := range target.Fields {
+		if from.GetField(field.Name) != nil {
+			columns = append(columns, field.Name)
+		}
+	}
+
+	b.Write(atg.dialectOptions.InsertClause)
+	atg.ExpressionSQLGenerator().LiteralExpression(b, newTable.Name)
+	b.WriteRunes(atg.dialectOptions.SpaceRune, atg.dialectOptions.LeftParenRune)
+	atg.columnList(b, columns)
+	b.WriteRunes(atg.dialectOptions.RightParenRune, atg.dialectOptions.NewLineRune)
+	b.Write(atg.dialectOptions.SelectClause)
+	atg.selectList(b, from, target, columns)
+	b.Write(atg.dialectOptions.FromFragment)
+	atg.ExpressionSQLGenerator().LiteralExpression(b, from.Name)
+	b.WriteRunes(atg.dialectOptions.SemiColonRune, atg.dialectOptions.NewLineRune)
+
+	b.Write(atg.dialectOptions.DropClause)
+	b.Write(atg.dialectOptions.TableFragment)
+	atg.ExpressionSQLGenerator().LiteralExpression(b, from.Name)
+	b.WriteRunes(atg.dialectOptions.SemiColonRune, atg.dialectOptions.NewLineRune)
+
+	b.Write(atg.dialectOptions.AlterClause)
+	b.Write(atg.dialectOptions.TableFragment)
+	atg.ExpressionSQLGenerator().LiteralExpression(b, newTable.Name)
+	b.Write(atg.dialectOptions.RenameToFragment)
+	atg.ExpressionSQLGenerator().LiteralExpression(b, target.Name)
 	b.WriteRunes(atg.dialectOptions.SemiColonRune)
-	return nil
+
+	for _, idx := range target.Index {
+		if containsIndex(skipIndices, idx.Name) {
+			continue
+		}
+
+		b.WriteRunes(atg.dialectOptions.NewLineRune)
+		atg.cig.Generate(b, target.Name, idx)
+	}
+}
+
+func (atg *alterTableGenerator) columnList(b sb.SQLBuilder, columns []string) {
+	for i, column := range columns {
+		atg.ExpressionSQLGenerator().LiteralExpression(b, column)
+		if i != len(columns)-1 {
+			b.WriteRunes(atg.dialectOptions.CommaRune, atg.dialectOptions.SpaceRune)
+		}
+	}
 }
 
-func (atg *alterTableGenerator) rollbackAlterColumns(b sb.SQLBuilder, fields []*step.AlterColumn) {
-	for i, field := range fields {
-		atg.rollbackAlterColumn(b, field)
-		if i != len(fields)-1 {
-			b.Write(atg.dialectOptions.CommaNewLineFragment)
+// selectList copies the columns, converted by their USING expression when
+// their type is changed.
+func (atg *alterTableGenerator) selectList(b sb.SQLBuilder, from, target *config.Schema, columns []string) {
+	for i, column := range columns {
+		field := target.GetField(column)
+		if field.Using != "" && !isSameType(field, from.GetField(column)) {
+			b.WriteString(field.Using)
+		} else {
+			atg.ExpressionSQLGenerator().LiteralExpression(b, column)
+		}
+		if i != len(columns)-1 {
+			b.WriteRunes(atg.dialectOptions.CommaRune, atg.dialectOptions.SpaceRune)
 		}
 	}
 }
 
-func (atg *alterTableGenerator) rollbackAlterColumn(b sb.SQLBuilder, field *step.AlterColumn) {
+func isSameType(field, other *config.Field) bool {
+	return field.Type == other.Type && field.Limit == other.Limit && field.Scale == other.Scale
+}
+
+func containsIndex(indices []*config.Index, name string) bool {
+	for _, idx := range indices {
+		if idx.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (atg *alterTableGenerator) rollbackAlterColumns(fields []*step.AlterColumn) [][]byte {
+	actions := [][]byte{}
+	for _, field := range fields {
+		actions = append(actions, atg.rollbackAlterColumn(field)...)
+	}
+	return actions
+}
+
+func (atg *alterTableGenerator) rollbackAlterColumn(field *step.AlterColumn) [][]byte {
+	if atg.needModifyColumn(field) {
+		b := sb.NewSQLBuilder()
+		atg.modifyColumn(b, field.LastField)
+		return [][]byte{b.Bytes()}
+	}
+
 	changes := [][]byte{}
-	if field.ChangedType {
+	if field.ChangedType || field.ChangedCollation {
 		buf := sb.NewSQLBuilder()
-		atg.changeColumnType(buf, field.LastField)
+		atg.changeColumnType(buf, field.LastField, field.Field, field.Field.DownUsing)
 		changes = append(changes, buf.Bytes())
 	}
 
@@ -216,8 +409,7 @@ func (atg *alterTableGenerator) rollbackAlterColumn(b sb.SQLBuilder, field *step
 		atg.changeColumnDefault(buf, field.LastField)
 		changes = append(changes, buf.Bytes())
 	}
-
-	b.Write(bytes.Join(changes, atg.dialectOptions.CommaNewLineFragment))
+	return changes
 }
 
 func (atg *alterTableGenerator) rollbackChangeColumnOptions(b sb.SQLBuilder, field *step.AlterColumn) {
@@ -231,6 +423,52 @@ func (atg *alterTableGenerator) rollbackChangeColumnOptions(b sb.SQLBuilder, fie
 	}
 }
 
+func (atg *alterTableGenerator) alterStorage(storage *step.AlterStorage) [][]byte {
+	actions := [][]byte{}
+	if params := storage.SetParameters(); len(params) > 0 {
+		buf := sb.NewSQLBuilder()
+		buf.WriteRunes(atg.dialectOptions.TabRune)
+		buf.Write(atg.dialectOptions.SetFragment)
+		buf.Write(atg.ExpressionSQLGenerator().GetStorageParametersFragment(params, true))
+		actions = append(actions, buf.Bytes())
+	}
+
+	if params := storage.ResetParameters(); len(params) > 0 {
+		buf := sb.NewSQLBuilder()
+		buf.WriteRunes(atg.dialectOptions.TabRune)
+		buf.Write(atg.dialectOptions.ResetFragment)
+		buf.Write(atg.ExpressionSQLGenerator().GetStorageParametersFragment(params, false))
+		actions = append(actions, buf.Bytes())
+	}
+
+	if storage.IsTablespaceChanged() {
+		tablespace := storage.Storage.GetTablespace()
+		if tablespace == "" {
+			tablespace = atg.dialectOptions.DefaultTablespace
+		}
+
+		buf := sb.NewSQLBuilder()
+		buf.WriteRunes(atg.dialectOptions.TabRune)
+		buf.Write(atg.dialectOptions.SetFragment)
+		buf.Write(atg.dialectOptions.TablespaceFragment)
+		atg.ExpressionSQLGenerator().LiteralExpression(buf, tablespace)
+		actions = append(actions, buf.Bytes())
+	}
+
+	if storage.IsUnloggedChanged() {
+		buf := sb.NewSQLBuilder()
+		buf.WriteRunes(atg.dialectOptions.TabRune)
+		buf.Write(atg.dialectOptions.SetFragment)
+		if storage.Storage.IsUnlogged() {
+			buf.Write(atg.dialectOptions.UnloggedFragment)
+		} else {
+			buf.Write(atg.dialectOptions.LoggedFragment)
+		}
+		actions = append(actions, buf.Bytes())
+	}
+	return actions
+}
+
 func (atg *alterTableGenerator) dropNotNull(b sb.SQLBuilder, name string) {
 	atg.alterColumnTemplate(b, name)
 	b.Write(atg.dialectOptions.DropFragment)
@@ -253,7 +491,13 @@ func (atg *alterTableGenerator) alterColumnTemplate(b sb.SQLBuilder, name string
 func (atg *alterTableGenerator) alterTableTemplate(b sb.SQLBuilder, name string) {
 	b.Write(atg.dialectOptions.AlterClause)
 	b.Write(atg.dialectOptions.TableFragment)
-	b.Write(atg.dialectOptions.IfExistsFragment)
+	if atg.dialectOptions.SupportIfExistsOnAlter {
+		b.Write(atg.dialectOptions.IfExistsFragment)
+	}
 	atg.ExpressionSQLGenerator().LiteralExpression(b, name)
-	b.WriteRunes(atg.dialectOptions.NewLineRune)
+	if atg.dialectOptions.SupportMultipleAlterActions {
+		b.WriteRunes(atg.dialectOptions.NewLineRune)
+	} else {
+		b.WriteRunes(atg.dialectOptions.SpaceRune)
+	}
 }
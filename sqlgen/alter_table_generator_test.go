@@ -213,3 +213,319 @@ func TestAlterSchemaGenerator_Rollback(t *testing.T) {
 	)
 	assert.Equal(t, result, buf.String())
 }
+
+func TestAlterSchemaGenerator_GenerateStorage(t *testing.T) {
+	alterStep := step.AlterSchema{
+		Name: "users",
+		AlteredColumns: []*step.AlterColumn{
+			{
+				Name: "email",
+				Field: &config.Field{
+					Name:      "email",
+					Type:      field_type.Varchar,
+					Limit:     100,
+					Collation: "und-x-icu",
+				},
+				LastField: &config.Field{
+					Name:  "email",
+					Type:  field_type.Varchar,
+					Limit: 100,
+				},
+				ChangedCollation: true,
+			},
+		},
+		Storage: &step.AlterStorage{
+			Storage: &config.Storage{
+				Fillfactor: 70,
+				Tablespace: "fast",
+			},
+			LastStorage: &config.Storage{
+				Autovacuum: map[string]interface{}{
+					"enabled": "false",
+				},
+				Unlogged: true,
+			},
+		},
+	}
+
+	gen := sqlgen.NewAlterTableGenerator("postgres", dialect.DefaultDialectOption())
+	buf := sb.NewSQLBuilder()
+	gen.Generate(buf, &alterStep)
+	result := fmt.Sprintf("%s\n%s,\n%s,\n%s,\n%s,\n%s;",
+		"ALTER TABLE IF EXISTS \"users\"",
+		"\tALTER COLUMN \"email\" SET DATA TYPE VARCHAR(100) COLLATE \"und-x-icu\"",
+		"\tSET (fillfactor=70)",
+		"\tRESET (autovacuum_enabled)",
+		"\tSET TABLESPACE \"fast\"",
+		"\tSET LOGGED",
+	)
+	assert.Equal(t, result, buf.String())
+
+	buf = sb.NewSQLBuilder()
+	gen.Rollback(buf, &alterStep)
+	result = fmt.Sprintf("%s\n%s,\n%s,\n%s,\n%s,\n%s;",
+		"ALTER TABLE IF EXISTS \"users\"",
+		"\tALTER COLUMN \"email\" SET DATA TYPE VARCHAR(100)",
+		"\tSET (autovacuum_enabled=false)",
+		"\tRESET (fillfactor)",
+		"\tSET TABLESPACE \"pg_default\"",
+		"\tSET UNLOGGED",
+	)
+	assert.Equal(t, result, buf.String())
+}
+
+func TestAlterSchemaGenerator_GenerateMySQL(t *testing.T) {
+	alterStep := step.AlterSchema{
+		Name: "users",
+		AddedColumns: []*config.Field{
+			{
+				Name:  "location",
+				Type:  field_type.Varchar,
+				Limit: 50,
+			},
+		},
+		AlteredColumns: []*step.AlterColumn{
+			{
+				Name: "name",
+				Field: &config.Field{
+					Name:    "name",
+					Type:    field_type.Varchar,
+					Limit:   200,
+					Default: "Alfred",
+					Options: []field_option.FieldOption{
+						field_option.NotNull,
+					},
+				},
+				ChangedType: true,
+				ChangedOptions: []step.OptionAction{
+					step.SetNotNull,
+				},
+			},
+		},
+	}
+
+	gen := sqlgen.NewAlterTableGenerator("mysql", dialect.MySQLDialectOption())
+	buf := sb.NewSQLBuilder()
+	gen.Generate(buf, &alterStep)
+	result := fmt.Sprintf("%s\n%s,\n%s;",
+		"ALTER TABLE `users`",
+		"\tADD COLUMN `location` VARCHAR(50)",
+		"\tMODIFY COLUMN `name` VARCHAR(200) NOT NULL DEFAULT 'Alfred'",
+	)
+	assert.Equal(t, result, buf.String())
+}
+
+func TestAlterSchemaGenerator_GenerateSQLite(t *testing.T) {
+	alterStep := step.AlterSchema{
+		Name: "users",
+		AddedColumns: []*config.Field{
+			{Name: "location", Type: field_type.Varchar, Limit: 50},
+		},
+		DroppedColumns: []*config.Field{
+			{Name: "stock", Type: field_type.Int},
+		},
+	}
+
+	gen := sqlgen.NewAlterTableGenerator("sqlite", dialect.SQLiteDialectOption())
+	buf := sb.NewSQLBuilder()
+	gen.Generate(buf, &alterStep)
+	result := fmt.Sprintf("%s\n%s",
+		"ALTER TABLE \"users\" ADD COLUMN \"location\" VARCHAR(50);",
+		"ALTER TABLE \"users\" DROP COLUMN \"stock\";",
+	)
+	assert.Equal(t, result, buf.String())
+}
+
+func TestAlterSchemaGenerator_GenerateSingleAction(t *testing.T) {
+	alterStep := step.AlterSchema{
+		Name: "users",
+		AlteredColumns: []*step.AlterColumn{
+			{
+				Name:                "note",
+				Field:               &config.Field{Name: "note", Type: field_type.Text, Default: "first,\nsecond"},
+				LastField:           &config.Field{Name: "note", Type: field_type.Text},
+				ChangedDefaultValue: true,
+			},
+		},
+		DroppedColumns: []*config.Field{
+			{Name: "stock", Type: field_type.Int},
+		},
+	}
+
+	do := dialect.DefaultDialectOption()
+	do.SupportMultipleAlterActions = false
+	gen := sqlgen.NewAlterTableGenerator("postgres", do)
+	buf := sb.NewSQLBuilder()
+	gen.Generate(buf, &alterStep)
+	result := fmt.Sprintf("%s\n%s",
+		"ALTER TABLE IF EXISTS \"users\" DROP COLUMN \"stock\";",
+		"ALTER TABLE IF EXISTS \"users\" ALTER COLUMN \"note\" SET DEFAULT 'first,\nsecond';",
+	)
+	assert.Equal(t, result, buf.String())
+}
+
+func TestAlterSchemaGenerator_RebuildSQLite(t *testing.T) {
+	lastSchema := &config.Schema{
+		Name: "users",
+		Fields: []*config.Field{
+			{Name: "id", Type: field_type.BigSerial, Options: []field_option.FieldOption{field_option.PrimaryKey}},
+			{Name: "name", Type: field_type.Varchar, Limit: 100},
+			{Name: "stock", Type: field_type.Int},
+		},
+		Index: []*config.Index{
+			{Name: "index_on_name", Fields: []*config.IndexField{{Column: "name", Order: "ASC"}}},
+		},
+	}
+	schema := &config.Schema{
+		Name: "users",
+		Fields: []*config.Field{
+			{Name: "id", Type: field_type.BigSerial, Options: []field_option.FieldOption{field_option.PrimaryKey}},
+			{Name: "name", Type: field_type.Varchar, Limit: 200, Options: []field_option.FieldOption{field_option.NotNull}},
+			{Name: "location", Type: field_type.Varchar, Limit: 50},
+		},
+		Index: []*config.Index{
+			{Name: "index_on_name", Fields: []*config.IndexField{{Column: "name", Order: "ASC"}}},
+		},
+	}
+	alterStep := step.AlterSchema{
+		Name:           "users",
+		AddedColumns:   []*config.Field{schema.Fields[2]},
+		DroppedColumns: []*config.Field{lastSchema.Fields[2]},
+		AlteredColumns: []*step.AlterColumn{
+			{
+				Name:           "name",
+				Field:          schema.Fields[1],
+				LastField:      lastSchema.Fields[1],
+				ChangedType:    true,
+				ChangedOptions: []step.OptionAction{step.SetNotNull},
+			},
+		},
+		Schema:     schema,
+		LastSchema: lastSchema,
+	}
+
+	gen := sqlgen.NewAlterTableGenerator("sqlite", dialect.SQLiteDialectOption())
+	buf := sb.NewSQLBuilder()
+	gen.Generate(buf, &alterStep)
+	result := fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s",
+		"CREATE TABLE IF NOT EXISTS \"_new_users\" (\n\t\"id\" INTEGER PRIMARY KEY,\n\t\"name\" VARCHAR(200) NOT NULL,\n\t\"location\" VARCHAR(50)\n);",
+		"INSERT INTO \"_new_users\" (\"id\", \"name\")",
+		"SELECT \"id\", \"name\" FROM \"users\";",
+		"DROP TABLE \"users\";",
+		"ALTER TABLE \"_new_users\" RENAME TO \"users\";",
+		"CREATE INDEX IF NOT EXISTS \"index_on_name\" ON \"users\"(\"name\" ASC);",
+	)
+	assert.Equal(t, result, buf.String())
+
+	buf = sb.NewSQLBuilder()
+	gen.Rollback(buf, &alterStep)
+	result = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s",
+		"CREATE TABLE IF NOT EXISTS \"_new_users\" (\n\t\"id\" INTEGER PRIMARY KEY,\n\t\"name\" VARCHAR(100),\n\t\"stock\" INT\n);",
+		"INSERT INTO \"_new_users\" (\"id\", \"name\")",
+		"SELECT \"id\", \"name\" FROM \"users\";",
+		"DROP TABLE \"users\";",
+		"ALTER TABLE \"_new_users\" RENAME TO \"users\";",
+		"CREATE INDEX IF NOT EXISTS \"index_on_name\" ON \"users\"(\"name\" ASC);",
+	)
+	assert.Equal(t, result, buf.String())
+}
+
+func TestAlterSchemaGenerator_GenerateCast(t *testing.T) {
+	testCases := map[string]struct {
+		from     *config.Field
+		to       *config.Field
+		up       string
+		rollback string
+	}{
+		"implicit": {
+			from:     &config.Field{Name: "stock", Type: field_type.Int},
+			to:       &config.Field{Name: "stock", Type: field_type.BigInt},
+			up:       "ALTER COLUMN \"stock\" SET DATA TYPE BIGINT",
+			rollback: "ALTER COLUMN \"stock\" SET DATA TYPE INT",
+		},
+		"varchar to int": {
+			from:     &config.Field{Name: "stock", Type: field_type.Varchar, Limit: 10},
+			to:       &config.Field{Name: "stock", Type: field_type.Int},
+			up:       "ALTER COLUMN \"stock\" SET DATA TYPE INT USING \"stock\"::INT",
+			rollback: "ALTER COLUMN \"stock\" SET DATA TYPE VARCHAR(10)",
+		},
+		"int to bool": {
+			from:     &config.Field{Name: "stock", Type: field_type.Int},
+			to:       &config.Field{Name: "stock", Type: field_type.Boolean},
+			up:       "ALTER COLUMN \"stock\" SET DATA TYPE BOOLEAN USING \"stock\" <> 0",
+			rollback: "ALTER COLUMN \"stock\" SET DATA TYPE INT USING CASE WHEN \"stock\" THEN 1 ELSE 0 END",
+		},
+		"json to jsonb": {
+			from:     &config.Field{Name: "stock", Type: field_type.Json},
+			to:       &config.Field{Name: "stock", Type: field_type.Jsonb},
+			up:       "ALTER COLUMN \"stock\" SET DATA TYPE JSONB",
+			rollback: "ALTER COLUMN \"stock\" SET DATA TYPE JSON",
+		},
+		"jsonb to bigserial": {
+			from:     &config.Field{Name: "stock", Type: field_type.Jsonb},
+			to:       &config.Field{Name: "stock", Type: field_type.BigSerial},
+			up:       "ALTER COLUMN \"stock\" SET DATA TYPE BIGSERIAL USING (\"stock\" #>> '{}')::BIGINT",
+			rollback: "ALTER COLUMN \"stock\" SET DATA TYPE JSONB USING to_jsonb(\"stock\")",
+		},
+		"user expression": {
+			from:     &config.Field{Name: "stock", Type: field_type.Varchar, Limit: 10},
+			to:       &config.Field{Name: "stock", Type: field_type.Int, Using: "NULLIF(\"stock\", '')::INT"},
+			up:       "ALTER COLUMN \"stock\" SET DATA TYPE INT USING NULLIF(\"stock\", '')::INT",
+			rollback: "ALTER COLUMN \"stock\" SET DATA TYPE VARCHAR(10)",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			alterStep := step.AlterSchema{
+				Name: "products",
+				AlteredColumns: []*step.AlterColumn{
+					{Name: "stock", Field: tc.to, LastField: tc.from, ChangedType: true},
+				},
+			}
+
+			gen := sqlgen.NewAlterTableGenerator("postgres", dialect.DefaultDialectOption())
+			buf := sb.NewSQLBuilder()
+			gen.Generate(buf, &alterStep)
+			assert.Equal(t, fmt.Sprintf("ALTER TABLE IF EXISTS \"products\"\n\t%s;", tc.up), buf.String())
+
+			buf = sb.NewSQLBuilder()
+			gen.Rollback(buf, &alterStep)
+			assert.Equal(t, fmt.Sprintf("ALTER TABLE IF EXISTS \"products\"\n\t%s;", tc.rollback), buf.String())
+		})
+	}
+}
+
+func TestAlterSchemaGenerator_RebuildSQLiteUsing(t *testing.T) {
+	lastSchema := &config.Schema{
+		Name: "products",
+		Fields: []*config.Field{
+			{Name: "id", Type: field_type.Int},
+			{Name: "stock", Type: field_type.Varchar, Limit: 10},
+		},
+	}
+	schema := &config.Schema{
+		Name: "products",
+		Fields: []*config.Field{
+			{Name: "id", Type: field_type.Int},
+			{Name: "stock", Type: field_type.Int, Using: "CAST(\"stock\" AS INTEGER)"},
+		},
+	}
+	alterStep := step.AlterSchema{
+		Name: "products",
+		AlteredColumns: []*step.AlterColumn{
+			{Name: "stock", Field: schema.Fields[1], LastField: lastSchema.Fields[1], ChangedType: true},
+		},
+		Schema:     schema,
+		LastSchema: lastSchema,
+	}
+
+	gen := sqlgen.NewAlterTableGenerator("sqlite", dialect.SQLiteDialectOption())
+	buf := sb.NewSQLBuilder()
+	gen.Generate(buf, &alterStep)
+	assert.Contains(t, buf.String(), "SELECT \"id\", CAST(\"stock\" AS INTEGER) FROM \"products\";")
+
+	buf = sb.NewSQLBuilder()
+	gen.Rollback(buf, &alterStep)
+	assert.Contains(t, buf.String(), "SELECT \"id\", \"stock\" FROM \"products\";")
+}
@@ -53,7 +53,9 @@ func (cig *createIndexGenerator) Generate(b sb.SQLBuilder, tblName string, idx *
 			WriteRunes(cig.dialectOptions.SpaceRune)
 	}
 
-	b.Write(cig.dialectOptions.IfNotExistsFragment)
+	if cig.dialectOptions.SupportIfExistsOnIndex {
+		b.Write(cig.dialectOptions.IfNotExistsFragment)
+	}
 	cig.ExpressionSQLGenerator().LiteralExpression(b, idx.Name)
 	b.Write(cig.dialectOptions.OnFragment)
 	cig.ExpressionSQLGenerator().LiteralExpression(b, tblName)
@@ -37,8 +37,12 @@ func (ctg *createTableGenerator) DialectOptions() *dialect.DialectOption {
 }
 
 func (ctg *createTableGenerator) Generate(b sb.SQLBuilder, schema *config.Schema) {
-	b.Write(ctg.dialectOptions.CreateClause).
-		Write(ctg.dialectOptions.TableFragment).
+	b.Write(ctg.dialectOptions.CreateClause)
+	if ctg.dialectOptions.SupportStorage && schema.Storage.IsUnlogged() {
+		b.Write(ctg.dialectOptions.UnloggedFragment).
+			WriteRunes(ctg.dialectOptions.SpaceRune)
+	}
+	b.Write(ctg.dialectOptions.TableFragment).
 		Write(ctg.dialectOptions.IfNotExistsFragment)
 
 	ctg.ExpressionSQLGenerator().LiteralExpression(b, schema.Name)
@@ -49,15 +53,35 @@ func (ctg *createTableGenerator) Generate(b sb.SQLBuilder, schema *config.Schema
 	ctg.FieldSQL(b, schema.Fields)
 	b.WriteRunes(ctg.dialectOptions.NewLineRune)
 	b.WriteRunes(ctg.dialectOptions.RightParenRune)
+	ctg.StorageSQL(b, schema.Storage)
 	b.WriteRunes(ctg.dialectOptions.SemiColonRune)
 }
 
+func (ctg *createTableGenerator) StorageSQL(b sb.SQLBuilder, storage *config.Storage) {
+	if !ctg.dialectOptions.SupportStorage {
+		return
+	}
+
+	params := storage.Parameters()
+	if len(params) > 0 {
+		b.Write(ctg.dialectOptions.WithFragment)
+		b.Write(ctg.ExpressionSQLGenerator().GetStorageParametersFragment(params, true))
+	}
+
+	if storage.GetTablespace() != "" {
+		b.WriteRunes(ctg.dialectOptions.SpaceRune)
+		b.Write(ctg.dialectOptions.TablespaceFragment)
+		ctg.ExpressionSQLGenerator().LiteralExpression(b, storage.GetTablespace())
+	}
+}
+
 func (ctg *createTableGenerator) FieldSQL(b sb.SQLBuilder, fields []*config.Field) {
 	for i, field := range fields {
 		b.WriteRunes(ctg.dialectOptions.TabRune)
 		ctg.ExpressionSQLGenerator().LiteralExpression(b, field.Name)
 		b.WriteRunes(ctg.dialectOptions.SpaceRune)
 		b.Write(ctg.esg.GetTypeFragment(field))
+		b.Write(ctg.esg.GetCollationFragment(field))
 		b.Write(ctg.esg.GetOptionsFragment(field))
 
 		if i != len(fields)-1 {
@@ -79,6 +79,69 @@ func TestCreateTableGenerator_Generate(t *testing.T) {
 			},
 			result: "CREATE TABLE IF NOT EXISTS \"user\" (\n\t\"id\" BIGSERIAL NOT NULL,\n\t\"name\" VARCHAR(255) NOT NULL,\n\t\"school\" VARCHAR(100) NULL,\n\t\"salary\" DECIMAL(5, 2)\n);",
 		},
+		{
+			dialect: dialect.DefaultDialectOption(),
+			input: &config.Schema{
+				Name: "sessions",
+				Fields: []*config.Field{
+					{
+						Name:      "token",
+						Type:      "varchar",
+						Limit:     100,
+						Collation: "C",
+						Options: []field_option.FieldOption{
+							field_option.NotNull,
+						},
+					},
+				},
+				Storage: &config.Storage{
+					Fillfactor: 70,
+					Autovacuum: map[string]interface{}{
+						"enabled": false,
+					},
+					Tablespace: "fast",
+					Unlogged:   true,
+				},
+			},
+			result: "CREATE UNLOGGED TABLE IF NOT EXISTS \"sessions\" (\n\t\"token\" VARCHAR(100) COLLATE \"C\" NOT NULL\n) WITH (autovacuum_enabled=false, fillfactor=70) TABLESPACE \"fast\";",
+		},
+		{
+			dialect: dialect.MySQLDialectOption(),
+			input: &config.Schema{
+				Name: "user",
+				Fields: []*config.Field{
+					{
+						Name: "id",
+						Type: "bigserial",
+						Options: []field_option.FieldOption{
+							field_option.NotNull,
+						},
+					},
+					{
+						Name: "created_at",
+						Type: "timestamptz",
+					},
+				},
+			},
+			result: "CREATE TABLE IF NOT EXISTS `user` (\n\t`id` BIGINT AUTO_INCREMENT NOT NULL,\n\t`created_at` TIMESTAMP\n);",
+		},
+		{
+			dialect: dialect.CockroachDialectOption(),
+			input: &config.Schema{
+				Name: "user",
+				Fields: []*config.Field{
+					{
+						Name: "id",
+						Type: "bigserial",
+						Options: []field_option.FieldOption{
+							field_option.PrimaryKey,
+						},
+					},
+				},
+				Storage: &config.Storage{Fillfactor: 70},
+			},
+			result: "CREATE TABLE IF NOT EXISTS \"user\" (\n\t\"id\" INT8 DEFAULT unique_rowid() PRIMARY KEY\n);",
+		},
 	}
 
 	for _, tc := range testCases {
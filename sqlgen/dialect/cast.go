@@ -0,0 +1,114 @@
+package dialect
+
+import "gitlab.com/wartek-id/core/tools/dbgen/types/field_type"
+
+type CastKind int
+
+const (
+	// CastImplicit conversions are done by the database when the column type
+	// is changed.
+	CastImplicit CastKind = iota
+	// CastUsing conversions need a USING expression.
+	CastUsing
+	// CastImpossible conversions have no sensible expression, the schema has to
+	// specify one.
+	CastImpossible
+)
+
+// DefaultCastExpression casts the column into the new type.
+const DefaultCastExpression = "%[1]s::%[2]s"
+
+// Cast tells how a column is converted into a new type. Expression is a format
+// where %[1]s is the quoted column and %[2]s the new type.
+type Cast struct {
+	Kind       CastKind
+	Expression string
+}
+
+type CastLookup map[field_type.FieldType]map[field_type.FieldType]Cast
+
+var (
+	integerTypes   = []field_type.FieldType{field_type.SmallInt, field_type.Int, field_type.BigInt}
+	numericTypes   = []field_type.FieldType{field_type.SmallInt, field_type.Int, field_type.BigInt, field_type.Float, field_type.Decimal}
+	stringTypes    = []field_type.FieldType{field_type.Varchar, field_type.Text}
+	jsonTypes      = []field_type.FieldType{field_type.Json, field_type.Jsonb}
+	timestampTypes = []field_type.FieldType{field_type.Timestamp, field_type.Timestamptz}
+)
+
+// PostgresCasts follows the casts of pg_cast: numbers and timestamps convert
+// between themselves and anything converts into a string, everything else
+// needs an explicit cast. Numbers and timestamps have no conversion between
+// them.
+func PostgresCasts() CastLookup {
+	casts := make(CastLookup)
+	set := func(from, to []field_type.FieldType, cast Cast) {
+		for _, f := range from {
+			if casts[f] == nil {
+				casts[f] = make(map[field_type.FieldType]Cast)
+			}
+			for _, t := range to {
+				if f != t {
+					casts[f][t] = cast
+				}
+			}
+		}
+	}
+
+	all := append(append(append(append([]field_type.FieldType{field_type.Boolean}, numericTypes...), stringTypes...), jsonTypes...), timestampTypes...)
+	using := Cast{Kind: CastUsing, Expression: DefaultCastExpression}
+	impossible := Cast{Kind: CastImpossible}
+
+	set(all, stringTypes, Cast{Kind: CastImplicit})
+	set(numericTypes, numericTypes, Cast{Kind: CastImplicit})
+	set(timestampTypes, timestampTypes, Cast{Kind: CastImplicit})
+
+	set(stringTypes, all, using)
+	set(stringTypes, stringTypes, Cast{Kind: CastImplicit})
+	set(jsonTypes, jsonTypes, Cast{Kind: CastImplicit})
+
+	set(numericTypes, []field_type.FieldType{field_type.Boolean}, Cast{Kind: CastUsing, Expression: "%[1]s <> 0"})
+	set([]field_type.FieldType{field_type.Boolean}, numericTypes, Cast{Kind: CastUsing, Expression: "CASE WHEN %[1]s THEN 1 ELSE 0 END"})
+	set(numericTypes, []field_type.FieldType{field_type.Json}, Cast{Kind: CastUsing, Expression: "to_json(%[1]s)"})
+	set(numericTypes, []field_type.FieldType{field_type.Jsonb}, Cast{Kind: CastUsing, Expression: "to_jsonb(%[1]s)"})
+	set([]field_type.FieldType{field_type.Boolean}, []field_type.FieldType{field_type.Json}, Cast{Kind: CastUsing, Expression: "to_json(%[1]s)"})
+	set([]field_type.FieldType{field_type.Boolean}, []field_type.FieldType{field_type.Jsonb}, Cast{Kind: CastUsing, Expression: "to_jsonb(%[1]s)"})
+	set(timestampTypes, []field_type.FieldType{field_type.Json}, Cast{Kind: CastUsing, Expression: "to_json(%[1]s)"})
+	set(timestampTypes, []field_type.FieldType{field_type.Jsonb}, Cast{Kind: CastUsing, Expression: "to_jsonb(%[1]s)"})
+
+	// a JSON scalar is unwrapped into text before being cast
+	set(jsonTypes, append(append([]field_type.FieldType{field_type.Boolean}, numericTypes...), timestampTypes...), Cast{Kind: CastUsing, Expression: "(%[1]s #>> '{}')::%[2]s"})
+
+	set(numericTypes, timestampTypes, impossible)
+	set(timestampTypes, numericTypes, impossible)
+	set([]field_type.FieldType{field_type.Boolean}, timestampTypes, impossible)
+	set(timestampTypes, []field_type.FieldType{field_type.Boolean}, impossible)
+	return casts
+}
+
+// BaseType returns the type storing the values of a serial type.
+func BaseType(ft field_type.FieldType) field_type.FieldType {
+	switch ft {
+	case field_type.SmallSerial:
+		return field_type.SmallInt
+	case field_type.Serial:
+		return field_type.Int
+	case field_type.BigSerial:
+		return field_type.BigInt
+	}
+	return ft
+}
+
+// Cast returns how a column is converted from one type into another, all the
+// conversions are implicit for dialects without a cast lookup.
+func (do *DialectOption) Cast(from, to field_type.FieldType) Cast {
+	from, to = BaseType(from), BaseType(to)
+	if from == to || do.CastLookup == nil {
+		return Cast{Kind: CastImplicit}
+	}
+
+	cast, ok := do.CastLookup[from][to]
+	if !ok {
+		return Cast{Kind: CastImpossible}
+	}
+	return cast
+}
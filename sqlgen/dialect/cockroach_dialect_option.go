@@ -0,0 +1,22 @@
+package dialect
+
+func CockroachDialectOption() *DialectOption {
+	do := DefaultDialectOption()
+
+	// serial columns are backed by unique_rowid(), which only fits a 64 bit
+	// integer, the narrower serials would be crawled back as bigserial
+	do.SmallSerialFragment = nil
+	do.SerialFragment = nil
+	do.BigSerialFragment = []byte("INT8 DEFAULT unique_rowid()")
+
+	// schema changes are not allowed inside explicit transactions, and indexes
+	// are always built online so CONCURRENTLY is never needed
+	do.SupportTransaction = false
+	do.SupportConcurrently = false
+	do.SupportStorage = false
+	do.SupportOnline = false
+	do.SupportLockAnalysis = false
+
+	do.BuildLookups()
+	return do
+}
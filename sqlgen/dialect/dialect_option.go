@@ -13,6 +13,10 @@ type DialectOption struct {
 	AlterClause  []byte
 	BeginClause  []byte
 	CommitClause []byte
+	GrantClause  []byte
+	RevokeClause []byte
+	InsertClause []byte
+	SelectClause []byte
 
 	IndexFragment []byte
 	TableFragment []byte
@@ -24,9 +28,23 @@ type DialectOption struct {
 	SetFragment      []byte
 	DefaultFragment  []byte
 	DataTypeFragment []byte
+	ResetFragment    []byte
+	CollateFragment  []byte
+	UsingFragment    []byte
+
+	ConstraintFragment []byte
+	CheckFragment      []byte
+	NotValidFragment   []byte
+	ValidateFragment   []byte
+	IsNotNullFragment  []byte
+	LockTimeoutClause  []byte
+	LockCommentClause  []byte
+	TriggerFragment    []byte
+	FunctionFragment   []byte
 
 	BooleanFragment     []byte
 	VarcharFragment     []byte
+	TextFragment        []byte
 	SmallIntFragment    []byte
 	IntFragment         []byte
 	BigIntFragment      []byte
@@ -51,24 +69,55 @@ type DialectOption struct {
 	IfExistsFragment      []byte
 	AutoIncrementFragment []byte
 
-	EmptyFragment        []byte
-	OnFragment           []byte
+	EmptyFragment      []byte
+	OnFragment         []byte
+	ToFragment         []byte
+	FromFragment       []byte
+	PublicFragment     []byte
+	WithFragment       []byte
+	TablespaceFragment []byte
+	UnloggedFragment   []byte
+	LoggedFragment     []byte
+	RenameToFragment   []byte
+
+	DefaultTablespace    string
+	RebuildTablePrefix   string
+	NotNullCheckSuffix   string
+	SyncTriggerInfix     string
 	CommaNewLineFragment []byte
+	ModifyColumnFragment []byte
 	SupportConcurrently  bool
 	SupportTransaction   bool
 
+	SupportIfExistsOnAlter      bool
+	SupportIfExistsOnIndex      bool
+	SupportAlterColumnType      bool
+	SupportMultipleAlterActions bool
+	SupportGrant                bool
+	SupportStorage              bool
+	DropIndexOnTable            bool
+	RebuildTableOnAlter         bool
+	SupportUsing                bool
+	SupportOnline               bool
+	SupportLockAnalysis         bool
+
 	LeftParenRune   rune
 	RightParenRune  rune
 	CommaRune       rune
+	EqualRune       rune
 	SemiColonRune   rune
 	SpaceRune       rune
 	QuoteRune       rune
 	StringQuoteRune rune
 	NewLineRune     rune
 	TabRune         rune
+	// AccountHostRune separates the user and the host of the account a
+	// role names, zero when roles have no host.
+	AccountHostRune rune
 
 	DataTypesLookup    map[field_type.FieldType][]byte
 	FieldOptionsLookup map[field_option.FieldOption][]byte
+	CastLookup         CastLookup
 }
 
 func DefaultDialectOption() *DialectOption {
@@ -78,6 +127,10 @@ func DefaultDialectOption() *DialectOption {
 		AlterClause:  []byte("ALTER "),
 		BeginClause:  []byte("BEGIN;"),
 		CommitClause: []byte("COMMIT;"),
+		GrantClause:  []byte("GRANT "),
+		RevokeClause: []byte("REVOKE "),
+		InsertClause: []byte("INSERT INTO "),
+		SelectClause: []byte("SELECT "),
 
 		IndexFragment: []byte("INDEX "),
 		TableFragment: []byte("TABLE "),
@@ -89,9 +142,23 @@ func DefaultDialectOption() *DialectOption {
 		SetFragment:      []byte("SET "),
 		DefaultFragment:  []byte("DEFAULT "),
 		DataTypeFragment: []byte("DATA TYPE "),
+		ResetFragment:    []byte("RESET "),
+		CollateFragment:  []byte(" COLLATE "),
+		UsingFragment:    []byte(" USING "),
+
+		ConstraintFragment: []byte("CONSTRAINT "),
+		CheckFragment:      []byte(" CHECK "),
+		NotValidFragment:   []byte(" NOT VALID"),
+		ValidateFragment:   []byte("VALIDATE "),
+		IsNotNullFragment:  []byte(" IS NOT NULL"),
+		LockTimeoutClause:  []byte("SET LOCAL lock_timeout = "),
+		LockCommentClause:  []byte("-- lock "),
+		TriggerFragment:    []byte("TRIGGER "),
+		FunctionFragment:   []byte("FUNCTION "),
 
 		BooleanFragment:     []byte("BOOLEAN"),
 		VarcharFragment:     []byte("VARCHAR"),
+		TextFragment:        []byte("TEXT"),
 		SmallIntFragment:    []byte("SMALLINT"),
 		IntFragment:         []byte("INT"),
 		BigIntFragment:      []byte("BIGINT"),
@@ -119,6 +186,7 @@ func DefaultDialectOption() *DialectOption {
 		LeftParenRune:   '(',
 		RightParenRune:  ')',
 		CommaRune:       ',',
+		EqualRune:       '=',
 		SemiColonRune:   ';',
 		SpaceRune:       ' ',
 		QuoteRune:       '"',
@@ -128,13 +196,47 @@ func DefaultDialectOption() *DialectOption {
 
 		CommaNewLineFragment: []byte(",\n"),
 		OnFragment:           []byte(" ON "),
+		ToFragment:           []byte(" TO "),
+		FromFragment:         []byte(" FROM "),
+		PublicFragment:       []byte("PUBLIC"),
+		WithFragment:         []byte(" WITH "),
+		TablespaceFragment:   []byte("TABLESPACE "),
+		UnloggedFragment:     []byte("UNLOGGED"),
+		LoggedFragment:       []byte("LOGGED"),
+		RenameToFragment:     []byte(" RENAME TO "),
+		DefaultTablespace:    "pg_default",
+		RebuildTablePrefix:   "_new_",
+		NotNullCheckSuffix:   "_not_null",
+		SyncTriggerInfix:     "_sync_",
 		SupportConcurrently:  false,
 		SupportTransaction:   true,
+
+		SupportIfExistsOnAlter:      true,
+		SupportIfExistsOnIndex:      true,
+		SupportAlterColumnType:      true,
+		SupportMultipleAlterActions: true,
+		SupportGrant:                true,
+		SupportStorage:              true,
+		DropIndexOnTable:            false,
+		RebuildTableOnAlter:         false,
+		SupportUsing:                true,
+		SupportOnline:               true,
+		SupportLockAnalysis:         true,
+
+		CastLookup: PostgresCasts(),
 	}
 
+	do.BuildLookups()
+	return do
+}
+
+// BuildLookups (re)builds the data types and field options lookups
+// from the current fragments.
+func (do *DialectOption) BuildLookups() {
 	do.DataTypesLookup = map[field_type.FieldType][]byte{
 		field_type.Boolean:     do.BooleanFragment,
 		field_type.Varchar:     do.VarcharFragment,
+		field_type.Text:        do.TextFragment,
 		field_type.SmallInt:    do.SmallIntFragment,
 		field_type.Int:         do.IntFragment,
 		field_type.BigInt:      do.BigIntFragment,
@@ -156,8 +258,6 @@ func DefaultDialectOption() *DialectOption {
 		field_option.Unique:        do.UniqueFragment,
 		field_option.PrimaryKey:    do.PrimaryKeyFragment,
 	}
-
-	return do
 }
 
 func (do *DialectOption) CreateTableTemplate() []byte {
@@ -181,6 +281,13 @@ func (do *DialectOption) AddColumnTemplate() []byte {
 	return buf.Bytes()
 }
 
+func (do *DialectOption) ModifyColumnTemplate() []byte {
+	buf := bytes.Buffer{}
+	buf.Write(do.ModifyColumnFragment)
+	buf.Write(do.ColumnFragment)
+	return buf.Bytes()
+}
+
 func (do *DialectOption) DropColumnTemplate() []byte {
 	buf := bytes.Buffer{}
 	buf.Write(do.DropClause)
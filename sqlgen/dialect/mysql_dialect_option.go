@@ -0,0 +1,39 @@
+package dialect
+
+func MySQLDialectOption() *DialectOption {
+	do := DefaultDialectOption()
+
+	do.BooleanFragment = []byte("BOOLEAN")
+	// MySQL only has JSON, a jsonb column would be crawled back as json
+	do.JsonbFragment = nil
+	do.FloatFragment = []byte("DOUBLE")
+	do.TimestampFragment = []byte("DATETIME")
+	do.TimestamptzFragment = []byte("TIMESTAMP")
+
+	do.SmallSerialFragment = []byte("SMALLINT AUTO_INCREMENT")
+	do.SerialFragment = []byte("INT AUTO_INCREMENT")
+	do.BigSerialFragment = []byte("BIGINT AUTO_INCREMENT")
+	do.AutoIncrementFragment = []byte("AUTO_INCREMENT")
+
+	do.ModifyColumnFragment = []byte("MODIFY ")
+	do.QuoteRune = '`'
+	// a role of user@host is the account 'user'@'host'
+	do.AccountHostRune = '@'
+
+	do.SupportConcurrently = false
+	do.SupportTransaction = false
+	do.SupportIfExistsOnAlter = false
+	do.SupportIfExistsOnIndex = false
+	do.SupportAlterColumnType = false
+	do.SupportStorage = false
+	do.DropIndexOnTable = true
+	do.SupportOnline = false
+	do.SupportLockAnalysis = false
+
+	// MODIFY COLUMN converts any value, without a USING expression
+	do.SupportUsing = false
+	do.CastLookup = nil
+
+	do.BuildLookups()
+	return do
+}
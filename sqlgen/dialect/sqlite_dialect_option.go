@@ -0,0 +1,29 @@
+package dialect
+
+func SQLiteDialectOption() *DialectOption {
+	do := DefaultDialectOption()
+
+	// only an INTEGER PRIMARY KEY column becomes an alias of the rowid, which
+	// is 64 bit, the narrower serials would be crawled back as bigserial
+	do.SmallSerialFragment = nil
+	do.SerialFragment = nil
+	do.BigSerialFragment = []byte("INTEGER")
+	do.AutoIncrementFragment = []byte("AUTOINCREMENT")
+
+	do.SupportConcurrently = false
+	do.SupportIfExistsOnAlter = false
+	do.SupportAlterColumnType = false
+	do.SupportMultipleAlterActions = false
+	do.SupportGrant = false
+	do.SupportStorage = false
+	do.RebuildTableOnAlter = true
+	do.SupportOnline = false
+	do.SupportLockAnalysis = false
+
+	// columns have no strict type, a USING expression is applied when the
+	// rows are copied into the rebuilt table
+	do.CastLookup = nil
+
+	do.BuildLookups()
+	return do
+}
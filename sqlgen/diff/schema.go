@@ -2,9 +2,12 @@ package diff
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/google/go-cmp/cmp"
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
 	"gitlab.com/wartek-id/core/tools/dbgen/types/field_type"
 )
@@ -23,11 +26,19 @@ type diffSchema struct {
 	schema  *config.Schema
 	fields  map[string]*config.Field
 	indexes map[string]*config.Index
+	grants  map[string]*config.Grant
 }
 
 type Schema struct {
 	from   map[string]*diffSchema
 	target map[string]*diffSchema
+
+	// tableFilter selects the managed tables, the others are never
+	// created, altered or dropped.
+	tableFilter *filter.TableFilter
+	// defaults plans the columns whose default alone differs, which
+	// migrations leave as they are.
+	defaults bool
 }
 
 func NewSchema(from, target []*config.Schema) *Schema {
@@ -37,14 +48,27 @@ func NewSchema(from, target []*config.Schema) *Schema {
 	}
 }
 
+// WithTableFilter only plans changes of the tables matched by the filter.
+func (diff *Schema) WithTableFilter(f *filter.TableFilter) *Schema {
+	diff.tableFilter = f
+	return diff
+}
+
+// WithDefaults also plans the columns whose default is the only difference,
+// to report every way the database differs from the schemas.
+func (diff *Schema) WithDefaults() *Schema {
+	diff.defaults = true
+	return diff
+}
+
 func (diff *Schema) GeneratePlan() (*step.MigrationPlanner, error) {
 	planner := step.NewMigrationPlanner()
 	planner.CreateTable = diff.CreatedTable()
 	planner.DropTable = diff.DroppedTable()
 
-	for name := range diff.target {
+	for _, name := range sortedNames(diff.target) {
 		existingTable := diff.from[name]
-		if existingTable == nil {
+		if existingTable == nil || !diff.tableFilter.Match(name) {
 			continue
 		}
 		alterTable, err := diff.AlteredSchema(name)
@@ -60,34 +84,42 @@ func (diff *Schema) GeneratePlan() (*step.MigrationPlanner, error) {
 	return planner, nil
 }
 
+// CreatedTable returns the new tables in the order they are created. The
+// schemas have no references, types or views to depend on, so tables are
+// ordered by name.
 func (diff *Schema) CreatedTable() []*config.Schema {
 	createdTable := make([]*config.Schema, 0)
-	for name, diffSchema := range diff.target {
-		if diff.from[name] == nil {
-			createdTable = append(createdTable, diffSchema.schema)
+	for _, name := range sortedNames(diff.target) {
+		if diff.from[name] == nil && diff.tableFilter.Match(name) {
+			createdTable = append(createdTable, diff.target[name].schema)
 		}
 	}
 	return createdTable
 }
 
+// DroppedTable returns the removed tables in the order they are dropped,
+// the reverse of the order they would be created in.
 func (diff *Schema) DroppedTable() []*config.Schema {
 	droppedTable := make([]*config.Schema, 0)
-	for name, diffSchema := range diff.from {
-		if diff.target[name] == nil {
-			droppedTable = append(droppedTable, diffSchema.schema)
+	names := sortedNames(diff.from)
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		if diff.target[name] == nil && diff.tableFilter.Match(name) {
+			droppedTable = append(droppedTable, diff.from[name].schema)
 		}
 	}
 	return droppedTable
 }
 
 func (diff *Schema) AlteredIndexes(existing, target map[string]*config.Index, planner *step.AlterSchema) {
-	for name, index := range existing {
+	for _, name := range sortedNames(existing) {
 		if target[name] == nil {
-			planner.DroppedIndices = append(planner.DroppedIndices, index)
+			planner.DroppedIndices = append(planner.DroppedIndices, existing[name])
 		}
 	}
 
-	for name, targetIndex := range target {
+	for _, name := range sortedNames(target) {
+		targetIndex := target[name]
 		existingIndex := existing[name]
 		if existingIndex == nil {
 			planner.AddedIndices = append(planner.AddedIndices, targetIndex)
@@ -114,37 +146,113 @@ func (diff *Schema) AlteredSchema(table string) (*step.AlterSchema, error) {
 	existingFields := tableFrom.fields
 	targetFields := tableTarget.fields
 	migrationSteps := step.NewAlterSchema(table)
+	migrationSteps.Schema = tableTarget.schema
+	migrationSteps.LastSchema = tableFrom.schema
+
+	// a renamed field moves the values of the existing column, which is kept
+	// until the new column exists and then contracted instead of dropped
+	transitions := make(map[string]*step.ColumnTransition)
+	for _, field := range tableTarget.schema.Fields {
+		renamedFrom := existingFields[field.RenamedFrom]
+		if field.RenamedFrom != "" && field.RenamedFrom != field.Name && renamedFrom != nil && targetFields[field.RenamedFrom] == nil {
+			transitions[field.RenamedFrom] = &step.ColumnTransition{From: renamedFrom, To: field}
+		}
+	}
 
-	for name, field := range targetFields {
-		existingField := existingFields[name]
+	// columns keep the order they are declared in
+	for _, field := range tableTarget.schema.Fields {
+		existingField := existingFields[field.Name]
+		if existingField == nil && transitions[field.RenamedFrom] != nil {
+			migrationSteps.ExpandedColumns = append(migrationSteps.ExpandedColumns, transitions[field.RenamedFrom])
+			continue
+		}
 		if existingField == nil {
 			migrationSteps.AddedColumns = append(migrationSteps.AddedColumns, field)
 			continue
 		}
 
 		alteredColumn := diff.alteredColumn(existingField, field)
-		if alteredColumn.HasChanges() {
+		if alteredColumn.HasChanges() || diff.defaults && alteredColumn.ChangedDefaultValue {
 			migrationSteps.AlteredColumns = append(migrationSteps.AlteredColumns, alteredColumn)
 		}
 	}
 
-	for name, field := range existingFields {
-		if targetFields[name] == nil {
-			migrationSteps.DroppedColumns = append(migrationSteps.DroppedColumns, field)
+	for _, field := range tableFrom.schema.Fields {
+		if targetFields[field.Name] != nil {
 			continue
 		}
+
+		transition := transitions[field.Name]
+		switch {
+		case transition == nil:
+			migrationSteps.DroppedColumns = append(migrationSteps.DroppedColumns, field)
+		case existingFields[transition.To.Name] != nil:
+			migrationSteps.ContractedColumns = append(migrationSteps.ContractedColumns, transition)
+		}
 	}
 
 	diff.AlteredIndexes(tableFrom.indexes, tableTarget.indexes, migrationSteps)
+	diff.AlteredGrants(tableFrom.grants, tableTarget.grants, migrationSteps)
+	diff.AlteredStorage(tableFrom.schema.Storage, tableTarget.schema.Storage, migrationSteps)
 	return migrationSteps, nil
 }
 
+// AlteredGrants only revokes the privileges of the roles the target declares,
+// the grants of other roles being managed outside of the schemas.
+func (diff *Schema) AlteredGrants(existing, target map[string]*config.Grant, planner *step.AlterSchema) {
+	for _, role := range sortedNames(existing) {
+		if target[role] == nil {
+			continue
+		}
+
+		revoked := diff.missingPrivileges(existing[role], target[role])
+		if revoked != nil {
+			planner.RevokedPrivileges = append(planner.RevokedPrivileges, revoked)
+		}
+	}
+
+	for _, role := range sortedNames(target) {
+		granted := diff.missingPrivileges(target[role], existing[role])
+		if granted != nil {
+			planner.GrantedPrivileges = append(planner.GrantedPrivileges, granted)
+		}
+	}
+}
+
+func (diff *Schema) AlteredStorage(existing, target *config.Storage, planner *step.AlterSchema) {
+	alterStorage := &step.AlterStorage{
+		Storage:     target,
+		LastStorage: existing,
+	}
+
+	if alterStorage.HasChanges() {
+		planner.Storage = alterStorage
+	}
+}
+
+// missingPrivileges returns privileges of grant which are not owned by other,
+// or nil when other already has all of them.
+func (diff *Schema) missingPrivileges(grant, other *config.Grant) *config.Grant {
+	missing := &config.Grant{Role: grant.Role}
+	for _, priv := range grant.Privileges {
+		if other == nil || !other.HasPrivilege(priv) {
+			missing.Privileges = append(missing.Privileges, priv)
+		}
+	}
+
+	if len(missing.Privileges) == 0 {
+		return nil
+	}
+	return missing
+}
+
 func (diff *Schema) alteredColumn(from, target *config.Field) *step.AlterColumn {
 	alterColumn := step.AlterColumn{
 		Name:                target.Name,
 		Field:               target,
 		LastField:           from,
 		ChangedType:         !diff.isSameFieldType(from, target),
+		ChangedCollation:    from.Collation != target.Collation,
 		ChangedDefaultValue: diff.changedDefaultValue(from, target),
 		ChangedOptions:      diff.changedOptions(from, target),
 	}
@@ -179,8 +287,13 @@ func (diff *Schema) changedOptions(from, target *config.Field) []step.OptionActi
 	return options
 }
 
+// changedDefaultValue compares the defaults as they are written, the crawled
+// 100 being the same default as the 100.0 decoded from JSON.
 func (diff *Schema) changedDefaultValue(from, target *config.Field) bool {
-	return from.Default != target.Default
+	if from.Default == nil || target.Default == nil {
+		return from.Default != target.Default
+	}
+	return fmt.Sprint(from.Default) != fmt.Sprint(target.Default)
 }
 
 func buildSchema(schemas []*config.Schema) map[string]*diffSchema {
@@ -191,6 +304,7 @@ func buildSchema(schemas []*config.Schema) map[string]*diffSchema {
 			schema:  sc,
 			fields:  nameableMapper(sc.Fields),
 			indexes: nameableMapper(sc.Index),
+			grants:  nameableMapper(sc.Grants),
 		}
 	}
 	return cmpSchema
@@ -204,3 +318,12 @@ func nameableMapper[T Nameable](elements []T) map[string]T {
 
 	return result
 }
+
+func sortedNames[T any](elements map[string]T) []string {
+	names := make([]string, 0, len(elements))
+	for name := range elements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
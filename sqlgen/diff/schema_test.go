@@ -6,8 +6,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/diff"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
 	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
 )
 
 func TestCreatedTable(t *testing.T) {
@@ -66,6 +68,30 @@ func TestDroppedTable(t *testing.T) {
 	assert.Equal(t, []*config.Schema{{Name: "toggle"}}, result)
 }
 
+func TestGeneratePlan_TableFilter(t *testing.T) {
+	from := []*config.Schema{
+		{Name: "user"},
+		{Name: "pgbouncer_stats"},
+		{Name: "goose_db_version"},
+		{Name: "audit_logs", Fields: []*config.Field{{Name: "id", Type: "bigint"}}},
+	}
+
+	target := []*config.Schema{
+		{Name: "user"},
+		{Name: "pgbouncer_config"},
+		{Name: "audit_logs", Fields: []*config.Field{{Name: "id", Type: "int"}}},
+	}
+
+	tableFilter, err := filter.NewTableFilter(nil, []string{"pgbouncer_*", "/^audit_/"}, "goose_db_version")
+	assert.Nil(t, err)
+
+	result, err := diff.NewSchema(from, target).WithTableFilter(tableFilter).GeneratePlan()
+	assert.Nil(t, err)
+	assert.Empty(t, result.CreateTable)
+	assert.Empty(t, result.DropTable)
+	assert.Empty(t, result.AlterSchema)
+}
+
 func TestAlterSchema(t *testing.T) {
 	testCases := []struct {
 		existing []*config.Schema
@@ -650,3 +676,149 @@ func TestGeneratePlan(t *testing.T) {
 		}
 	}
 }
+
+func TestAlteredGrants(t *testing.T) {
+	existing := []*config.Schema{
+		{
+			Name: "users",
+			Grants: []*config.Grant{
+				{Role: "app", Privileges: []privilege.Privilege{privilege.Select, privilege.Insert}},
+				{Role: "legacy", Privileges: []privilege.Privilege{privilege.Select}},
+			},
+		},
+	}
+	target := []*config.Schema{
+		{
+			Name: "users",
+			Grants: []*config.Grant{
+				{Role: "app", Privileges: []privilege.Privilege{privilege.Select, privilege.Update}},
+				{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+			},
+		},
+	}
+
+	diffSchema := diff.NewSchema(existing, target)
+	result, err := diffSchema.AlteredSchema("users")
+	assert.NoError(t, err)
+	assert.True(t, result.GrantsChanged())
+	assert.ElementsMatch(t, []*config.Grant{
+		{Role: "app", Privileges: []privilege.Privilege{privilege.Update}},
+		{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+	}, result.GrantedPrivileges)
+	assert.ElementsMatch(t, []*config.Grant{
+		{Role: "app", Privileges: []privilege.Privilege{privilege.Insert}},
+	}, result.RevokedPrivileges)
+
+	diffSchema = diff.NewSchema(target, target)
+	result, err = diffSchema.AlteredSchema("users")
+	assert.NoError(t, err)
+	assert.False(t, result.HasChanges())
+}
+
+func TestAlteredStorage(t *testing.T) {
+	existing := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "email", Type: "varchar", Limit: 100},
+			},
+			Storage: &config.Storage{
+				Autovacuum: map[string]interface{}{"enabled": "false"},
+			},
+		},
+	}
+	target := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "email", Type: "varchar", Limit: 100, Collation: "C"},
+			},
+			Storage: &config.Storage{
+				Autovacuum: map[string]interface{}{"enabled": false},
+				Fillfactor: 70,
+			},
+		},
+	}
+
+	diffSchema := diff.NewSchema(existing, target)
+	result, err := diffSchema.AlteredSchema("users")
+	assert.NoError(t, err)
+	assert.Len(t, result.AlteredColumns, 1)
+	assert.True(t, result.AlteredColumns[0].ChangedCollation)
+	assert.False(t, result.AlteredColumns[0].ChangedType)
+	assert.True(t, result.IsStorageChanged())
+	assert.Equal(t, []config.StorageParameter{{Name: "fillfactor", Value: "70"}}, result.Storage.SetParameters())
+	assert.Empty(t, result.Storage.ResetParameters())
+
+	diffSchema = diff.NewSchema(existing, existing)
+	result, err = diffSchema.AlteredSchema("users")
+	assert.NoError(t, err)
+	assert.False(t, result.HasChanges())
+}
+
+func TestColumnTransitions(t *testing.T) {
+	existing := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigint"},
+				{Name: "mail", Type: "varchar", Limit: 100},
+				{Name: "age", Type: "varchar", Limit: 10},
+			},
+		},
+	}
+	target := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigint"},
+				{Name: "email", Type: "varchar", Limit: 200, RenamedFrom: "mail"},
+				{Name: "age_years", Type: "int", RenamedFrom: "age", Options: []field_option.FieldOption{field_option.NotNull}},
+			},
+		},
+	}
+
+	diffSchema := diff.NewSchema(existing, target)
+	result, err := diffSchema.AlteredSchema("users")
+	assert.NoError(t, err)
+	assert.True(t, result.IsColumnsExpanded())
+	assert.False(t, result.IsColumnsContracted())
+	assert.Empty(t, result.AddedColumns)
+	assert.Empty(t, result.DroppedColumns)
+	assert.Equal(t, []*step.ColumnTransition{
+		{From: existing[0].Fields[1], To: target[0].Fields[1]},
+		{From: existing[0].Fields[2], To: target[0].Fields[2]},
+	}, result.ExpandedColumns)
+
+	// once expanded, both columns exist and the existing one is contracted
+	expanded := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigint"},
+				{Name: "mail", Type: "varchar", Limit: 100},
+				{Name: "age", Type: "varchar", Limit: 10},
+				{Name: "email", Type: "varchar", Limit: 200},
+				{Name: "age_years", Type: "int", Options: []field_option.FieldOption{field_option.NotNull}},
+			},
+		},
+	}
+	diffSchema = diff.NewSchema(expanded, target)
+	result, err = diffSchema.AlteredSchema("users")
+	assert.NoError(t, err)
+	assert.False(t, result.IsColumnsExpanded())
+	assert.True(t, result.IsColumnsContracted())
+	assert.Empty(t, result.DroppedColumns)
+	assert.Empty(t, result.AlteredColumns)
+	assert.Equal(t, []*step.ColumnTransition{
+		{From: expanded[0].Fields[1], To: target[0].Fields[1]},
+		{From: expanded[0].Fields[2], To: target[0].Fields[2]},
+	}, result.ContractedColumns)
+
+	// without the existing column there is nothing to move
+	diffSchema = diff.NewSchema([]*config.Schema{{Name: "users", Fields: []*config.Field{{Name: "id", Type: "bigint"}}}}, target)
+	result, err = diffSchema.AlteredSchema("users")
+	assert.NoError(t, err)
+	assert.False(t, result.IsColumnsExpanded())
+	assert.Len(t, result.AddedColumns, 2)
+}
@@ -0,0 +1,145 @@
+package sqlgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
+)
+
+// Drift is what differs between the crawled database and the schemas.
+type Drift struct {
+	// OnlyInDatabase are the objects missing from the schemas, such as
+	// tables or indices created by hand.
+	OnlyInDatabase []string
+	// OnlyInSchemas are the objects missing from the database.
+	OnlyInSchemas []string
+	// Different are the objects found in both which do not match.
+	Different []string
+}
+
+func (d *Drift) HasDrift() bool {
+	return len(d.OnlyInDatabase) != 0 || len(d.OnlyInSchemas) != 0 || len(d.Different) != 0
+}
+
+// Drift diffs the schemas against the crawled database. Unlike Plan, it
+// does not refuse the type changes the dialect cannot convert, and reports
+// the columns whose default alone differs, they are drift as well.
+func (gen *SqlGenerator) Drift(ctx context.Context) (*Drift, error) {
+	differ, err := gen.differ(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := differ.WithDefaults().GeneratePlan()
+	if err != nil {
+		return nil, err
+	}
+	return PlanDrift(plan), nil
+}
+
+// PlanDrift describes the plan as the drift of the database from the
+// schemas, the plan going from the database to the schemas.
+func PlanDrift(plan *step.MigrationPlanner) *Drift {
+	drift := &Drift{
+		OnlyInDatabase: make([]string, 0),
+		OnlyInSchemas:  make([]string, 0),
+		Different:      make([]string, 0),
+	}
+
+	for _, sc := range plan.CreateTable {
+		drift.OnlyInSchemas = append(drift.OnlyInSchemas, "table "+sc.Name)
+	}
+	for _, sc := range plan.DropTable {
+		drift.OnlyInDatabase = append(drift.OnlyInDatabase, "table "+sc.Name)
+	}
+
+	for _, as := range plan.AlteredTables() {
+		for _, field := range as.AddedColumns {
+			drift.OnlyInSchemas = append(drift.OnlyInSchemas, fmt.Sprintf("column %s.%s %s", as.Name, field.Name, fieldDescription(field)))
+		}
+		for _, transition := range as.ExpandedColumns {
+			drift.OnlyInSchemas = append(drift.OnlyInSchemas, fmt.Sprintf("column %s.%s %s", as.Name, transition.To.Name, fieldDescription(transition.To)))
+		}
+		for _, field := range as.DroppedColumns {
+			drift.OnlyInDatabase = append(drift.OnlyInDatabase, fmt.Sprintf("column %s.%s %s", as.Name, field.Name, fieldDescription(field)))
+		}
+		for _, transition := range as.ContractedColumns {
+			drift.OnlyInDatabase = append(drift.OnlyInDatabase, fmt.Sprintf("column %s.%s %s", as.Name, transition.From.Name, fieldDescription(transition.From)))
+		}
+		for _, column := range as.AlteredColumns {
+			drift.Different = append(drift.Different, fmt.Sprintf("column %s.%s: %s in the database, %s in the schemas",
+				as.Name, column.Name, fieldDescription(column.LastField), fieldDescription(column.Field)))
+		}
+
+		for _, index := range as.AddedIndices {
+			drift.OnlyInSchemas = append(drift.OnlyInSchemas, fmt.Sprintf("index %s.%s", as.Name, index.Name))
+		}
+		for _, index := range as.DroppedIndices {
+			drift.OnlyInDatabase = append(drift.OnlyInDatabase, fmt.Sprintf("index %s.%s", as.Name, index.Name))
+		}
+
+		for _, grant := range as.GrantedPrivileges {
+			drift.OnlyInSchemas = append(drift.OnlyInSchemas, fmt.Sprintf("grant %s on %s to %s", grantPrivileges(grant), as.Name, grant.Role))
+		}
+		for _, grant := range as.RevokedPrivileges {
+			drift.OnlyInDatabase = append(drift.OnlyInDatabase, fmt.Sprintf("grant %s on %s to %s", grantPrivileges(grant), as.Name, grant.Role))
+		}
+
+		if as.IsStorageChanged() {
+			drift.Different = append(drift.Different, "storage of "+as.Name)
+		}
+	}
+
+	return drift
+}
+
+// WriteDrift writes the drift, or that the database matches the schemas.
+func WriteDrift(w io.Writer, drift *Drift) error {
+	if !drift.HasDrift() {
+		_, err := fmt.Fprintln(w, color.GreenString("The database matches the schemas."))
+		return err
+	}
+
+	sections := make([]string, 0)
+	add := func(title string, objects []string) {
+		if len(objects) == 0 {
+			return
+		}
+		sections = append(sections, fmt.Sprintf("%s\n\t%s", title, strings.Join(objects, "\n\t")))
+	}
+	add(color.RedString("Only in the database:"), drift.OnlyInDatabase)
+	add(color.RedString("Only in the schemas:"), drift.OnlyInSchemas)
+	add(color.YellowString("Different:"), drift.Different)
+
+	_, err := fmt.Fprintln(w, strings.Join(sections, "\n\n"))
+	return err
+}
+
+// fieldDescription gives the type of the field along with its nullability,
+// default and collation.
+func fieldDescription(field *config.Field) string {
+	description := step.TypeName(field)
+	if field.IsNotNull() {
+		description += " not null"
+	}
+	if field.Default != nil {
+		description += fmt.Sprintf(" default %v", field.Default)
+	}
+	if field.Collation != "" {
+		description += " collate " + field.Collation
+	}
+	return description
+}
+
+func grantPrivileges(grant *config.Grant) string {
+	privileges := make([]string, 0, len(grant.Privileges))
+	for _, privilege := range grant.Privileges {
+		privileges = append(privileges, string(privilege))
+	}
+	return strings.Join(privileges, ", ")
+}
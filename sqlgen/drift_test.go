@@ -0,0 +1,132 @@
+package sqlgen_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+func TestSqlGenerator_Drift(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	database := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "joined", Type: "bigint"},
+				{Name: "hotfix", Type: "text"},
+			},
+			Index: []*config.Index{
+				{Name: "index_users_on_hotfix", Fields: []*config.IndexField{{Column: "hotfix", Order: "ASC"}}},
+			},
+			Grants: []*config.Grant{
+				{Role: "support", Privileges: []privilege.Privilege{privilege.Select, privilege.Insert}},
+				{Role: "dba", Privileges: []privilege.Privilege{privilege.Select}},
+			},
+		},
+		{Name: "tmp_backup", Fields: []*config.Field{{Name: "id", Type: "bigint"}}},
+	}
+	schemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "joined", Type: "timestamptz", Options: []field_option.FieldOption{field_option.NotNull}},
+				{Name: "email", Type: "varchar", Limit: 100},
+			},
+			Grants: []*config.Grant{{Role: "support", Privileges: []privilege.Privilege{privilege.Select}}},
+		},
+		{Name: "documents", Fields: []*config.Field{{Name: "id", Type: "bigserial"}}},
+	}
+
+	gen := sqlgen.NewGenerator(schema.NewStaticSchema(database), schemas, &sqlgen.Flag{})
+	drift, err := gen.Drift(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, drift.HasDrift())
+	assert.Equal(t, &sqlgen.Drift{
+		OnlyInDatabase: []string{
+			"table tmp_backup",
+			"column users.hotfix text",
+			"index users.index_users_on_hotfix",
+			"grant INSERT on users to support",
+		},
+		OnlyInSchemas: []string{
+			"table documents",
+			"column users.email varchar(100)",
+		},
+		Different: []string{
+			"column users.joined: bigint in the database, timestamptz not null in the schemas",
+		},
+	}, drift)
+
+	buf := bytes.Buffer{}
+	err = sqlgen.WriteDrift(&buf, drift)
+	assert.NoError(t, err)
+	assert.Equal(t, "Only in the database:\n"+
+		"\ttable tmp_backup\n"+
+		"\tcolumn users.hotfix text\n"+
+		"\tindex users.index_users_on_hotfix\n"+
+		"\tgrant INSERT on users to support\n\n"+
+		"Only in the schemas:\n"+
+		"\ttable documents\n"+
+		"\tcolumn users.email varchar(100)\n\n"+
+		"Different:\n"+
+		"\tcolumn users.joined: bigint in the database, timestamptz not null in the schemas\n", buf.String())
+
+	gen = sqlgen.NewGenerator(schema.NewStaticSchema(schemas), schemas, &sqlgen.Flag{})
+	drift, err = gen.Drift(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, drift.HasDrift())
+
+	buf.Reset()
+	err = sqlgen.WriteDrift(&buf, drift)
+	assert.NoError(t, err)
+	assert.Equal(t, "The database matches the schemas.\n", buf.String())
+}
+
+func TestSqlGenerator_DriftDefault(t *testing.T) {
+	database := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "status", Type: "varchar", Limit: 20, Default: "pending"},
+				{Name: "score", Type: "int", Default: 100},
+			},
+		},
+	}
+	schemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "status", Type: "varchar", Limit: 20, Default: "active"},
+				{Name: "score", Type: "int", Default: float64(100)},
+			},
+		},
+	}
+
+	gen := sqlgen.NewGenerator(schema.NewStaticSchema(database), schemas, &sqlgen.Flag{})
+	drift, err := gen.Drift(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, drift.HasDrift())
+	assert.Equal(t, []string{
+		"column users.status: varchar(20) default pending in the database, varchar(20) default active in the schemas",
+	}, drift.Different)
+
+	// the migrations leave a default-only change alone
+	plan, err := gen.Plan(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, plan.AlteredTables())
+}
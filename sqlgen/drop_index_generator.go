@@ -11,7 +11,7 @@ type DropIndexGenerator interface {
 	Dialect() string
 	DialectOptions() *dialect.DialectOption
 	ExpressionSQLGenerator() exp.ExpressionSQLGenerator
-	Generate(sb.SQLBuilder, *config.Index)
+	Generate(sb.SQLBuilder, string, *config.Index)
 }
 
 type dropIndexGenerator struct {
@@ -40,11 +40,21 @@ func (dig *dropIndexGenerator) ExpressionSQLGenerator() exp.ExpressionSQLGenerat
 	return dig.esg
 }
 
-func (dig *dropIndexGenerator) Generate(b sb.SQLBuilder, index *config.Index) {
+func (dig *dropIndexGenerator) Generate(b sb.SQLBuilder, tblName string, index *config.Index) {
 	b.Write(dig.dialectOptions.DropClause).
-		Write(dig.dialectOptions.IndexFragment).
-		Write(dig.dialectOptions.IfExistsFragment)
+		Write(dig.dialectOptions.IndexFragment)
+	if dig.dialectOptions.SupportConcurrently {
+		b.Write(dig.dialectOptions.ConcurrentlyFragment).
+			WriteRunes(dig.dialectOptions.SpaceRune)
+	}
+	if dig.dialectOptions.SupportIfExistsOnIndex {
+		b.Write(dig.dialectOptions.IfExistsFragment)
+	}
 
 	dig.ExpressionSQLGenerator().LiteralExpression(b, index.Name)
+	if dig.dialectOptions.DropIndexOnTable {
+		b.Write(dig.dialectOptions.OnFragment)
+		dig.ExpressionSQLGenerator().LiteralExpression(b, tblName)
+	}
 	b.WriteRunes(dig.dialectOptions.SemiColonRune)
 }
@@ -54,12 +54,30 @@ func TestDropIndexGenerator_Generate(t *testing.T) {
 			},
 			result: `DROP INDEX IF EXISTS "index_on_school";`,
 		},
+		{
+			dialect: dialect.MySQLDialectOption(),
+			input: &config.Index{
+				Name: "index_on_school",
+			},
+			result: "DROP INDEX `index_on_school` ON `users`;",
+		},
+		{
+			dialect: func() *dialect.DialectOption {
+				do := dialect.DefaultDialectOption()
+				do.SupportConcurrently = true
+				return do
+			}(),
+			input: &config.Index{
+				Name: "index_on_school",
+			},
+			result: `DROP INDEX CONCURRENTLY IF EXISTS "index_on_school";`,
+		},
 	}
 
 	for _, tc := range testCases {
 		buf := sb.NewSQLBuilder()
 		sqlGen := sqlgen.NewDropIndexGenerator("postgres", tc.dialect)
-		sqlGen.Generate(buf, tc.input)
+		sqlGen.Generate(buf, "users", tc.input)
 		result, err := buf.ToSQL()
 		assert.Nil(t, err)
 		assert.Equal(t, tc.result, result)
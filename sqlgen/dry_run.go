@@ -0,0 +1,23 @@
+package sqlgen
+
+import (
+	"context"
+	"io"
+)
+
+// DryRun runs the guards Generate runs, then writes the summary of the plan
+// listing every change of the altered tables followed by the up and down
+// migrations Generate would write, without writing any file. It tells
+// whether the plan has changes.
+func (gen *SqlGenerator) DryRun(ctx context.Context, w io.Writer) (bool, error) {
+	plan, err := gen.Plan(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	err = gen.Guards(plan)
+	if err != nil {
+		return false, err
+	}
+	return gen.writeReport(w, plan, true)
+}
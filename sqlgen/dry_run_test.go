@@ -0,0 +1,121 @@
+package sqlgen_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+func TestSqlGenerator_DryRun(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	oldSchemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "name", Type: "varchar", Limit: 100},
+				{Name: "legacy", Type: "text"},
+			},
+			Index: []*config.Index{
+				{Name: "index_users_on_legacy", Fields: []*config.IndexField{{Column: "legacy", Order: "ASC"}}},
+			},
+		},
+		{
+			Name:   "logs",
+			Fields: []*config.Field{{Name: "id", Type: "bigint"}},
+		},
+	}
+	newSchemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "name", Type: "varchar", Limit: 200, Default: "anonymous", Options: []field_option.FieldOption{field_option.NotNull}},
+				{Name: "email", Type: "varchar", Limit: 100},
+			},
+			Index: []*config.Index{
+				{Name: "index_users_on_email", Fields: []*config.IndexField{{Column: "email", Order: "ASC"}}},
+			},
+			Grants: []*config.Grant{{Role: "app", Privileges: []privilege.Privilege{privilege.Select, privilege.Insert}}},
+		},
+		{
+			Name:   "documents",
+			Fields: []*config.Field{{Name: "id", Type: "bigserial"}},
+		},
+	}
+
+	dir := t.TempDir()
+	gen := sqlgen.NewGenerator(schema.NewStaticSchema(oldSchemas), newSchemas, &sqlgen.Flag{OutputDirectory: dir, OutputTarget: "users", AllowDestructive: true})
+	buf := bytes.Buffer{}
+	changed, err := gen.DryRun(context.Background(), &buf)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	preview := buf.String()
+	assert.Contains(t, preview, "+ documents: created\n"+
+		"- logs: dropped\n"+
+		"~ users: 1 column added, 1 column altered, 1 column dropped, 1 index added, 1 index dropped, 1 grant added\n"+
+		"    + column email varchar(100)\n"+
+		"    ~ column name type varchar(100) → varchar(200)\n"+
+		"    ~ column name default none → anonymous\n"+
+		"    ~ column name set not null\n"+
+		"    - column legacy\n"+
+		"    + index index_users_on_email\n"+
+		"    - index index_users_on_legacy\n"+
+		"    + grant app SELECT, INSERT\n\n"+
+		"-- up\nBEGIN;")
+	assert.Contains(t, preview, "-- down\nBEGIN;")
+	assert.Contains(t, preview, "\tADD COLUMN \"email\" VARCHAR(100)")
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	buf.Reset()
+	gen = sqlgen.NewGenerator(schema.NewStaticSchema(newSchemas), newSchemas, &sqlgen.Flag{OutputDirectory: dir, OutputTarget: "users"})
+	changed, err = gen.DryRun(context.Background(), &buf)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "No changes being detected.\n", buf.String())
+}
+
+func TestSqlGenerator_DryRunGuards(t *testing.T) {
+	oldSchemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "legacy", Type: "text"},
+			},
+		},
+	}
+	newSchemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+			},
+		},
+	}
+
+	// the preview is refused as the migration would be
+	gen := sqlgen.NewGenerator(schema.NewStaticSchema(oldSchemas), newSchemas, &sqlgen.Flag{OutputDirectory: t.TempDir(), OutputTarget: "users"})
+	buf := bytes.Buffer{}
+	changed, err := gen.DryRun(context.Background(), &buf)
+	assert.True(t, errors.Is(err, sqlgen.ErrDestructiveChanges))
+	assert.False(t, changed)
+	assert.Empty(t, buf.String())
+}
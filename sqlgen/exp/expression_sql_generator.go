@@ -12,6 +12,8 @@ import (
 type ExpressionSQLGenerator interface {
 	GetTypeFragment(field *config.Field) []byte
 	GetOptionsFragment(field *config.Field) []byte
+	GetCollationFragment(field *config.Field) []byte
+	GetStorageParametersFragment(params []config.StorageParameter, withValue bool) []byte
 	LiteralExpression(buf sb.SQLBuilder, value string)
 	GetDefaultValue(value interface{}) []byte
 }
@@ -59,6 +61,35 @@ func (ex *expressionSQLGenerator) GetOptionsFragment(field *config.Field) []byte
 	return bytes.Join(options, []byte(string(ex.dialectOptions.SpaceRune)))
 }
 
+func (ex *expressionSQLGenerator) GetCollationFragment(field *config.Field) []byte {
+	if field.Collation == "" {
+		return []byte{}
+	}
+
+	buf := sb.NewSQLBuilder()
+	buf.Write(ex.dialectOptions.CollateFragment)
+	ex.LiteralExpression(buf, field.Collation)
+	return buf.Bytes()
+}
+
+func (ex *expressionSQLGenerator) GetStorageParametersFragment(params []config.StorageParameter, withValue bool) []byte {
+	buf := sb.NewSQLBuilder()
+	buf.WriteRunes(ex.dialectOptions.LeftParenRune)
+	for i, param := range params {
+		buf.WriteString(param.Name)
+		if withValue {
+			buf.WriteRunes(ex.dialectOptions.EqualRune).
+				WriteString(param.Value)
+		}
+
+		if i != len(params)-1 {
+			buf.WriteRunes(ex.dialectOptions.CommaRune, ex.dialectOptions.SpaceRune)
+		}
+	}
+	buf.WriteRunes(ex.dialectOptions.RightParenRune)
+	return buf.Bytes()
+}
+
 func (ex *expressionSQLGenerator) LiteralExpression(buf sb.SQLBuilder, value string) {
 	buf.WriteRunes(ex.dialectOptions.QuoteRune)
 	buf.WriteString(value)
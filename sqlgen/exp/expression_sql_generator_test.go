@@ -98,3 +98,27 @@ func TestGetDefaultValue(t *testing.T) {
 	result = ex.GetDefaultValue(1)
 	assert.Equal(t, []byte("1"), result)
 }
+
+func TestGetCollationFragment(t *testing.T) {
+	ex := exp.NewExpressionSQLGenerator("", dialect.DefaultDialectOption())
+
+	result := ex.GetCollationFragment(&config.Field{Collation: "und-x-icu"})
+	assert.Equal(t, " COLLATE \"und-x-icu\"", string(result))
+
+	result = ex.GetCollationFragment(&config.Field{})
+	assert.Equal(t, "", string(result))
+}
+
+func TestGetStorageParametersFragment(t *testing.T) {
+	ex := exp.NewExpressionSQLGenerator("", dialect.DefaultDialectOption())
+	params := []config.StorageParameter{
+		{Name: "autovacuum_enabled", Value: "false"},
+		{Name: "fillfactor", Value: "70"},
+	}
+
+	result := ex.GetStorageParametersFragment(params, true)
+	assert.Equal(t, "(autovacuum_enabled=false, fillfactor=70)", string(result))
+
+	result = ex.GetStorageParametersFragment(params, false)
+	assert.Equal(t, "(autovacuum_enabled, fillfactor)", string(result))
+}
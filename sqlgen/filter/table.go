@@ -0,0 +1,118 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const DefaultMigrationTable = "schema_migrations"
+
+var ErrInvalidPattern = errors.New("invalid table pattern")
+
+// TableFilter selects the tables managed by the schemas. A pattern is a glob
+// such as audit_*, or a regular expression when wrapped in slashes such as
+// /^audit_[0-9]+$/. The migration tracking table is never managed.
+type TableFilter struct {
+	migrationTable string
+	include        []*pattern
+	exclude        []*pattern
+}
+
+type pattern struct {
+	glob  string
+	regex *regexp.Regexp
+}
+
+func NewTableFilter(include, exclude []string, migrationTable string) (*TableFilter, error) {
+	if migrationTable == "" {
+		migrationTable = DefaultMigrationTable
+	}
+
+	f := &TableFilter{migrationTable: migrationTable}
+
+	var err error
+	f.include, err = parsePatterns(include)
+	if err != nil {
+		return nil, err
+	}
+
+	f.exclude, err = parsePatterns(exclude)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// MigrationTable returns the name of the migration tracking table.
+func (f *TableFilter) MigrationTable() string {
+	if f == nil {
+		return DefaultMigrationTable
+	}
+	return f.migrationTable
+}
+
+// Match reports whether the table is managed: it is not the migration table,
+// matches an include pattern when there are any and no exclude pattern.
+// A nil filter only skips the default migration table.
+func (f *TableFilter) Match(table string) bool {
+	if table == f.MigrationTable() {
+		return false
+	}
+	if f == nil {
+		return true
+	}
+
+	if len(f.include) > 0 && !matchAny(f.include, table) {
+		return false
+	}
+	return !matchAny(f.exclude, table)
+}
+
+func parsePatterns(values []string) ([]*pattern, error) {
+	patterns := make([]*pattern, 0, len(values))
+	for _, value := range values {
+		p, err := parsePattern(value)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+func parsePattern(value string) (*pattern, error) {
+	if len(value) > 1 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+		regex, err := regexp.Compile(value[1 : len(value)-1])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %s", ErrInvalidPattern, value, err)
+		}
+		return &pattern{regex: regex}, nil
+	}
+
+	// the pattern is only checked when it is matched
+	if _, err := path.Match(value, ""); err != nil {
+		return nil, fmt.Errorf("%w %q: %s", ErrInvalidPattern, value, err)
+	}
+	return &pattern{glob: value}, nil
+}
+
+func (p *pattern) match(table string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(table)
+	}
+
+	match, _ := path.Match(p.glob, table)
+	return match
+}
+
+func matchAny(patterns []*pattern, table string) bool {
+	for _, p := range patterns {
+		if p.match(table) {
+			return true
+		}
+	}
+	return false
+}
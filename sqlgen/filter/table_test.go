@@ -0,0 +1,74 @@
+package filter_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
+)
+
+func TestTableFilter_Match(t *testing.T) {
+	testCases := map[string]struct {
+		include        []string
+		exclude        []string
+		migrationTable string
+		matched        []string
+		skipped        []string
+	}{
+		"default": {
+			matched: []string{"users", "migrations"},
+			skipped: []string{"schema_migrations"},
+		},
+		"migration table": {
+			migrationTable: "goose_db_version",
+			matched:        []string{"users", "schema_migrations"},
+			skipped:        []string{"goose_db_version"},
+		},
+		"include glob": {
+			include: []string{"user*", "orders"},
+			matched: []string{"users", "user_roles", "orders"},
+			skipped: []string{"order_items", "schema_migrations"},
+		},
+		"exclude glob and regex": {
+			exclude: []string{"pgbouncer_*", `/^audit_[0-9]+$/`},
+			matched: []string{"users", "audit_logs"},
+			skipped: []string{"pgbouncer_stats", "audit_2023"},
+		},
+		"exclude wins over include": {
+			include: []string{"user*"},
+			exclude: []string{"user_tmp"},
+			matched: []string{"users"},
+			skipped: []string{"user_tmp"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			f, err := filter.NewTableFilter(tc.include, tc.exclude, tc.migrationTable)
+			assert.Nil(t, err)
+
+			for _, table := range tc.matched {
+				assert.True(t, f.Match(table), table)
+			}
+			for _, table := range tc.skipped {
+				assert.False(t, f.Match(table), table)
+			}
+		})
+	}
+}
+
+func TestTableFilter_Nil(t *testing.T) {
+	var f *filter.TableFilter
+	assert.Equal(t, filter.DefaultMigrationTable, f.MigrationTable())
+	assert.True(t, f.Match("users"))
+	assert.False(t, f.Match(filter.DefaultMigrationTable))
+}
+
+func TestNewTableFilter_InvalidPattern(t *testing.T) {
+	_, err := filter.NewTableFilter([]string{"[users"}, nil, "")
+	assert.True(t, errors.Is(err, filter.ErrInvalidPattern))
+
+	_, err = filter.NewTableFilter(nil, []string{"/(audit/"}, "")
+	assert.True(t, errors.Is(err, filter.ErrInvalidPattern))
+}
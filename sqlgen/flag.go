@@ -3,14 +3,49 @@ package sqlgen
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"strings"
 	"time"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
 )
 
+// VersionLayout formats the time of the generation into the version of the
+// migration.
+const VersionLayout = "20060102150405"
+
 type Flag struct {
 	OutputDirectory string
 	OutputTarget    string
 	SkipDropTable   bool
+	Dialect         string
+	TableFilter     *filter.TableFilter
+
+	// AllowDestructive writes destructive changes which are not
+	// acknowledged by the schemas.
+	AllowDestructive bool
+
+	// Online splits the migration into phases which avoid long exclusive
+	// locks, LockTimeout bounds the wait for the locks they still take.
+	Online      bool
+	LockTimeout time.Duration
+
+	// BackfillBatchSize is the number of rows updated per transaction by
+	// the backfill of an expanded column.
+	BackfillBatchSize int
+
+	// AnnotateLocks precedes the statements with a comment giving the lock
+	// they take and whether they rewrite the table.
+	AnnotateLocks bool
+
+	// Format is the layout and versioning scheme of the migration files,
+	// golang-migrate when empty.
+	Format string
+
+	// FullSchemaFile is where the create statements of every target schema
+	// are written, FullSchemaMigrationFilename when empty.
+	FullSchemaFile string
 }
 
 func NewFlag(dir, target string, skipDrop bool) (*Flag, error) {
@@ -18,8 +53,12 @@ func NewFlag(dir, target string, skipDrop bool) (*Flag, error) {
 		return nil, errors.New("output target cannot contain \"\\\" character")
 	}
 
-	t := time.Now()
-	target = fmt.Sprintf("%s_%s", t.Format("20060102150405"), target)
+	t, err := nextVersion(dir, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	target = fmt.Sprintf("%s_%s", t.Format(VersionLayout), target)
 	flag := Flag{
 		OutputDirectory: dir,
 		OutputTarget:    target,
@@ -27,3 +66,26 @@ func NewFlag(dir, target string, skipDrop bool) (*Flag, error) {
 	}
 	return &flag, nil
 }
+
+// nextVersion returns the time of the generation, or the second after the
+// latest migration of the directory when that is later, the phases of an
+// online migration taking the seconds after their version.
+func nextVersion(dir string, now time.Time) (time.Time, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return now, nil
+	}
+	if err != nil {
+		return now, err
+	}
+
+	for _, entry := range entries {
+		// flyway prefixes the version of the versioned and undo migrations
+		version, _ := splitTarget(strings.TrimLeft(entry.Name(), "VU"))
+		t, err := time.ParseInLocation(VersionLayout, version, now.Location())
+		if err == nil && !t.Before(now.Truncate(time.Second)) {
+			now = t.Add(time.Second)
+		}
+	}
+	return now, nil
+}
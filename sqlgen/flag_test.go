@@ -1,7 +1,10 @@
 package sqlgen_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
@@ -16,3 +19,22 @@ func TestNewFlag(t *testing.T) {
 	assert.Error(t, err, "output target cannot contain \"\\\" character")
 	assert.Nil(t, flag)
 }
+
+func TestNewFlag_AfterLatestVersion(t *testing.T) {
+	dir := t.TempDir()
+	latest := time.Now().Add(time.Hour)
+	err := os.WriteFile(filepath.Join(dir, latest.Format(sqlgen.VersionLayout)+"_users_contract.up.sql"), nil, 0644)
+	assert.NoError(t, err)
+
+	flag, err := sqlgen.NewFlag(dir, "migration", false)
+	assert.NoError(t, err)
+	assert.Equal(t, latest.Add(time.Second).Format(sqlgen.VersionLayout)+"_migration", flag.OutputTarget)
+
+	latest = latest.Add(time.Minute)
+	err = os.WriteFile(filepath.Join(dir, "V"+latest.Format(sqlgen.VersionLayout)+"__users.sql"), nil, 0644)
+	assert.NoError(t, err)
+
+	flag, err = sqlgen.NewFlag(dir, "migration", false)
+	assert.NoError(t, err)
+	assert.Equal(t, latest.Add(time.Second).Format(sqlgen.VersionLayout)+"_migration", flag.OutputTarget)
+}
@@ -0,0 +1,246 @@
+package sqlgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/migrate"
+)
+
+// Migration formats, the layout and versioning scheme of the files of a
+// migration tool.
+const (
+	FormatGolangMigrate = "golang-migrate"
+	FormatGoose         = "goose"
+	FormatFlyway        = "flyway"
+	FormatDbmate        = "dbmate"
+	FormatAtlas         = "atlas"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported migration format")
+
+// MigrationFile is a file written for a migration, its Name being relative
+// to the migration directory.
+type MigrationFile struct {
+	Name    string
+	Content []byte
+}
+
+// MigrationWriter renders a migration into the files of a migration tool.
+type MigrationWriter interface {
+	Format() string
+	// RunsTransactions tells whether the tool wraps every migration in a
+	// transaction by itself, the migrations are then given to Files without
+	// their own BEGIN and COMMIT.
+	RunsTransactions() bool
+	// Files renders the migration named by the target, {version}_{name}.
+	Files(target string, migration *Migration) []*MigrationFile
+}
+
+// DirectoryWriter is implemented by the formats whose tool keeps a file
+// describing the whole migration directory, written after the migrations.
+type DirectoryWriter interface {
+	WriteDirectory(dir string) error
+}
+
+var migrationWriters = map[string]MigrationWriter{
+	FormatGolangMigrate: &golangMigrateWriter{},
+	FormatGoose:         &gooseWriter{},
+	FormatFlyway:        &flywayWriter{},
+	FormatDbmate:        &dbmateWriter{},
+	FormatAtlas:         &atlasWriter{},
+}
+
+// NewMigrationWriter returns the writer of the format, golang-migrate when
+// the format is empty.
+func NewMigrationWriter(format string) (MigrationWriter, error) {
+	if format == "" {
+		format = FormatGolangMigrate
+	}
+
+	writer, ok := migrationWriters[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s, expected one of %s", ErrUnsupportedFormat, format, strings.Join(Formats(), ", "))
+	}
+	return writer, nil
+}
+
+// Formats returns the names of the supported formats.
+func Formats() []string {
+	formats := make([]string, 0, len(migrationWriters))
+	for format := range migrationWriters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// ValidateHistoryFormat checks the migrations of the format can be read back
+// as the migration history, which only the golang-migrate layout can.
+func ValidateHistoryFormat(format string) error {
+	writer, err := NewMigrationWriter(format)
+	if err != nil {
+		return err
+	}
+	if writer.Format() != FormatGolangMigrate {
+		return fmt.Errorf("%w: the migration history is read from the %s layout only, not %s", ErrUnsupportedFormat, FormatGolangMigrate, writer.Format())
+	}
+	return nil
+}
+
+// golangMigrateWriter writes {version}_{name}.up.sql and
+// {version}_{name}.down.sql, the migrations wrapping themselves in a
+// transaction.
+type golangMigrateWriter struct{}
+
+func (w *golangMigrateWriter) Format() string {
+	return FormatGolangMigrate
+}
+
+func (w *golangMigrateWriter) RunsTransactions() bool {
+	return false
+}
+
+func (w *golangMigrateWriter) Files(target string, migration *Migration) []*MigrationFile {
+	up, down := migrationFileNames(target)
+	return []*MigrationFile{
+		{Name: up, Content: migration.Up},
+		{Name: down, Content: migration.Down},
+	}
+}
+
+// gooseWriter writes a single {version}_{name}.sql file holding both
+// directions. Sections with dollar quoted bodies, whose semicolons goose
+// would split on, are a single statement.
+type gooseWriter struct{}
+
+func (w *gooseWriter) Format() string {
+	return FormatGoose
+}
+
+func (w *gooseWriter) RunsTransactions() bool {
+	return true
+}
+
+func (w *gooseWriter) Files(target string, migration *Migration) []*MigrationFile {
+	sections := make([][]byte, 0, 3)
+	if !migration.Transactional {
+		sections = append(sections, []byte("-- +goose NO TRANSACTION"))
+	}
+	sections = append(sections,
+		getContents([]byte("-- +goose Up"), gooseStatement(migration.Up)),
+		getContents([]byte("-- +goose Down"), gooseStatement(migration.Down)),
+	)
+	return []*MigrationFile{{Name: target + DefaultMigrationExt, Content: getContents(sections...)}}
+}
+
+func gooseStatement(content []byte) []byte {
+	if !bytes.Contains(content, []byte("$$")) {
+		return content
+	}
+	return bytes.Join([][]byte{[]byte("-- +goose StatementBegin"), content, []byte("-- +goose StatementEnd")}, []byte("\n"))
+}
+
+// flywayWriter writes the versioned migration V{version}__{name}.sql and the
+// undo migration U{version}__{name}.sql. The migrations which cannot run in
+// a transaction get a script configuration turning it off.
+type flywayWriter struct{}
+
+func (w *flywayWriter) Format() string {
+	return FormatFlyway
+}
+
+func (w *flywayWriter) RunsTransactions() bool {
+	return true
+}
+
+func (w *flywayWriter) Files(target string, migration *Migration) []*MigrationFile {
+	version, name := splitTarget(target)
+	up := fmt.Sprintf("V%s__%s%s", version, name, DefaultMigrationExt)
+	down := fmt.Sprintf("U%s__%s%s", version, name, DefaultMigrationExt)
+
+	files := []*MigrationFile{
+		{Name: up, Content: migration.Up},
+		{Name: down, Content: migration.Down},
+	}
+	if !migration.Transactional {
+		files = append(files,
+			&MigrationFile{Name: up + ".conf", Content: []byte("executeInTransaction=false\n")},
+			&MigrationFile{Name: down + ".conf", Content: []byte("executeInTransaction=false\n")},
+		)
+	}
+	return files
+}
+
+// dbmateWriter writes a single {version}_{name}.sql file holding both
+// directions.
+type dbmateWriter struct{}
+
+func (w *dbmateWriter) Format() string {
+	return FormatDbmate
+}
+
+func (w *dbmateWriter) RunsTransactions() bool {
+	return true
+}
+
+func (w *dbmateWriter) Files(target string, migration *Migration) []*MigrationFile {
+	up, down := []byte("-- migrate:up"), []byte("-- migrate:down")
+	if !migration.Transactional {
+		up, down = []byte("-- migrate:up transaction:false"), []byte("-- migrate:down transaction:false")
+	}
+	return []*MigrationFile{{
+		Name:    target + DefaultMigrationExt,
+		Content: getContents(getContents(up, migration.Up), getContents(down, migration.Down)),
+	}}
+}
+
+// atlasWriter writes the {version}_{name}.sql file of the up migration only,
+// atlas planning the rollbacks itself, then the atlas.sum file of the
+// directory which atlas checks before applying anything.
+type atlasWriter struct{}
+
+func (w *atlasWriter) Format() string {
+	return FormatAtlas
+}
+
+func (w *atlasWriter) RunsTransactions() bool {
+	return true
+}
+
+func (w *atlasWriter) Files(target string, migration *Migration) []*MigrationFile {
+	content := migration.Up
+	if !migration.Transactional {
+		content = getContents([]byte("-- atlas:txmode none"), content)
+	}
+	return []*MigrationFile{{Name: target + DefaultMigrationExt, Content: content}}
+}
+
+func (w *atlasWriter) WriteDirectory(dir string) error {
+	return migrate.WriteAtlasSum(dir)
+}
+
+// migrationFileNames returns the up and down file names of the target,
+// keeping the extension of the target when it has one.
+func migrationFileNames(target string) (string, string) {
+	ext := filepath.Ext(target)
+	if ext != "" {
+		target = strings.Replace(target, ext, "", -1)
+	} else {
+		ext = DefaultMigrationExt
+	}
+	return fmt.Sprintf("%s.up%s", target, ext), fmt.Sprintf("%s.down%s", target, ext)
+}
+
+// splitTarget splits {version}_{name} into its version and name.
+func splitTarget(target string) (string, string) {
+	version, name, found := strings.Cut(target, "_")
+	if !found {
+		return version, version
+	}
+	return version, name
+}
@@ -0,0 +1,280 @@
+package sqlgen_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/migrate"
+	mock_schema "gitlab.com/wartek-id/core/tools/dbgen/sqlgen/mocks/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+)
+
+func TestNewMigrationWriter(t *testing.T) {
+	writer, err := sqlgen.NewMigrationWriter("")
+	assert.NoError(t, err)
+	assert.Equal(t, sqlgen.FormatGolangMigrate, writer.Format())
+
+	_, err = sqlgen.NewMigrationWriter("liquibase")
+	assert.True(t, errors.Is(err, sqlgen.ErrUnsupportedFormat))
+	assert.EqualError(t, err, "unsupported migration format: liquibase, expected one of atlas, dbmate, flyway, golang-migrate, goose")
+}
+
+func TestValidateHistoryFormat(t *testing.T) {
+	assert.NoError(t, sqlgen.ValidateHistoryFormat(""))
+	assert.NoError(t, sqlgen.ValidateHistoryFormat(sqlgen.FormatGolangMigrate))
+
+	for _, format := range []string{sqlgen.FormatGoose, sqlgen.FormatFlyway, sqlgen.FormatDbmate, sqlgen.FormatAtlas} {
+		err := sqlgen.ValidateHistoryFormat(format)
+		assert.True(t, errors.Is(err, sqlgen.ErrUnsupportedFormat), format)
+	}
+	assert.EqualError(t, sqlgen.ValidateHistoryFormat(sqlgen.FormatGoose), "unsupported migration format: the migration history is read from the golang-migrate layout only, not goose")
+}
+
+// writeFormatMigration writes a migration of the format into a new directory.
+func writeFormatMigration(t *testing.T, format string) string {
+	writer, err := sqlgen.NewMigrationWriter(format)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	files := writer.Files("20240102030405_users", &sqlgen.Migration{
+		Up:   []byte(`CREATE INDEX CONCURRENTLY IF NOT EXISTS "index_users_on_email" ON "users"("email" ASC);`),
+		Down: []byte(`DROP INDEX CONCURRENTLY IF EXISTS "index_users_on_email";`),
+	})
+	for _, file := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, file.Name), file.Content, 0644))
+	}
+	return dir
+}
+
+func TestMigrationHistory_Formats(t *testing.T) {
+	for _, format := range []string{sqlgen.FormatGoose, sqlgen.FormatFlyway, sqlgen.FormatDbmate, sqlgen.FormatAtlas} {
+		t.Run(format, func(t *testing.T) {
+			// --from-migrations
+			_, err := schema.NewMigrationSchema(writeFormatMigration(t, format))
+			assert.True(t, errors.Is(err, schema.ErrUnsupportedLayout))
+
+			// db:migrate
+			_, err = migrate.Load(writeFormatMigration(t, format))
+			assert.True(t, errors.Is(err, schema.ErrUnsupportedLayout))
+
+			// migrate:verify
+			dir := writeFormatMigration(t, format)
+			assert.NoError(t, migrate.WriteSum(dir))
+			assert.NoError(t, migrate.Verify(dir))
+
+			sum, err := migrate.ReadSum(dir)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, sum.Files)
+			edited := make([]string, 0, len(sum.Files))
+			for _, file := range sum.Files {
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, file.Name), []byte("-- edited"), 0644))
+				edited = append(edited, file.Name)
+			}
+			assert.Equal(t, &migrate.SumError{Edited: edited}, migrate.Verify(dir))
+		})
+	}
+}
+
+func TestMigrationWriter_Files(t *testing.T) {
+	transactional := &sqlgen.Migration{
+		Up:            []byte(`ALTER TABLE "users" ADD COLUMN "email" TEXT;`),
+		Down:          []byte(`ALTER TABLE "users" DROP COLUMN "email";`),
+		Transactional: true,
+	}
+	concurrent := &sqlgen.Migration{
+		Name: "create_index_users_on_email",
+		Up:   []byte(`CREATE INDEX CONCURRENTLY IF NOT EXISTS "index_users_on_email" ON "users"("email" ASC);`),
+		Down: []byte(`DROP INDEX CONCURRENTLY IF EXISTS "index_users_on_email";`),
+	}
+	backfill := &sqlgen.Migration{
+		Name: "backfill_users_email",
+		Up:   []byte("DO $$\nBEGIN\n\tUPDATE \"users\" SET \"email\" = \"mail\";\n\tCOMMIT;\nEND $$;"),
+		Down: sqlgen.NothingToRollBack,
+	}
+
+	testCases := map[string]struct {
+		format    string
+		migration *sqlgen.Migration
+		files     map[string]string
+	}{
+		"golang-migrate": {
+			format:    sqlgen.FormatGolangMigrate,
+			migration: transactional,
+			files: map[string]string{
+				"20240102030405_users.up.sql":   `ALTER TABLE "users" ADD COLUMN "email" TEXT;`,
+				"20240102030405_users.down.sql": `ALTER TABLE "users" DROP COLUMN "email";`,
+			},
+		},
+		"goose": {
+			format:    sqlgen.FormatGoose,
+			migration: transactional,
+			files: map[string]string{
+				"20240102030405_users.sql": `-- +goose Up
+
+ALTER TABLE "users" ADD COLUMN "email" TEXT;
+
+-- +goose Down
+
+ALTER TABLE "users" DROP COLUMN "email";`,
+			},
+		},
+		"goose without transaction": {
+			format:    sqlgen.FormatGoose,
+			migration: backfill,
+			files: map[string]string{
+				"20240102030405_users.sql": `-- +goose NO TRANSACTION
+
+-- +goose Up
+
+-- +goose StatementBegin
+DO $$
+BEGIN
+	UPDATE "users" SET "email" = "mail";
+	COMMIT;
+END $$;
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- nothing to roll back`,
+			},
+		},
+		"flyway": {
+			format:    sqlgen.FormatFlyway,
+			migration: transactional,
+			files: map[string]string{
+				"V20240102030405__users.sql": `ALTER TABLE "users" ADD COLUMN "email" TEXT;`,
+				"U20240102030405__users.sql": `ALTER TABLE "users" DROP COLUMN "email";`,
+			},
+		},
+		"flyway without transaction": {
+			format:    sqlgen.FormatFlyway,
+			migration: concurrent,
+			files: map[string]string{
+				"V20240102030405__users.sql":      `CREATE INDEX CONCURRENTLY IF NOT EXISTS "index_users_on_email" ON "users"("email" ASC);`,
+				"U20240102030405__users.sql":      `DROP INDEX CONCURRENTLY IF EXISTS "index_users_on_email";`,
+				"V20240102030405__users.sql.conf": "executeInTransaction=false\n",
+				"U20240102030405__users.sql.conf": "executeInTransaction=false\n",
+			},
+		},
+		"dbmate": {
+			format:    sqlgen.FormatDbmate,
+			migration: transactional,
+			files: map[string]string{
+				"20240102030405_users.sql": `-- migrate:up
+
+ALTER TABLE "users" ADD COLUMN "email" TEXT;
+
+-- migrate:down
+
+ALTER TABLE "users" DROP COLUMN "email";`,
+			},
+		},
+		"dbmate without transaction": {
+			format:    sqlgen.FormatDbmate,
+			migration: concurrent,
+			files: map[string]string{
+				"20240102030405_users.sql": `-- migrate:up transaction:false
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "index_users_on_email" ON "users"("email" ASC);
+
+-- migrate:down transaction:false
+
+DROP INDEX CONCURRENTLY IF EXISTS "index_users_on_email";`,
+			},
+		},
+		"atlas": {
+			format:    sqlgen.FormatAtlas,
+			migration: transactional,
+			files: map[string]string{
+				"20240102030405_users.sql": `ALTER TABLE "users" ADD COLUMN "email" TEXT;`,
+			},
+		},
+		"atlas without transaction": {
+			format:    sqlgen.FormatAtlas,
+			migration: concurrent,
+			files: map[string]string{
+				"20240102030405_users.sql": `-- atlas:txmode none
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "index_users_on_email" ON "users"("email" ASC);`,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			writer, err := sqlgen.NewMigrationWriter(tc.format)
+			assert.NoError(t, err)
+
+			files := make(map[string]string)
+			for _, file := range writer.Files("20240102030405_users", tc.migration) {
+				files[file.Name] = string(file.Content)
+			}
+			assert.Equal(t, tc.files, files)
+		})
+	}
+}
+
+func TestSqlGenerator_GenerateFormat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return([]*config.Schema{
+		{
+			Name:   "users",
+			Fields: []*config.Field{{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}}},
+		},
+	}, nil).AnyTimes()
+	target := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "email", Type: "text"},
+			},
+			Index: []*config.Index{
+				{Name: "index_users_on_email", Fields: []*config.IndexField{{Column: "email"}}},
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	gen := sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{
+		FullSchemaFile:  fullSchemaFile(t),
+		OutputDirectory: dir,
+		OutputTarget:    "20240102030405_users",
+		Online:          true,
+		Format:          sqlgen.FormatAtlas,
+	})
+	err := gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	assert.NoError(t, err)
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	sort.Strings(files)
+	assert.Equal(t, []string{
+		"20240102030405_users.snapshot.json",
+		"20240102030405_users.sql",
+		"20240102030406_users_create_index_users_on_email.sql",
+		"atlas.sum",
+		"dbgen.sum",
+	}, files)
+
+	expand, err := os.ReadFile(filepath.Join(dir, "20240102030405_users.sql"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ALTER TABLE IF EXISTS \"users\"\n\tADD COLUMN \"email\" TEXT;", string(expand))
+	assert.NoError(t, migrate.Verify(dir))
+}
@@ -2,6 +2,8 @@ package sqlgen
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,6 +13,8 @@ import (
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dialect"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/diff"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/migrate"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/registry"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/sb"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
@@ -24,15 +28,23 @@ const (
 
 var SectionSeparator = []byte("\n\n")
 
+var ErrDestructiveChanges = errors.New("migration has destructive changes")
+
 type SqlGenerator struct {
 	dbUpFilename   string
 	dbDownFilename string
+	snapshotFile   string
+	upWritten      bool
 	flag           *Flag
 	generators     *generators
 	schemas        []*config.Schema
 	crawler        schema.Schema
 	dialect        string
 	dialectOption  *dialect.DialectOption
+	writer         MigrationWriter
+	// err is the error of the dialect or the format of the flag, returned by
+	// Validate before anything is generated.
+	err error
 }
 
 type generators struct {
@@ -41,44 +53,81 @@ type generators struct {
 	atg AlterTableGenerator
 	dig DropIndexGenerator
 	dtg DropTableGenerator
+	gg  GrantGenerator
+	nng NotNullGenerator
+	tg  TransitionGenerator
+
+	// concurrentCig and concurrentDig build and drop indices without
+	// blocking writes, for online migrations.
+	concurrentCig CreateIndexGenerator
+	concurrentDig DropIndexGenerator
 }
 
 func NewGenerator(crawler schema.Schema, schemas []*config.Schema, flag *Flag) *SqlGenerator {
 	dbUpFilename, dbDownFilename := getTargetPath(flag.OutputDirectory, flag.OutputTarget)
+	snapshotFile := getSnapshotPath(flag.OutputDirectory, flag.OutputTarget)
+	dialectName, dialectOption, err := getDialectOption(flag.Dialect)
 
-	return &SqlGenerator{
+	gen := &SqlGenerator{
 		dbUpFilename:   dbUpFilename,
 		dbDownFilename: dbDownFilename,
+		snapshotFile:   snapshotFile,
 		schemas:        schemas,
 		flag:           flag,
-		generators:     initGenerator(DefaultDialect, dialect.DefaultDialectOption()),
 		crawler:        crawler,
-		dialect:        DefaultDialect,
-		dialectOption:  dialect.DefaultDialectOption(),
+		dialect:        dialectName,
+		dialectOption:  dialectOption,
+		err:            err,
+	}
+	if err == nil {
+		gen.generators = initGenerator(dialectName, dialectOption)
+		gen.writer, gen.err = NewMigrationWriter(flag.Format)
+	}
+	return gen
+}
+
+// getDialectOption returns the registered options of the dialect, the default
+// dialect when the name is empty.
+func getDialectOption(name string) (string, *dialect.DialectOption, error) {
+	if name == "" {
+		name = DefaultDialect
+	}
+
+	d, err := registry.Get(name)
+	if err != nil {
+		return name, nil, err
 	}
+	return d.Name, d.Options(), nil
 }
 
 func initGenerator(dialect string, do *dialect.DialectOption) *generators {
+	concurrent := *do
+	concurrent.SupportConcurrently = true
+
 	return &generators{
 		ctg: NewCreateTableGenerator(dialect, do),
 		cig: NewCreateIndexGenerator(dialect, do),
 		atg: NewAlterTableGenerator(dialect, do),
 		dig: NewDropIndexGenerator(dialect, do),
 		dtg: NewDropTableGenerator(dialect, do),
+		gg:  NewGrantGenerator(dialect, do),
+		nng: NewNotNullGenerator(dialect, do),
+		tg:  NewTransitionGenerator(dialect, do),
+
+		concurrentCig: NewCreateIndexGenerator(dialect, &concurrent),
+		concurrentDig: NewDropIndexGenerator(dialect, &concurrent),
 	}
 }
 
 func getTargetPath(dir, target string) (string, string) {
-	ext := filepath.Ext(target)
-	if ext != "" {
-		target = strings.Replace(target, ext, "", -1)
-	} else {
-		ext = DefaultMigrationExt
-	}
+	up, down := migrationFileNames(target)
+	return fmt.Sprintf("%s/%s", dir, up), fmt.Sprintf("%s/%s", dir, down)
+}
 
-	upFilename := fmt.Sprintf("%s/%s.up%s", dir, target, ext)
-	downFilename := fmt.Sprintf("%s/%s.down%s", dir, target, ext)
-	return upFilename, downFilename
+// getSnapshotPath returns the snapshot path of the migration target.
+func getSnapshotPath(dir, target string) string {
+	target = strings.TrimSuffix(target, filepath.Ext(target))
+	return fmt.Sprintf("%s/%s%s", dir, target, schema.SnapshotSuffix)
 }
 
 func (gen *SqlGenerator) CreateTableGenerator() CreateTableGenerator {
@@ -101,125 +150,431 @@ func (gen *SqlGenerator) DropTableGenerator() DropTableGenerator {
 	return gen.generators.dtg
 }
 
-func (gen *SqlGenerator) Generate() error {
-	currentSchemas, err := gen.crawler.GetSchemas()
+func (gen *SqlGenerator) GrantGenerator() GrantGenerator {
+	return gen.generators.gg
+}
+
+func (gen *SqlGenerator) NotNullGenerator() NotNullGenerator {
+	return gen.generators.nng
+}
+
+func (gen *SqlGenerator) TransitionGenerator() TransitionGenerator {
+	return gen.generators.tg
+}
+
+// Validate checks the target schemas only use what the dialect can express.
+func (gen *SqlGenerator) Validate() error {
+	if gen.err != nil {
+		return gen.err
+	}
+
+	d, err := registry.Get(gen.dialect)
 	if err != nil {
 		return err
 	}
 
-	planner := diff.NewSchema(currentSchemas, gen.schemas)
-	migrationPlanner, err := planner.GeneratePlan()
+	if gen.flag.Online && !gen.dialectOption.SupportOnline {
+		return fmt.Errorf("%w: online migrations for %s dialect", registry.ErrUnsupportedFeature, d.Name)
+	}
+	return d.Validate(gen.schemas)
+}
+
+// Plan validates the target schemas and diffs them against the crawled ones,
+// refusing the type changes the dialect cannot convert.
+func (gen *SqlGenerator) Plan(ctx context.Context) (*step.MigrationPlanner, error) {
+	plan, err := gen.diff(ctx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	d, err := registry.Get(gen.dialect)
+	if err != nil {
+		return nil, err
+	}
+	err = d.ValidatePlan(plan)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (gen *SqlGenerator) diff(ctx context.Context) (*step.MigrationPlanner, error) {
+	differ, err := gen.differ(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return differ.GeneratePlan()
+}
 
-	err = gen.UpMigration(migrationPlanner)
+// differ validates the target schemas and crawls the current ones to diff
+// them against.
+func (gen *SqlGenerator) differ(ctx context.Context) (*diff.Schema, error) {
+	err := gen.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	currentSchemas, err := gen.crawler.GetSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return diff.NewSchema(currentSchemas, gen.schemas).WithTableFilter(gen.flag.TableFilter), nil
+}
+
+func (gen *SqlGenerator) Generate(ctx context.Context) error {
+	migrationPlanner, err := gen.Plan(ctx)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println()
-	err = gen.DownMigration(migrationPlanner)
+	err = gen.Guards(migrationPlanner)
 	if err != nil {
 		return err
 	}
 
+	if gen.flag.Online || migrationPlanner.HasExpandedColumns() || gen.writer.Format() != FormatGolangMigrate {
+		err = gen.PhasedMigration(migrationPlanner)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = gen.UpMigration(migrationPlanner)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println()
+		err = gen.DownMigration(migrationPlanner)
+		if err != nil {
+			return err
+		}
+	}
+
 	fmt.Println()
 	err = gen.FullSchemaMigration()
 	if err != nil {
 		return err
 	}
 
+	if gen.upWritten {
+		fmt.Println()
+		err = gen.Snapshot(migrationPlanner)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println()
+		err = gen.Sum()
+		if err != nil {
+			return err
+		}
+	}
+
 	fmt.Println("\nDatabase Migration Generation Completed.")
 	return nil
 }
 
+// Guards refuses the plans losing data, holding locks on large tables or
+// rewriting tables online, and the migration directories edited since their
+// sum file was written.
+func (gen *SqlGenerator) Guards(plan *step.MigrationPlanner) error {
+	err := gen.Guard(plan)
+	if err != nil {
+		return err
+	}
+
+	err = gen.LockGuard(plan)
+	if err != nil {
+		return err
+	}
+
+	err = gen.OnlineGuard(plan)
+	if err != nil {
+		return err
+	}
+
+	return gen.SumGuard()
+}
+
+// Guard prints the risky and destructive changes of the plan, and refuses
+// the destructive ones unless they are allowed or acknowledged by the schemas.
+func (gen *SqlGenerator) Guard(plan *step.MigrationPlanner) error {
+	risky := make([]*step.Change, 0)
+	destructive := make([]*step.Change, 0)
+	refused := 0
+	for _, change := range plan.Changes(gen.flag.SkipDropTable) {
+		switch change.Risk {
+		case step.Risky:
+			risky = append(risky, change)
+		case step.Destructive:
+			destructive = append(destructive, change)
+			if !change.Acknowledged {
+				refused++
+			}
+		}
+	}
+
+	if len(risky) > 0 {
+		fmt.Println(color.YellowString("Risky changes:"))
+		for _, change := range risky {
+			fmt.Printf("\t%s\n", change)
+		}
+		fmt.Println()
+	}
+
+	if len(destructive) > 0 {
+		fmt.Println(color.RedString("Destructive changes, the following data would be lost:"))
+		for _, change := range destructive {
+			if change.Acknowledged {
+				fmt.Printf("\t%s (acknowledged)\n", change)
+			} else {
+				fmt.Printf("\t%s\n", change)
+			}
+		}
+		fmt.Println()
+	}
+
+	if refused > 0 && !gen.flag.AllowDestructive {
+		return fmt.Errorf("%w: %d change(s) would lose data, use --allow-destructive or list the columns in allow_destructive of the schema", ErrDestructiveChanges, refused)
+	}
+	return nil
+}
+
 func (gen *SqlGenerator) FullSchemaMigration() error {
 	fmt.Println("🚀 Generating up full schema migration file")
 
 	createTables := gen.GenerateCreateTables(gen.schemas)
-	err := gen.Writer(FullSchemaMigrationFilename, getContents(createTables))
+	err := gen.Writer(gen.fullSchemaFile(), getContents(createTables))
+	if err != nil {
+		fmt.Println(color.RedString("Failed"))
+		return err
+	}
+	fmt.Println(color.GreenString("Succeeded"))
+	return nil
+}
+
+func (gen *SqlGenerator) fullSchemaFile() string {
+	if gen.flag.FullSchemaFile == "" {
+		return FullSchemaMigrationFilename
+	}
+	return gen.flag.FullSchemaFile
+}
+
+// Snapshot writes the target schemas next to the migration, as the
+// baseline of the next migration generated with a snapshot crawler. The
+// columns of the transitions started by the plan are kept until contracted,
+// and the new columns stay nullable until then.
+func (gen *SqlGenerator) Snapshot(plan *step.MigrationPlanner) error {
+	fmt.Println("🚀 Generating schema snapshot file")
+	fmt.Printf("Target file: %s\n", color.HiBlueString(gen.snapshotFile))
+
+	content, err := schema.MarshalSnapshot(snapshotSchemas(gen.schemas, plan))
+	if err != nil {
+		fmt.Println(color.RedString("Failed"))
+		return err
+	}
+
+	err = gen.Writer(gen.snapshotFile, content)
+	if err != nil {
+		fmt.Println(color.RedString("Failed"))
+		return err
+	}
+	fmt.Println(color.GreenString("Succeeded"))
+	return nil
+}
+
+// SumGuard refuses to add migrations to a directory whose migrations no
+// longer match its sum file, which the new sum file would silently accept.
+// A directory without a sum file gets its first one.
+func (gen *SqlGenerator) SumGuard() error {
+	err := migrate.Verify(gen.migrationDir())
+	if errors.Is(err, migrate.ErrSumNotExists) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w, review the changes then run migrate:hash", err)
+	}
+	return nil
+}
+
+// Sum writes the sum file of the migration directory, recording the hash of
+// every migration including the ones just written.
+func (gen *SqlGenerator) Sum() error {
+	fmt.Println("🚀 Generating migration sum file")
+	fmt.Printf("Target file: %s\n", color.HiBlueString(filepath.Join(gen.migrationDir(), migrate.SumFile)))
+
+	err := migrate.WriteSum(gen.migrationDir())
 	if err != nil {
 		fmt.Println(color.RedString("Failed"))
 		return err
 	}
+
+	if writer, ok := gen.writer.(DirectoryWriter); ok {
+		err = writer.WriteDirectory(gen.migrationDir())
+		if err != nil {
+			fmt.Println(color.RedString("Failed"))
+			return err
+		}
+	}
 	fmt.Println(color.GreenString("Succeeded"))
 	return nil
 }
 
+// migrationDir is the directory the migrations are written to, the output
+// target may hold a path of its own.
+func (gen *SqlGenerator) migrationDir() string {
+	return filepath.Dir(gen.dbUpFilename)
+}
+
 func (gen *SqlGenerator) UpMigration(plan *step.MigrationPlanner) error {
 	fmt.Println("🚀 Generating up database migration files")
 	fmt.Printf("Target file: %s\n", color.HiBlueString(gen.dbUpFilename))
 
-	createTables := gen.GenerateCreateTables(plan.CreateTable)
-	alterTables := gen.AlterTableUp(plan.AlterSchema)
-	dropTables := []byte{}
-	if !gen.flag.SkipDropTable {
-		dropTables = gen.GenerateDropTables(plan.DropTable)
-	}
-
-	content := getContents(createTables, dropTables, alterTables)
-	if len(bytes.TrimSpace(content)) == 0 {
+	content := gen.UpContent(plan)
+	if len(content) == 0 {
 		fmt.Println(color.YellowString("No changes being detected, skipping..."))
 		return nil
 	}
 
-	if gen.dialectOption.SupportTransaction {
-		content = getContents(
-			gen.dialectOption.BeginClause, content, gen.dialectOption.CommitClause,
-		)
-	}
 	err := gen.Writer(gen.dbUpFilename, content)
 	if err != nil {
 		fmt.Println(color.RedString("Failed"))
 		return err
 	}
+	gen.upWritten = true
 	fmt.Println(color.GreenString("Succeeded"))
 	return nil
 }
 
+// UpContent returns the up migration of the plan, empty when nothing changes.
+func (gen *SqlGenerator) UpContent(plan *step.MigrationPlanner) []byte {
+	createTables := gen.GenerateCreateTables(plan.CreateTable)
+	alterTables := gen.AlterTableUp(plan.AlterSchema)
+	dropTables := []byte{}
+	if !gen.flag.SkipDropTable {
+		dropTables = gen.GenerateDropTables(plan.DropTable)
+	}
+
+	return gen.wrapTransaction(getContents(createTables, dropTables, alterTables))
+}
+
+// DownContent returns the down migration of the plan, empty when nothing changes.
+// Tables are dropped and created back in the reverse order of the up migration.
+func (gen *SqlGenerator) DownContent(plan *step.MigrationPlanner) []byte {
+	createTableDown := gen.GenerateDropTables(reverseSchemas(plan.CreateTable))
+	alterTables := gen.AlterTableDown(plan.AlterSchema)
+	dropTableDown := []byte{}
+	if !gen.flag.SkipDropTable {
+		dropTableDown = gen.GenerateCreateTables(reverseSchemas(plan.DropTable))
+	}
+
+	return gen.wrapTransaction(getContents(createTableDown, dropTableDown, alterTables))
+}
+
+func (gen *SqlGenerator) wrapTransaction(content []byte) []byte {
+	if len(content) == 0 || !gen.dialectOption.SupportTransaction {
+		return content
+	}
+	return getContents(gen.dialectOption.BeginClause, content, gen.dialectOption.CommitClause)
+}
+
 func (gen *SqlGenerator) AlterTableUp(alterSchemas map[string]*step.AlterSchema) []byte {
 	contents := make([][]byte, 0)
-	for _, as := range alterSchemas {
+	for _, as := range step.SortAlterSchemas(alterSchemas) {
 		diBuf := sb.NewSQLBuilder()
 		for _, idx := range as.DroppedIndices {
-			gen.DropIndexGenerator().Generate(diBuf, idx)
+			gen.writeLock(diBuf, step.DropIndexLock(as.Name, idx, gen.dialectOption.SupportConcurrently))
+			gen.DropIndexGenerator().Generate(diBuf, as.Name, idx)
 			diBuf.WriteNewLine()
 		}
 
+		coBuf := sb.NewSQLBuilder()
+		for _, transition := range as.ContractedColumns {
+			gen.TransitionGenerator().Contract(coBuf, as.Name, transition)
+			coBuf.WriteNewLine()
+		}
+
 		atBuf := sb.NewSQLBuilder()
+		gen.writeLock(atBuf, as.AlterLock())
 		gen.AlterTableGenerator().Generate(atBuf, as)
 
+		exBuf := sb.NewSQLBuilder()
+		for _, transition := range as.ExpandedColumns {
+			gen.TransitionGenerator().Expand(exBuf, as.Name, transition)
+			exBuf.WriteNewLine()
+		}
+
 		aiBuf := sb.NewSQLBuilder()
 		for _, idx := range as.AddedIndices {
+			gen.writeLock(aiBuf, step.CreateIndexLock(as.Name, idx, gen.dialectOption.SupportConcurrently))
 			gen.CreateIndexGenerator().Generate(aiBuf, as.Name, idx)
 			aiBuf.WriteNewLine()
 		}
 
-		contents = append(contents, getContents(atBuf.Bytes(), diBuf.Bytes(), aiBuf.Bytes()))
+		grBuf := sb.NewSQLBuilder()
+		if gen.dialectOption.SupportGrant {
+			for _, grant := range as.RevokedPrivileges {
+				gen.GrantGenerator().Revoke(grBuf, as.Name, grant)
+				grBuf.WriteNewLine()
+			}
+			for _, grant := range as.GrantedPrivileges {
+				gen.GrantGenerator().Generate(grBuf, as.Name, grant)
+				grBuf.WriteNewLine()
+			}
+		}
+
+		contents = append(contents, getContents(coBuf.Bytes(), atBuf.Bytes(), exBuf.Bytes(), diBuf.Bytes(), aiBuf.Bytes(), grBuf.Bytes()))
 	}
 	return bytes.Join(contents, SectionSeparator)
 }
 
 func (gen *SqlGenerator) AlterTableDown(alterSchemas map[string]*step.AlterSchema) []byte {
 	contents := make([][]byte, 0)
-	for _, as := range alterSchemas {
+	for _, as := range step.SortAlterSchemas(alterSchemas) {
 		aiBuf := sb.NewSQLBuilder()
 		for _, idx := range as.AddedIndices {
-			gen.DropIndexGenerator().Generate(aiBuf, idx)
+			gen.writeLock(aiBuf, step.DropIndexLock(as.Name, idx, gen.dialectOption.SupportConcurrently))
+			gen.DropIndexGenerator().Generate(aiBuf, as.Name, idx)
 			aiBuf.WriteNewLine()
 		}
 
+		exBuf := sb.NewSQLBuilder()
+		for _, transition := range as.ExpandedColumns {
+			gen.TransitionGenerator().RollbackExpand(exBuf, as.Name, transition)
+			exBuf.WriteNewLine()
+		}
+
 		atBuf := sb.NewSQLBuilder()
+		gen.writeLock(atBuf, as.RollbackLock())
 		gen.AlterTableGenerator().Rollback(atBuf, as)
 
+		coBuf := sb.NewSQLBuilder()
+		for _, transition := range as.ContractedColumns {
+			gen.TransitionGenerator().RollbackContract(coBuf, as.Name, transition)
+			coBuf.WriteNewLine()
+		}
+
 		diBuf := sb.NewSQLBuilder()
 		for _, idx := range as.DroppedIndices {
+			gen.writeLock(diBuf, step.CreateIndexLock(as.Name, idx, gen.dialectOption.SupportConcurrently))
 			gen.CreateIndexGenerator().Generate(diBuf, as.Name, idx)
 			diBuf.WriteNewLine()
 		}
 
-		contents = append(contents, getContents(atBuf.Bytes(), diBuf.Bytes(), aiBuf.Bytes()))
+		grBuf := sb.NewSQLBuilder()
+		if gen.dialectOption.SupportGrant {
+			for _, grant := range as.GrantedPrivileges {
+				gen.GrantGenerator().Revoke(grBuf, as.Name, grant)
+				grBuf.WriteNewLine()
+			}
+			for _, grant := range as.RevokedPrivileges {
+				gen.GrantGenerator().Generate(grBuf, as.Name, grant)
+				grBuf.WriteNewLine()
+			}
+		}
+
+		contents = append(contents, getContents(exBuf.Bytes(), atBuf.Bytes(), coBuf.Bytes(), diBuf.Bytes(), aiBuf.Bytes(), grBuf.Bytes()))
 	}
 	return bytes.Join(contents, SectionSeparator)
 }
@@ -228,24 +583,12 @@ func (gen *SqlGenerator) DownMigration(plan *step.MigrationPlanner) error {
 	fmt.Println("🚀 Generating down database migration files")
 	fmt.Printf("Target file: %s\n", color.HiBlueString(gen.dbDownFilename))
 
-	createTableDown := gen.GenerateDropTables(plan.CreateTable)
-	alterTables := gen.AlterTableDown(plan.AlterSchema)
-	dropTableDown := []byte{}
-	if !gen.flag.SkipDropTable {
-		dropTableDown = gen.GenerateCreateTables(plan.DropTable)
-	}
-
-	content := getContents(createTableDown, dropTableDown, alterTables)
-	if len(bytes.TrimSpace(content)) == 0 {
+	content := gen.DownContent(plan)
+	if len(content) == 0 {
 		fmt.Println(color.YellowString("No changes being detected, skipping..."))
 		return nil
 	}
 
-	if gen.dialectOption.SupportTransaction {
-		content = getContents(
-			gen.dialectOption.BeginClause, content, gen.dialectOption.CommitClause,
-		)
-	}
 	err := gen.Writer(gen.dbDownFilename, content)
 	if err != nil {
 		fmt.Println(color.RedString("Failed"))
@@ -283,6 +626,18 @@ func (gen *SqlGenerator) GenerateCreateTables(schemas []*config.Schema) []byte {
 		if len(schema.Index) > 0 {
 			sb.WriteNewLine()
 		}
+
+		if !gen.dialectOption.SupportGrant {
+			continue
+		}
+
+		for _, grant := range schema.Grants {
+			gen.GrantGenerator().Generate(sb, schema.Name, grant)
+			sb.WriteNewLine()
+		}
+		if len(schema.Grants) > 0 {
+			sb.WriteNewLine()
+		}
 	}
 
 	return bytes.TrimSpace(sb.Bytes())
@@ -291,6 +646,7 @@ func (gen *SqlGenerator) GenerateCreateTables(schemas []*config.Schema) []byte {
 func (gen *SqlGenerator) GenerateDropTables(schemas []*config.Schema) []byte {
 	sb := sb.NewSQLBuilder()
 	for _, schema := range schemas {
+		gen.writeLock(sb, step.DropTableLock(schema.Name))
 		gen.DropTableGenerator().Generate(sb, schema)
 		sb.WriteNewLine()
 		sb.WriteNewLine()
@@ -299,6 +655,42 @@ func (gen *SqlGenerator) GenerateDropTables(schemas []*config.Schema) []byte {
 	return bytes.TrimSpace(sb.Bytes())
 }
 
+func snapshotSchemas(schemas []*config.Schema, plan *step.MigrationPlanner) []*config.Schema {
+	snapshot := make([]*config.Schema, 0, len(schemas))
+	for _, sc := range schemas {
+		as := plan.AlterSchema[sc.Name]
+		if as == nil || !as.IsColumnsExpanded() {
+			snapshot = append(snapshot, sc)
+			continue
+		}
+
+		expanded := *sc
+		expanded.Fields = make([]*config.Field, 0, len(sc.Fields)+len(as.ExpandedColumns))
+		for _, field := range sc.Fields {
+			expanding := false
+			for _, transition := range as.ExpandedColumns {
+				if transition.To.Name == field.Name {
+					expanded.Fields = append(expanded.Fields, transition.From, step.Nullable(field))
+					expanding = true
+				}
+			}
+			if !expanding {
+				expanded.Fields = append(expanded.Fields, field)
+			}
+		}
+		snapshot = append(snapshot, &expanded)
+	}
+	return snapshot
+}
+
+func reverseSchemas(schemas []*config.Schema) []*config.Schema {
+	reversed := make([]*config.Schema, 0, len(schemas))
+	for i := len(schemas) - 1; i >= 0; i-- {
+		reversed = append(reversed, schemas[i])
+	}
+	return reversed
+}
+
 func getContents(contents ...[]byte) []byte {
 	container := make([][]byte, 0)
 
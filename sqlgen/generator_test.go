@@ -1,9 +1,13 @@
 package sqlgen_test
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
@@ -11,8 +15,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/diff"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/migrate"
 	mock_schema "gitlab.com/wartek-id/core/tools/dbgen/sqlgen/mocks/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/registry"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
 	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
 )
 
 func TestSqlGenerator_Generate(t *testing.T) {
@@ -23,7 +32,7 @@ func TestSqlGenerator_Generate(t *testing.T) {
 	upTarget := fmt.Sprintf("%s.up.sql", target)
 	downTarget := fmt.Sprintf("%s.down.sql", target)
 	mockCrawler := mock_schema.NewMockSchema(ctrl)
-	mockCrawler.EXPECT().GetSchemas().Return([]*config.Schema{
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return([]*config.Schema{
 		{
 			Name: "documents",
 			Fields: []*config.Field{
@@ -164,8 +173,8 @@ func TestSqlGenerator_Generate(t *testing.T) {
 				},
 			},
 		},
-	}, &sqlgen.Flag{OutputTarget: target, SkipDropTable: false})
-	err := gen.Generate()
+	}, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputTarget: target, SkipDropTable: false, AllowDestructive: true})
+	err := gen.Generate(context.Background())
 	assert.NoError(t, err)
 
 	upMigration, err := os.ReadFile(upTarget)
@@ -204,6 +213,14 @@ func TestSqlGenerator_Generate(t *testing.T) {
 	assert.Contains(t, string(downMigration), "DROP INDEX IF EXISTS \"index_document_on_name_approval\";")
 	assert.Contains(t, string(downMigration), "CREATE TABLE IF NOT EXISTS \"example\" (\n\t\"id\" BIGSERIAL PRIMARY KEY,\n\t\"name\" VARCHAR(100)\n);")
 	assert.Contains(t, string(downMigration), "COMMIT;")
+
+	snapshot, err := schema.NewSnapshotSchema(filepath.Dir(target))
+	assert.NoError(t, err)
+	tables, err := snapshot.GetTables(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"documents", "user"}, tables)
+
+	assert.NoError(t, migrate.Verify(filepath.Dir(target)))
 }
 
 func TestSqlGenerator_GenerateNoDrop(t *testing.T) {
@@ -214,7 +231,7 @@ func TestSqlGenerator_GenerateNoDrop(t *testing.T) {
 	upTarget := fmt.Sprintf("%s.up.sql", target)
 	downTarget := fmt.Sprintf("%s.down.sql", target)
 	mockCrawler := mock_schema.NewMockSchema(ctrl)
-	mockCrawler.EXPECT().GetSchemas().Return([]*config.Schema{
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return([]*config.Schema{
 		{
 			Name: "documents",
 			Fields: []*config.Field{
@@ -355,8 +372,8 @@ func TestSqlGenerator_GenerateNoDrop(t *testing.T) {
 				},
 			},
 		},
-	}, &sqlgen.Flag{OutputTarget: target, SkipDropTable: true})
-	err := gen.Generate()
+	}, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputTarget: target, SkipDropTable: true, AllowDestructive: true})
+	err := gen.Generate(context.Background())
 	assert.NoError(t, err)
 
 	upMigration, err := os.ReadFile(upTarget)
@@ -405,7 +422,7 @@ func TestSqlGenerator_GenerateNoChanges(t *testing.T) {
 	upTarget := fmt.Sprintf("%s.up.sql", target)
 	downTarget := fmt.Sprintf("%s.down.sql", target)
 	mockCrawler := mock_schema.NewMockSchema(ctrl)
-	mockCrawler.EXPECT().GetSchemas().Return([]*config.Schema{
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return([]*config.Schema{
 		{
 			Name: "documents",
 			Fields: []*config.Field{
@@ -515,8 +532,8 @@ func TestSqlGenerator_GenerateNoChanges(t *testing.T) {
 				},
 			},
 		},
-	}, &sqlgen.Flag{OutputTarget: target, SkipDropTable: false})
-	err := gen.Generate()
+	}, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputTarget: target, SkipDropTable: false})
+	err := gen.Generate(context.Background())
 	assert.NoError(t, err)
 
 	upMig, err := os.ReadFile(upTarget)
@@ -526,6 +543,180 @@ func TestSqlGenerator_GenerateNoChanges(t *testing.T) {
 	downMig, err := os.ReadFile(downTarget)
 	assert.Error(t, err, "not found")
 	assert.Nil(t, downMig)
+
+	_, err = os.Stat(target + schema.SnapshotSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSqlGenerator_GenerateGrants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	target := filepath.Join(t.TempDir(), "generator")
+	upTarget := fmt.Sprintf("%s.up.sql", target)
+	downTarget := fmt.Sprintf("%s.down.sql", target)
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return([]*config.Schema{
+		{
+			Name: "documents",
+			Fields: []*config.Field{
+				{
+					Name: "id",
+					Type: "bigserial",
+				},
+			},
+			Grants: []*config.Grant{
+				{Role: "legacy", Privileges: []privilege.Privilege{privilege.Select}},
+				{Role: "reporting", Privileges: []privilege.Privilege{privilege.Insert}},
+			},
+		},
+	}, nil).AnyTimes()
+
+	gen := sqlgen.NewGenerator(mockCrawler, []*config.Schema{
+		{
+			Name: "documents",
+			Fields: []*config.Field{
+				{
+					Name: "id",
+					Type: "bigserial",
+				},
+			},
+			Grants: []*config.Grant{
+				{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+			},
+		},
+		{
+			Name: "user",
+			Fields: []*config.Field{
+				{
+					Name: "id",
+					Type: "bigserial",
+				},
+			},
+			Grants: []*config.Grant{
+				{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+			},
+		},
+	}, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputTarget: target, SkipDropTable: false})
+	err := gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	upMigration, err := os.ReadFile(upTarget)
+	assert.NoError(t, err)
+	assert.Contains(t, string(upMigration), "CREATE TABLE IF NOT EXISTS \"user\" (\n\t\"id\" BIGSERIAL\n);\n\nGRANT SELECT ON TABLE \"user\" TO \"reporting\";")
+	assert.Contains(t, string(upMigration), "REVOKE INSERT ON TABLE \"documents\" FROM \"reporting\";\nGRANT SELECT ON TABLE \"documents\" TO \"reporting\";")
+	assert.NotContains(t, string(upMigration), "legacy")
+	assert.NotContains(t, string(upMigration), "ALTER TABLE")
+
+	downMigration, err := os.ReadFile(downTarget)
+	assert.NoError(t, err)
+	assert.Contains(t, string(downMigration), "DROP TABLE IF EXISTS \"user\";")
+	assert.Contains(t, string(downMigration), "REVOKE SELECT ON TABLE \"documents\" FROM \"reporting\";\nGRANT INSERT ON TABLE \"documents\" TO \"reporting\";")
+	assert.NotContains(t, string(downMigration), "legacy")
+	assert.NotContains(t, string(downMigration), "ALTER TABLE")
+}
+
+func TestSqlGenerator_GenerateCockroach(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	target := filepath.Join(t.TempDir(), "generator")
+	upTarget := fmt.Sprintf("%s.up.sql", target)
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return([]*config.Schema{}, nil).AnyTimes()
+
+	gen := sqlgen.NewGenerator(mockCrawler, []*config.Schema{
+		{
+			Name: "user",
+			Fields: []*config.Field{
+				{
+					Name: "id",
+					Type: "bigserial",
+				},
+			},
+		},
+	}, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputTarget: target, Dialect: "cockroach"})
+	err := gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	upMigration, err := os.ReadFile(upTarget)
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS \"user\" (\n\t\"id\" INT8 DEFAULT unique_rowid()\n);", string(upMigration))
+}
+
+func TestSqlGenerator_GenerateUnsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	gen := sqlgen.NewGenerator(mockCrawler, []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{
+					Name: "id",
+					Type: "bigserial",
+				},
+			},
+			Storage: &config.Storage{Unlogged: true},
+		},
+	}, &sqlgen.Flag{OutputTarget: "generator", Dialect: "sqlite"})
+	err := gen.Generate(context.Background())
+	assert.EqualError(t, err, "unsupported feature: storage on users for sqlite dialect")
+}
+
+func TestSqlGenerator_GenerateUnknownFlag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	gen := sqlgen.NewGenerator(mockCrawler, []*config.Schema{}, &sqlgen.Flag{OutputTarget: "generator", Dialect: "oracle"})
+	err := gen.Generate(context.Background())
+	assert.True(t, errors.Is(err, registry.ErrUnknownDialect))
+
+	gen = sqlgen.NewGenerator(mockCrawler, []*config.Schema{}, &sqlgen.Flag{OutputTarget: "generator", Format: "liquibase"})
+	err = gen.Generate(context.Background())
+	assert.True(t, errors.Is(err, sqlgen.ErrUnsupportedFormat))
+}
+
+func TestSqlGenerator_SQLiteRoundTrip(t *testing.T) {
+	schemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "name", Type: "varchar", Limit: 100, Default: "anonymous", Options: []field_option.FieldOption{field_option.NotNull}},
+				{Name: "score", Type: "decimal", Limit: 10, Scale: 2},
+			},
+			Index: []*config.Index{
+				{Name: "index_users_on_name", Fields: []*config.IndexField{{Column: "name", Order: "ASC"}}},
+			},
+		},
+	}
+
+	db, err := sql.Open(schema.SQLiteDriver, ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// the tables are created with the statements of the migration
+	gen := sqlgen.NewGenerator(nil, schemas, &sqlgen.Flag{OutputTarget: "generator", Dialect: "sqlite"})
+	_, err = db.Exec(string(gen.GenerateCreateTables(schemas)))
+	assert.NoError(t, err)
+
+	crawled, err := schema.NewSQLiteSchema(db).GetSchemas(context.Background())
+	assert.NoError(t, err)
+	plan, err := diff.NewSchema(crawled, schemas).GeneratePlan()
+	assert.NoError(t, err)
+	assert.Empty(t, plan.CreateTable)
+	assert.Empty(t, plan.DropTable)
+	assert.Empty(t, plan.AlterSchema)
+}
+
+// fullSchemaFile keeps the full schema migration of a test out of the
+// package directory.
+func fullSchemaFile(t *testing.T) string {
+	return filepath.Join(t.TempDir(), "migration.sql")
 }
 
 func TestSqlGenerator_CreateTableGenerator(t *testing.T) {
@@ -552,3 +743,274 @@ func TestSqlGenerator_DropTableGenerator(t *testing.T) {
 	gen := sqlgen.NewGenerator(nil, []*config.Schema{}, &sqlgen.Flag{OutputTarget: "target"})
 	assert.NotNil(t, gen.DropTableGenerator())
 }
+
+func TestSqlGenerator_GrantGenerator(t *testing.T) {
+	gen := sqlgen.NewGenerator(nil, []*config.Schema{}, &sqlgen.Flag{OutputTarget: "target"})
+	assert.NotNil(t, gen.GrantGenerator())
+}
+
+func TestSqlGenerator_GenerateDeterministic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newSchemas := func(prefix string, tables int, grant privilege.Privilege) []*config.Schema {
+		schemas := make([]*config.Schema, 0, tables)
+		for i := 0; i < tables; i++ {
+			name := fmt.Sprintf("%s_%02d", prefix, i)
+			fields := []*config.Field{{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}}}
+			indices := make([]*config.Index, 0)
+			for c := 0; c < 4; c++ {
+				column := fmt.Sprintf("%s_col_%d", grant, c)
+				fields = append(fields, &config.Field{Name: column, Type: "int"})
+				indices = append(indices, &config.Index{
+					Name:   fmt.Sprintf("index_%s_on_%s", name, column),
+					Fields: []*config.IndexField{{Column: column}},
+				})
+			}
+
+			schemas = append(schemas, &config.Schema{
+				Name:   name,
+				Fields: fields,
+				Index:  indices,
+				Grants: []*config.Grant{
+					{Role: "reporting", Privileges: []privilege.Privilege{grant}},
+					{Role: "app", Privileges: []privilege.Privilege{grant}},
+				},
+			})
+		}
+		return schemas
+	}
+
+	// both sets share the common tables, which are altered
+	current := append(newSchemas("dropped", 8, privilege.Select), newSchemas("common", 8, privilege.Select)...)
+	target := append(newSchemas("created", 8, privilege.Insert), newSchemas("common", 8, privilege.Insert)...)
+
+	generate := func() map[string]string {
+		mockCrawler := mock_schema.NewMockSchema(ctrl)
+		mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return(current, nil)
+
+		dir := t.TempDir()
+		gen := sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "20230101000000_generator", AllowDestructive: true})
+		err := gen.Generate(context.Background())
+		assert.NoError(t, err)
+
+		files := make(map[string]string)
+		for _, name := range []string{"20230101000000_generator.up.sql", "20230101000000_generator.down.sql", "20230101000000_generator.snapshot.json"} {
+			b, err := os.ReadFile(filepath.Join(dir, name))
+			assert.NoError(t, err)
+			files[name] = string(b)
+		}
+		return files
+	}
+
+	first := generate()
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, first, generate())
+	}
+
+	up := first["20230101000000_generator.up.sql"]
+	assert.Less(t, strings.Index(up, `"created_00"`), strings.Index(up, `"created_07"`))
+	assert.Less(t, strings.Index(up, `"dropped_07"`), strings.Index(up, `"dropped_00"`))
+}
+
+func TestSqlGenerator_GenerateDestructive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	current := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "legacy", Type: "text"},
+			},
+		},
+	}
+	target := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+			},
+		},
+	}
+
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return(current, nil).AnyTimes()
+
+	dir := t.TempDir()
+	gen := sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "generator"})
+	err := gen.Generate(context.Background())
+	assert.True(t, errors.Is(err, sqlgen.ErrDestructiveChanges))
+	_, err = os.Stat(filepath.Join(dir, "generator.up.sql"))
+	assert.True(t, os.IsNotExist(err))
+
+	target[0].AllowDestructive = []string{"legacy"}
+	gen = sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "generator"})
+	err = gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	upMigration, err := os.ReadFile(filepath.Join(dir, "generator.up.sql"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(upMigration), `DROP COLUMN "legacy"`)
+}
+
+func TestSqlGenerator_GenerateSum(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return([]*config.Schema{}, nil).AnyTimes()
+
+	schemas := []*config.Schema{
+		{
+			Name:   "users",
+			Fields: []*config.Field{{Name: "id", Type: "bigserial"}},
+		},
+	}
+
+	dir := t.TempDir()
+	gen := sqlgen.NewGenerator(mockCrawler, schemas, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "1_users"})
+	err := gen.Generate(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, migrate.Verify(dir))
+
+	err = os.WriteFile(filepath.Join(dir, "1_users.up.sql"), []byte(`CREATE TABLE "users" ("id" BIGINT);`), 0644)
+	assert.NoError(t, err)
+
+	gen = sqlgen.NewGenerator(mockCrawler, schemas, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "2_users"})
+	err = gen.Generate(context.Background())
+	var sumErr *migrate.SumError
+	assert.True(t, errors.As(err, &sumErr))
+	assert.Equal(t, []string{"1_users.up.sql"}, sumErr.Edited)
+	_, err = os.Stat(filepath.Join(dir, "2_users.up.sql"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSqlGenerator_GenerateImpossibleCast(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	current := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "joined", Type: "bigint"},
+			},
+		},
+	}
+	target := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "joined", Type: "timestamptz"},
+			},
+		},
+	}
+
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return(current, nil).AnyTimes()
+
+	dir := t.TempDir()
+	flag := &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "generator", AllowDestructive: true}
+	gen := sqlgen.NewGenerator(mockCrawler, target, flag)
+	err := gen.Generate(context.Background())
+	assert.True(t, errors.Is(err, registry.ErrImpossibleCast))
+	_, err = os.Stat(filepath.Join(dir, "generator.up.sql"))
+	assert.True(t, os.IsNotExist(err))
+
+	// the down migration cannot cast the timestamps back either
+	target[0].Fields[1].Using = `to_timestamp("joined")`
+	gen = sqlgen.NewGenerator(mockCrawler, target, flag)
+	err = gen.Generate(context.Background())
+	assert.True(t, errors.Is(err, registry.ErrImpossibleCast))
+	_, err = os.Stat(filepath.Join(dir, "generator.down.sql"))
+	assert.True(t, os.IsNotExist(err))
+
+	target[0].Fields[1].DownUsing = `extract(epoch FROM "joined")::BIGINT`
+	gen = sqlgen.NewGenerator(mockCrawler, target, flag)
+	err = gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	upMigration, err := os.ReadFile(filepath.Join(dir, "generator.up.sql"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(upMigration), `SET DATA TYPE TIMESTAMPTZ USING to_timestamp("joined")`)
+
+	downMigration, err := os.ReadFile(filepath.Join(dir, "generator.down.sql"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(downMigration), `SET DATA TYPE BIGINT USING extract(epoch FROM "joined")::BIGINT`)
+}
+
+func TestSqlGenerator_GenerateTransition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	current := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "mail", Type: "varchar", Limit: 100},
+			},
+		},
+	}
+	target := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "email", Type: "varchar", Limit: 100, RenamedFrom: "mail"},
+			},
+		},
+	}
+
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return(current, nil).AnyTimes()
+
+	dir := t.TempDir()
+	gen := sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "20240102030405_users", BackfillBatchSize: 100})
+	err := gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	assert.NoError(t, err)
+	sort.Strings(files)
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	assert.Equal(t, []string{
+		"20240102030405_users.up.sql",
+		"20240102030406_users_backfill_users_email.up.sql",
+	}, files)
+
+	upMigration, err := os.ReadFile(filepath.Join(dir, "20240102030405_users.up.sql"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(upMigration), "ADD COLUMN \"email\" VARCHAR(100);")
+	assert.Contains(t, string(upMigration), "CREATE TRIGGER \"users_sync_email\" BEFORE INSERT OR UPDATE ON \"users\"")
+	assert.NotContains(t, string(upMigration), "DROP COLUMN \"mail\"")
+
+	backfill, err := os.ReadFile(filepath.Join(dir, "20240102030406_users_backfill_users_email.up.sql"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(backfill), "BEGIN;")
+	assert.Contains(t, string(backfill), "ORDER BY \"id\" LIMIT 100")
+
+	// the snapshot keeps both columns, so the next migration contracts
+	snapshot, err := schema.NewSnapshotSchema(dir)
+	assert.NoError(t, err)
+	expanded, err := snapshot.GetSchemas(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, expanded[0].Fields, 3)
+
+	gen = sqlgen.NewGenerator(snapshot, target, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "20240102030407_users"})
+	err = gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	contract, err := os.ReadFile(filepath.Join(dir, "20240102030407_users.up.sql"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(contract), "DROP TRIGGER IF EXISTS \"users_sync_email\" ON \"users\";")
+	assert.Contains(t, string(contract), "DROP COLUMN \"mail\"")
+	_, err = os.Stat(filepath.Join(dir, "20240102030408_users_backfill_users_email.up.sql"))
+	assert.True(t, os.IsNotExist(err))
+}
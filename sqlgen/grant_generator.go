@@ -0,0 +1,94 @@
+package sqlgen
+
+import (
+	"strings"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dialect"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/exp"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/sb"
+)
+
+type GrantGenerator interface {
+	Dialect() string
+	DialectOptions() *dialect.DialectOption
+	ExpressionSQLGenerator() exp.ExpressionSQLGenerator
+	Generate(sb.SQLBuilder, string, *config.Grant)
+	Revoke(sb.SQLBuilder, string, *config.Grant)
+}
+
+type grantGenerator struct {
+	dialect        string
+	esg            exp.ExpressionSQLGenerator
+	dialectOptions *dialect.DialectOption
+}
+
+func NewGrantGenerator(dialect string, do *dialect.DialectOption) GrantGenerator {
+	return &grantGenerator{
+		dialect:        dialect,
+		dialectOptions: do,
+		esg:            exp.NewExpressionSQLGenerator(dialect, do),
+	}
+}
+
+func (gg *grantGenerator) Dialect() string {
+	return gg.dialect
+}
+
+func (gg *grantGenerator) DialectOptions() *dialect.DialectOption {
+	return gg.dialectOptions
+}
+
+func (gg *grantGenerator) ExpressionSQLGenerator() exp.ExpressionSQLGenerator {
+	return gg.esg
+}
+
+func (gg *grantGenerator) Generate(b sb.SQLBuilder, tblName string, grant *config.Grant) {
+	b.Write(gg.dialectOptions.GrantClause)
+	gg.privilegesSQL(b, tblName, grant)
+	b.Write(gg.dialectOptions.ToFragment)
+	gg.roleExpression(b, grant.Role)
+	b.WriteRunes(gg.dialectOptions.SemiColonRune)
+}
+
+func (gg *grantGenerator) Revoke(b sb.SQLBuilder, tblName string, grant *config.Grant) {
+	b.Write(gg.dialectOptions.RevokeClause)
+	gg.privilegesSQL(b, tblName, grant)
+	b.Write(gg.dialectOptions.FromFragment)
+	gg.roleExpression(b, grant.Role)
+	b.WriteRunes(gg.dialectOptions.SemiColonRune)
+}
+
+func (gg *grantGenerator) privilegesSQL(b sb.SQLBuilder, tblName string, grant *config.Grant) {
+	for i, priv := range grant.Privileges {
+		b.WriteString(string(priv))
+		if i != len(grant.Privileges)-1 {
+			b.WriteRunes(gg.dialectOptions.CommaRune)
+			b.WriteRunes(gg.dialectOptions.SpaceRune)
+		}
+	}
+
+	b.Write(gg.dialectOptions.OnFragment)
+	b.Write(gg.dialectOptions.TableFragment)
+	gg.ExpressionSQLGenerator().LiteralExpression(b, tblName)
+}
+
+func (gg *grantGenerator) roleExpression(b sb.SQLBuilder, role string) {
+	if strings.EqualFold(role, string(gg.dialectOptions.PublicFragment)) {
+		b.Write(gg.dialectOptions.PublicFragment)
+		return
+	}
+
+	// the user and the host are quoted apart, a quoted user@host being the
+	// user of that name on any host
+	if gg.dialectOptions.AccountHostRune != 0 {
+		if i := strings.LastIndexByte(role, byte(gg.dialectOptions.AccountHostRune)); i >= 0 {
+			gg.ExpressionSQLGenerator().LiteralExpression(b, role[:i])
+			b.WriteRunes(gg.dialectOptions.AccountHostRune)
+			gg.ExpressionSQLGenerator().LiteralExpression(b, role[i+1:])
+			return
+		}
+	}
+
+	gg.ExpressionSQLGenerator().LiteralExpression(b, role)
+}
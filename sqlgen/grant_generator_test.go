@@ -0,0 +1,98 @@
+package sqlgen_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dialect"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/sb"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+func TestGrantGenerator_Dialect(t *testing.T) {
+	dial := "postgres"
+	do := dialect.DefaultDialectOption()
+
+	sqlGen := sqlgen.NewGrantGenerator(dial, do)
+	assert.Equal(t, dial, sqlGen.Dialect())
+}
+
+func TestGrantGenerator_DialectOptions(t *testing.T) {
+	dial := "postgres"
+	do := dialect.DefaultDialectOption()
+
+	sqlGen := sqlgen.NewGrantGenerator(dial, do)
+	assert.Equal(t, do, sqlGen.DialectOptions())
+}
+
+func TestGrantGenerator_ExpressionSQLGenerator(t *testing.T) {
+	dial := "postgres"
+	do := dialect.DefaultDialectOption()
+
+	sqlGen := sqlgen.NewGrantGenerator(dial, do)
+	assert.NotNil(t, sqlGen.ExpressionSQLGenerator())
+}
+
+func TestGrantGenerator_Generate(t *testing.T) {
+	testCases := []struct {
+		dialect *dialect.DialectOption
+		input   *config.Grant
+		result  string
+	}{
+		{
+			dialect: dialect.DefaultDialectOption(),
+			input: &config.Grant{
+				Role:       "reporting",
+				Privileges: []privilege.Privilege{privilege.Select},
+			},
+			result: `GRANT SELECT ON TABLE "users" TO "reporting";`,
+		},
+		{
+			dialect: dialect.DefaultDialectOption(),
+			input: &config.Grant{
+				Role:       "public",
+				Privileges: []privilege.Privilege{privilege.Select, privilege.Insert},
+			},
+			result: `GRANT SELECT, INSERT ON TABLE "users" TO PUBLIC;`,
+		},
+		{
+			dialect: dialect.MySQLDialectOption(),
+			input: &config.Grant{
+				Role:       "reporting",
+				Privileges: []privilege.Privilege{privilege.Select},
+			},
+			result: "GRANT SELECT ON TABLE `users` TO `reporting`;",
+		},
+		{
+			dialect: dialect.MySQLDialectOption(),
+			input: &config.Grant{
+				Role:       "app@10.0.0.%",
+				Privileges: []privilege.Privilege{privilege.Select},
+			},
+			result: "GRANT SELECT ON TABLE `users` TO `app`@`10.0.0.%`;",
+		},
+	}
+
+	for _, tc := range testCases {
+		buf := sb.NewSQLBuilder()
+		sqlGen := sqlgen.NewGrantGenerator("postgres", tc.dialect)
+		sqlGen.Generate(buf, "users", tc.input)
+		result, err := buf.ToSQL()
+		assert.Nil(t, err)
+		assert.Equal(t, tc.result, result)
+	}
+}
+
+func TestGrantGenerator_Revoke(t *testing.T) {
+	buf := sb.NewSQLBuilder()
+	sqlGen := sqlgen.NewGrantGenerator("postgres", dialect.DefaultDialectOption())
+	sqlGen.Revoke(buf, "users", &config.Grant{
+		Role:       "reporting",
+		Privileges: []privilege.Privilege{privilege.Select, privilege.Update},
+	})
+	result, err := buf.ToSQL()
+	assert.Nil(t, err)
+	assert.Equal(t, `REVOKE SELECT, UPDATE ON TABLE "users" FROM "reporting";`, result)
+}
@@ -1,6 +1,7 @@
 package json
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -21,13 +22,13 @@ func NewSchemasGenerator(schemas schema.Schema) *JsonSchemasGenerator {
 	}
 }
 
-func (s *JsonSchemasGenerator) GenerateBySchemas(outputDir string) error {
+func (s *JsonSchemasGenerator) GenerateBySchemas(ctx context.Context, outputDir string) error {
 	err := os.MkdirAll(outputDir, 0755)
 	if err != nil {
 		return err
 	}
 
-	currentSchemas, err := s.schemas.GetSchemas()
+	currentSchemas, err := s.schemas.GetSchemas(ctx)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package json_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -17,7 +18,7 @@ func TestGenerateBySchemas(t *testing.T) {
 
 	outputDir := t.TempDir()
 	mockCrawler := mock_schema.NewMockSchema(ctrl)
-	mockCrawler.EXPECT().GetSchemas().Return([]*config.Schema{
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return([]*config.Schema{
 		{
 			Name: "example",
 			Fields: []*config.Field{
@@ -37,7 +38,7 @@ func TestGenerateBySchemas(t *testing.T) {
 		},
 	}, nil).AnyTimes()
 	gen := json.NewSchemasGenerator(mockCrawler)
-	err := gen.GenerateBySchemas(outputDir)
+	err := gen.GenerateBySchemas(context.Background(), outputDir)
 
 	assert.NoError(t, err)
 }
@@ -0,0 +1,87 @@
+package sqlgen
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/sb"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
+)
+
+var ErrLargeTableLocks = errors.New("migration locks large tables")
+
+// Locks returns the locks the up migrations take on the existing tables, as
+// generated: the indices are built concurrently and the columns set NOT NULL
+// by validated checks in online migrations. It is empty for the dialects
+// whose locks are not analyzed.
+func (gen *SqlGenerator) Locks(plan *step.MigrationPlanner) []*step.Lock {
+	locks := make([]*step.Lock, 0)
+	if !gen.dialectOption.SupportLockAnalysis {
+		return locks
+	}
+
+	if !gen.flag.SkipDropTable {
+		for _, sc := range plan.DropTable {
+			locks = append(locks, step.DropTableLock(sc.Name))
+		}
+	}
+
+	concurrently := gen.flag.Online || gen.dialectOption.SupportConcurrently
+	for _, as := range plan.AlteredTables() {
+		alter := as
+		if gen.flag.Online {
+			alter, _ = expandSchema(as)
+		}
+		if lock := alter.AlterLock(); lock != nil {
+			locks = append(locks, lock)
+		}
+
+		for _, idx := range as.DroppedIndices {
+			locks = append(locks, step.DropIndexLock(as.Name, idx, concurrently))
+		}
+		for _, idx := range as.AddedIndices {
+			locks = append(locks, step.CreateIndexLock(as.Name, idx, concurrently))
+		}
+	}
+	return locks
+}
+
+// LockGuard refuses the plan when it rewrites a table marked as large, or
+// blocks its writes while scanning it.
+func (gen *SqlGenerator) LockGuard(plan *step.MigrationPlanner) error {
+	large := make(map[string]bool)
+	for _, sc := range gen.schemas {
+		large[sc.Name] = sc.Large
+	}
+
+	refused := make([]*step.Lock, 0)
+	for _, lock := range gen.Locks(plan) {
+		if large[lock.Table] && lock.Disruptive() {
+			refused = append(refused, lock)
+		}
+	}
+	if len(refused) == 0 {
+		return nil
+	}
+
+	fmt.Println(color.RedString("Locks on large tables, the following statements would hold them:"))
+	for _, lock := range refused {
+		fmt.Printf("\t%s\n", lock)
+	}
+	fmt.Println()
+
+	return fmt.Errorf("%w: %d statement(s) would hold a large table, use --online, renamed_from or split the change", ErrLargeTableLocks, len(refused))
+}
+
+// writeLock precedes a statement with a comment giving its lock, when the
+// locks are annotated.
+func (gen *SqlGenerator) writeLock(b sb.SQLBuilder, lock *step.Lock) {
+	if lock == nil || !gen.flag.AnnotateLocks || !gen.dialectOption.SupportLockAnalysis {
+		return
+	}
+
+	b.Write(gen.dialectOption.LockCommentClause)
+	b.WriteString(lock.String())
+	b.WriteNewLine()
+}
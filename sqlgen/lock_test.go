@@ -0,0 +1,117 @@
+package sqlgen_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+)
+
+func lockSchemas() ([]*config.Schema, []*config.Schema) {
+	current := []*config.Schema{
+		{
+			Name: "events",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "count", Type: "int"},
+				{Name: "kind", Type: "varchar", Limit: 20},
+			},
+		},
+		{Name: "logs", Fields: []*config.Field{{Name: "id", Type: "bigint"}}},
+	}
+	target := []*config.Schema{
+		{
+			Name: "events",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "count", Type: "bigint"},
+				{Name: "kind", Type: "varchar", Limit: 50},
+			},
+			Index: []*config.Index{
+				{Name: "index_events_on_kind", Fields: []*config.IndexField{{Column: "kind", Order: "ASC"}}},
+			},
+		},
+	}
+	return current, target
+}
+
+func TestSqlGenerator_GenerateAnnotateLocks(t *testing.T) {
+	current, target := lockSchemas()
+
+	dir := t.TempDir()
+	gen := sqlgen.NewGenerator(schema.NewStaticSchema(current), target, &sqlgen.Flag{
+		FullSchemaFile:   fullSchemaFile(t),
+		OutputDirectory:  dir,
+		OutputTarget:     "events",
+		AllowDestructive: true,
+		AnnotateLocks:    true,
+	})
+	err := gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	upMigration, err := os.ReadFile(filepath.Join(dir, "events.up.sql"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(upMigration), "-- lock logs: ACCESS EXCLUSIVE (drop table)\nDROP TABLE IF EXISTS \"logs\";")
+	assert.Contains(t, string(upMigration), "-- lock events: ACCESS EXCLUSIVE, rewrites the table (change type of count to bigint)\nALTER TABLE IF EXISTS \"events\"\n")
+	assert.Contains(t, string(upMigration), "-- lock events: SHARE, holds the lock while scanning the table (create index index_events_on_kind)\nCREATE INDEX IF NOT EXISTS \"index_events_on_kind\"")
+
+	downMigration, err := os.ReadFile(filepath.Join(dir, "events.down.sql"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(downMigration), "-- lock events: ACCESS EXCLUSIVE (drop index index_events_on_kind)\nDROP INDEX IF EXISTS \"index_events_on_kind\";")
+	assert.Contains(t, string(downMigration), "-- lock events: ACCESS EXCLUSIVE, rewrites the table (change type of count to int, change type of kind to varchar(20))\nALTER TABLE IF EXISTS \"events\"\n")
+
+	// the other dialects are not annotated
+	gen = sqlgen.NewGenerator(schema.NewStaticSchema(current), target, &sqlgen.Flag{
+		FullSchemaFile:   fullSchemaFile(t),
+		OutputDirectory:  dir,
+		OutputTarget:     "events_mysql",
+		Dialect:          "mysql",
+		AllowDestructive: true,
+		AnnotateLocks:    true,
+	})
+	err = gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	upMigration, err = os.ReadFile(filepath.Join(dir, "events_mysql.up.sql"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(upMigration), "-- lock")
+}
+
+func TestSqlGenerator_GenerateLargeTable(t *testing.T) {
+	current, target := lockSchemas()
+	target[0].Large = true
+
+	dir := t.TempDir()
+	flag := &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "events", AllowDestructive: true}
+	gen := sqlgen.NewGenerator(schema.NewStaticSchema(current), target, flag)
+	err := gen.Generate(context.Background())
+	assert.True(t, errors.Is(err, sqlgen.ErrLargeTableLocks))
+	assert.EqualError(t, err, "migration locks large tables: 2 statement(s) would hold a large table, use --online, renamed_from or split the change")
+	_, err = os.Stat(filepath.Join(dir, "events.up.sql"))
+	assert.True(t, os.IsNotExist(err))
+
+	// online the index is built concurrently, the type change still rewrites
+	flag.Online = true
+	gen = sqlgen.NewGenerator(schema.NewStaticSchema(current), target, flag)
+	plan, err := gen.Plan(context.Background())
+	assert.NoError(t, err)
+	locks := make([]string, 0)
+	for _, lock := range gen.Locks(plan) {
+		if lock.Disruptive() {
+			locks = append(locks, lock.String())
+		}
+	}
+	assert.Equal(t, []string{"events: ACCESS EXCLUSIVE, rewrites the table (change type of count to bigint)"}, locks)
+
+	target[0].Fields[1].Type = "int"
+	gen = sqlgen.NewGenerator(schema.NewStaticSchema(current), target, flag)
+	err = gen.Generate(context.Background())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v2"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+)
+
+const DownMigrationSuffix = schema.DownMigrationSuffix
+
+var (
+	ErrInvalidVersion   = errors.New("invalid migration version")
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+)
+
+// Migration is a pair of up and down files of the migration directory, named
+// 20060102150405_name.up.sql and 20060102150405_name.down.sql.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load returns the migrations of the directory sorted by version, the down
+// file of a migration being empty when it has none.
+func Load(dir string) ([]*Migration, error) {
+	files, err := schema.MigrationFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]*Migration, 0, len(files))
+	for _, file := range files {
+		version, err := strconv.ParseUint(schema.MigrationVersion(file), 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidVersion, filepath.Base(file))
+		}
+
+		if n := len(migrations); n > 0 && migrations[n-1].Version == version {
+			return nil, fmt.Errorf("%w: %s and %s", ErrDuplicateVersion, migrations[n-1].Name, filepath.Base(file))
+		}
+
+		migration := &Migration{
+			Version: version,
+			Name:    strings.TrimSuffix(filepath.Base(file), schema.UpMigrationSuffix),
+			Up:      file,
+		}
+
+		down := strings.TrimSuffix(file, schema.UpMigrationSuffix) + DownMigrationSuffix
+		if _, err := os.Stat(down); err == nil {
+			migration.Down = down
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// ownsTransaction reports whether the SQL must run outside of a transaction
+// block: it begins and commits its own transactions, such as the generated
+// migrations wrapped in BEGIN and COMMIT, or holds a statement which cannot
+// run in a transaction block, such as CREATE INDEX CONCURRENTLY or a DO block
+// committing its batches. SQL which does not parse is left to the database
+// to report, inside a transaction.
+func ownsTransaction(sql string) bool {
+	result, err := pg_query.Parse(sql)
+	if err != nil {
+		return false
+	}
+
+	for _, raw := range result.GetStmts() {
+		stmt := raw.GetStmt()
+		switch {
+		case stmt.GetTransactionStmt() != nil,
+			stmt.GetDoStmt() != nil,
+			stmt.GetCallStmt() != nil,
+			stmt.GetVacuumStmt() != nil,
+			stmt.GetReindexStmt() != nil,
+			stmt.GetCreatedbStmt() != nil,
+			stmt.GetDropdbStmt() != nil,
+			stmt.GetAlterSystemStmt() != nil:
+			return true
+		case stmt.GetIndexStmt().GetConcurrent(),
+			stmt.GetDropStmt().GetConcurrent():
+			return true
+		}
+	}
+	return false
+}
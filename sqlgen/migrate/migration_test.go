@@ -0,0 +1,78 @@
+package migrate_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/migrate"
+)
+
+func writeMigrations(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		assert.Nil(t, err)
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"20230102000000_add_email.up.sql":    `ALTER TABLE "users" ADD COLUMN "email" TEXT;`,
+		"20230101000000_users.up.sql":        `CREATE TABLE "users" ("id" BIGSERIAL PRIMARY KEY);`,
+		"20230101000000_users.down.sql":      `DROP TABLE "users";`,
+		"20230101000000_users.snapshot.json": `[]`,
+		"README.md":                          "not a migration",
+	})
+
+	migrations, err := migrate.Load(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []*migrate.Migration{
+		{
+			Version: 20230101000000,
+			Name:    "20230101000000_users",
+			Up:      filepath.Join(dir, "20230101000000_users.up.sql"),
+			Down:    filepath.Join(dir, "20230101000000_users.down.sql"),
+		},
+		{
+			Version: 20230102000000,
+			Name:    "20230102000000_add_email",
+			Up:      filepath.Join(dir, "20230102000000_add_email.up.sql"),
+		},
+	}, migrations)
+}
+
+func TestLoad_MissingDirectory(t *testing.T) {
+	migrations, err := migrate.Load(filepath.Join(t.TempDir(), "missing"))
+	assert.Nil(t, err)
+	assert.Empty(t, migrations)
+}
+
+func TestLoad_InvalidVersion(t *testing.T) {
+	testCases := map[string]struct {
+		files map[string]string
+		err   error
+	}{
+		"not a number": {
+			files: map[string]string{"users.up.sql": ""},
+			err:   migrate.ErrInvalidVersion,
+		},
+		"duplicate": {
+			files: map[string]string{
+				"20230101000000_users.up.sql": "",
+				"20230101000000_logs.up.sql":  "",
+			},
+			err: migrate.ErrDuplicateVersion,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := migrate.Load(writeMigrations(t, tc.files))
+			assert.True(t, errors.Is(err, tc.err), err)
+		})
+	}
+}
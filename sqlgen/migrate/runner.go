@@ -0,0 +1,346 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
+)
+
+// NoVersion is the version of a database without any applied migration.
+const NoVersion = uint64(0)
+
+var (
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrNoDownFile     = errors.New("migration has no down file")
+)
+
+// DirtyError reports a migration which failed halfway, leaving the database
+// in a state only a person can tell.
+type DirtyError struct {
+	Version uint64
+}
+
+func (e *DirtyError) Error() string {
+	return fmt.Sprintf("database is dirty at version %d, fix the database by hand then run db:migrate force <version>", e.Version)
+}
+
+// Conn is the single connection a runner holds its advisory lock on.
+type Conn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// Connect opens the connection of a runner.
+func Connect(ctx context.Context, connString string) (*pgx.Conn, error) {
+	return pgx.Connect(ctx, connString)
+}
+
+// Runner applies the migrations of a directory to a Postgres database. The
+// applied version is recorded in the migration table the same way
+// golang-migrate does: a single row holding the version and whether its
+// migration failed halfway, the database being dirty.
+type Runner struct {
+	conn       Conn
+	table      string
+	migrations []*Migration
+}
+
+func NewRunner(conn Conn, migrations []*Migration) *Runner {
+	return &Runner{conn: conn, table: filter.DefaultMigrationTable, migrations: migrations}
+}
+
+// WithMigrationTable records the versions in the table instead of the
+// default schema_migrations.
+func (r *Runner) WithMigrationTable(table string) *Runner {
+	if table != "" {
+		r.table = table
+	}
+	return r
+}
+
+// Status is the applied version of the database along with the migrations
+// of the directory.
+type Status struct {
+	Version    uint64
+	Dirty      bool
+	Migrations []*MigrationStatus
+}
+
+type MigrationStatus struct {
+	*Migration
+	Applied bool
+}
+
+// Run holds the advisory lock of the migration table while running the
+// function, so two runs against the same database never interleave. The
+// migration table is created on the first run.
+func (r *Runner) Run(ctx context.Context, run func(ctx context.Context) error) error {
+	_, err := r.conn.Exec(ctx, "SELECT pg_advisory_lock(hashtext($1))", r.table)
+	if err != nil {
+		return err
+	}
+
+	err = r.ensureTable(ctx)
+	if err == nil {
+		err = run(ctx)
+	}
+
+	// the lock is released with the session anyway, failing to release it
+	// only matters when the run succeeded
+	_, unlockErr := r.conn.Exec(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", r.table)
+	if err != nil {
+		return err
+	}
+	return unlockErr
+}
+
+func (r *Runner) ensureTable(ctx context.Context) error {
+	_, err := r.conn.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`, r.quotedTable()))
+	return err
+}
+
+// Status reads the applied version of the database.
+func (r *Runner) Status(ctx context.Context) (*Status, error) {
+	version, dirty, err := r.version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Version: version, Dirty: dirty, Migrations: make([]*MigrationStatus, 0, len(r.migrations))}
+	for _, migration := range r.migrations {
+		status.Migrations = append(status.Migrations, &MigrationStatus{
+			Migration: migration,
+			Applied:   migration.Version <= version,
+		})
+	}
+	return status, nil
+}
+
+// WriteStatus writes the applied version, then every migration of the
+// directory and whether it is applied.
+func WriteStatus(w io.Writer, status *Status) error {
+	lines := make([]string, 0, len(status.Migrations)+2)
+	switch {
+	case status.Version == NoVersion:
+		lines = append(lines, "Version: none")
+	case status.Dirty:
+		lines = append(lines, color.RedString("Version: %d (dirty)", status.Version))
+	default:
+		lines = append(lines, fmt.Sprintf("Version: %d", status.Version))
+	}
+
+	if len(status.Migrations) == 0 {
+		lines = append(lines, color.YellowString("No migrations found."))
+	}
+	for _, migration := range status.Migrations {
+		if migration.Applied {
+			lines = append(lines, color.GreenString("applied  %s", migration.Name))
+		} else {
+			lines = append(lines, color.YellowString("pending  %s", migration.Name))
+		}
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// Up applies every pending migration and returns them.
+func (r *Runner) Up(ctx context.Context) ([]*Migration, error) {
+	if len(r.migrations) == 0 {
+		return make([]*Migration, 0), nil
+	}
+	return r.To(ctx, r.migrations[len(r.migrations)-1].Version)
+}
+
+// Down rolls back the last applied migration and returns it, nothing when no
+// migration is applied.
+func (r *Runner) Down(ctx context.Context) ([]*Migration, error) {
+	version, dirty, err := r.version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, &DirtyError{Version: version}
+	}
+	if version == NoVersion {
+		return make([]*Migration, 0), nil
+	}
+
+	i := r.index(version)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %d is applied but has no migration file", ErrUnknownVersion, version)
+	}
+	if i == 0 {
+		return r.To(ctx, NoVersion)
+	}
+	return r.To(ctx, r.migrations[i-1].Version)
+}
+
+// To applies the pending migrations up to the version, or rolls back the
+// applied migrations after it, and returns them in the order they ran.
+// Version 0 rolls back every migration.
+func (r *Runner) To(ctx context.Context, target uint64) ([]*Migration, error) {
+	if target != NoVersion && r.index(target) < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+
+	version, dirty, err := r.version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, &DirtyError{Version: version}
+	}
+
+	ran := make([]*Migration, 0)
+	if target >= version {
+		for _, migration := range r.migrations {
+			if migration.Version <= version || migration.Version > target {
+				continue
+			}
+			if err := r.apply(ctx, migration.Up, migration.Version); err != nil {
+				return ran, fmt.Errorf("%s: %w", migration.Name, err)
+			}
+			ran = append(ran, migration)
+		}
+		return ran, nil
+	}
+
+	if version != NoVersion && r.index(version) < 0 {
+		return nil, fmt.Errorf("%w: %d is applied but has no migration file", ErrUnknownVersion, version)
+	}
+	for i := len(r.migrations) - 1; i >= 0; i-- {
+		migration := r.migrations[i]
+		if migration.Version > version || migration.Version <= target {
+			continue
+		}
+		if migration.Down == "" {
+			return ran, fmt.Errorf("%w: %s", ErrNoDownFile, migration.Name)
+		}
+
+		previous := NoVersion
+		if i > 0 {
+			previous = r.migrations[i-1].Version
+		}
+		if err := r.apply(ctx, migration.Down, previous); err != nil {
+			return ran, fmt.Errorf("%s: %w", migration.Name, err)
+		}
+		ran = append(ran, migration)
+	}
+	return ran, nil
+}
+
+// Force records the version as applied and clean without running anything,
+// recovering from a dirty database once it was fixed by hand. Version 0
+// records that no migration is applied.
+func (r *Runner) Force(ctx context.Context, version uint64) error {
+	if version != NoVersion && r.index(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.setVersion(ctx, tx, version, false); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// apply runs the migration file and records the version it leads to. A file
+// which can run in a transaction runs in the one recording the version, so a
+// failure leaves the database as it was. Otherwise the version is recorded
+// dirty before running the file and clean after it succeeds.
+func (r *Runner) apply(ctx context.Context, file string, version uint64) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	sql := string(b)
+
+	if ownsTransaction(sql) {
+		if err := r.recordVersion(ctx, version, true); err != nil {
+			return err
+		}
+		if _, err := r.conn.Exec(ctx, sql); err != nil {
+			return err
+		}
+		return r.recordVersion(ctx, version, false)
+	}
+
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if err := r.setVersion(ctx, tx, version, false); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Runner) recordVersion(ctx context.Context, version uint64, dirty bool) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.setVersion(ctx, tx, version, dirty); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Runner) setVersion(ctx context.Context, tx pgx.Tx, version uint64, dirty bool) error {
+	// no row means no migration is applied, rolling back the first migration
+	// still records the database dirty when it fails
+	_, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s", r.quotedTable()))
+	if err != nil || (version == NoVersion && !dirty) {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s (version, dirty) VALUES ($1, $2)", r.quotedTable()), int64(version), dirty)
+	return err
+}
+
+func (r *Runner) version(ctx context.Context) (uint64, bool, error) {
+	// an empty table means no migration is applied
+	var version int64
+	var dirty bool
+	query := fmt.Sprintf("SELECT COALESCE(MAX(version), 0), COALESCE(BOOL_OR(dirty), false) FROM %s", r.quotedTable())
+	err := r.conn.QueryRow(ctx, query).Scan(&version, &dirty)
+	if err != nil {
+		return NoVersion, false, err
+	}
+	return uint64(version), dirty, nil
+}
+
+func (r *Runner) index(version uint64) int {
+	for i, migration := range r.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *Runner) quotedTable() string {
+	return pgx.Identifier{r.table}.Sanitize()
+}
@@ -0,0 +1,256 @@
+package migrate_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/migrate"
+)
+
+const (
+	usersUp = `BEGIN;
+CREATE TABLE IF NOT EXISTS "users" ("id" BIGSERIAL PRIMARY KEY);
+COMMIT;`
+	usersDown      = `DROP TABLE IF EXISTS "users";`
+	emailUp        = `ALTER TABLE "users" ADD COLUMN "email" TEXT;`
+	emailDown      = `ALTER TABLE "users" DROP COLUMN "email";`
+	emailIndexUp   = `CREATE INDEX CONCURRENTLY IF NOT EXISTS "index_users_on_email" ON "users"("email" ASC);`
+	emailIndexDown = `DROP INDEX CONCURRENTLY IF EXISTS "index_users_on_email";`
+)
+
+func loadMigrations(t *testing.T) []*migrate.Migration {
+	migrations, err := migrate.Load(writeMigrations(t, map[string]string{
+		"1_users.up.sql":         usersUp,
+		"1_users.down.sql":       usersDown,
+		"2_email.up.sql":         emailUp,
+		"2_email.down.sql":       emailDown,
+		"3_email_index.up.sql":   emailIndexUp,
+		"3_email_index.down.sql": emailIndexDown,
+	}))
+	assert.Nil(t, err)
+	return migrations
+}
+
+func expectVersion(mock pgxmock.PgxConnIface, version int64, dirty bool) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(version), 0), COALESCE(BOOL_OR(dirty), false) FROM "schema_migrations"`)).
+		WillReturnRows(pgxmock.NewRows([]string{"version", "dirty"}).AddRow(version, dirty))
+}
+
+func expectSetVersion(mock pgxmock.PgxConnIface, version int64, dirty bool) {
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "schema_migrations"`)).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	if version > 0 || dirty {
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "schema_migrations" (version, dirty) VALUES ($1, $2)`)).
+			WithArgs(version, dirty).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
+}
+
+// expectTransactional expects the file to run in the transaction recording
+// its version.
+func expectTransactional(mock pgxmock.PgxConnIface, sql string, version int64) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(sql)).WillReturnResult(pgxmock.NewResult("ALTER", 0))
+	expectSetVersion(mock, version, false)
+	mock.ExpectCommit()
+}
+
+// expectOwnTransaction expects the file to run on its own, between its
+// version being recorded dirty and clean.
+func expectOwnTransaction(mock pgxmock.PgxConnIface, sql string, version int64) {
+	mock.ExpectBegin()
+	expectSetVersion(mock, version, true)
+	mock.ExpectCommit()
+	mock.ExpectExec(regexp.QuoteMeta(sql)).WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectBegin()
+	expectSetVersion(mock, version, false)
+	mock.ExpectCommit()
+}
+
+func TestRunner_Run(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock(hashtext($1))")).
+		WithArgs("deployments").
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS "deployments" (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`)).
+		WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock(hashtext($1))")).
+		WithArgs("deployments").
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+	runErr := errors.New("run failed")
+	err = migrate.NewRunner(mock, nil).WithMigrationTable("deployments").Run(context.Background(), func(ctx context.Context) error {
+		return runErr
+	})
+	assert.Equal(t, runErr, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestRunner_Up(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	expectVersion(mock, 0, false)
+	expectOwnTransaction(mock, usersUp, 1)
+	expectTransactional(mock, emailUp, 2)
+	expectOwnTransaction(mock, emailIndexUp, 3)
+
+	migrations := loadMigrations(t)
+	ran, err := migrate.NewRunner(mock, migrations).Up(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, migrations, ran)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestRunner_UpFailure(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	expectVersion(mock, 2, false)
+	mock.ExpectBegin()
+	expectSetVersion(mock, 3, true)
+	mock.ExpectCommit()
+	mock.ExpectExec(regexp.QuoteMeta(emailIndexUp)).WillReturnError(errors.New("deadlock detected"))
+
+	ran, err := migrate.NewRunner(mock, loadMigrations(t)).Up(context.Background())
+	assert.EqualError(t, err, "3_email_index: deadlock detected")
+	assert.Empty(t, ran)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestRunner_Dirty(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	expectVersion(mock, 3, true)
+
+	_, err = migrate.NewRunner(mock, loadMigrations(t)).Up(context.Background())
+	var dirtyErr *migrate.DirtyError
+	assert.True(t, errors.As(err, &dirtyErr))
+	assert.Equal(t, uint64(3), dirtyErr.Version)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestRunner_Down(t *testing.T) {
+	testCases := map[string]struct {
+		version int64
+		expect  func(mock pgxmock.PgxConnIface)
+		ran     []int
+	}{
+		"last migration": {
+			version: 2,
+			expect: func(mock pgxmock.PgxConnIface) {
+				expectTransactional(mock, emailDown, 1)
+			},
+			ran: []int{1},
+		},
+		"first migration": {
+			version: 1,
+			expect: func(mock pgxmock.PgxConnIface) {
+				expectTransactional(mock, usersDown, 0)
+			},
+			ran: []int{0},
+		},
+		"nothing applied": {
+			version: 0,
+			expect:  func(mock pgxmock.PgxConnIface) {},
+			ran:     []int{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			mock, err := pgxmock.NewConn()
+			assert.Nil(t, err)
+			defer mock.Close(context.Background())
+
+			expectVersion(mock, tc.version, false)
+			if tc.version > 0 {
+				expectVersion(mock, tc.version, false)
+			}
+			tc.expect(mock)
+
+			migrations := loadMigrations(t)
+			ran, err := migrate.NewRunner(mock, migrations).Down(context.Background())
+			assert.Nil(t, err)
+
+			expected := make([]*migrate.Migration, 0)
+			for _, i := range tc.ran {
+				expected = append(expected, migrations[i])
+			}
+			assert.Equal(t, expected, ran)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRunner_To(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	expectVersion(mock, 3, false)
+	expectOwnTransaction(mock, emailIndexDown, 2)
+	expectTransactional(mock, emailDown, 1)
+
+	migrations := loadMigrations(t)
+	ran, err := migrate.NewRunner(mock, migrations).To(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []*migrate.Migration{migrations[2], migrations[1]}, ran)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	_, err = migrate.NewRunner(mock, migrations).To(context.Background(), 4)
+	assert.True(t, errors.Is(err, migrate.ErrUnknownVersion), err)
+}
+
+func TestRunner_Force(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	mock.ExpectBegin()
+	expectSetVersion(mock, 2, false)
+	mock.ExpectCommit()
+
+	runner := migrate.NewRunner(mock, loadMigrations(t))
+	assert.Nil(t, runner.Force(context.Background(), 2))
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	err = runner.Force(context.Background(), 4)
+	assert.True(t, errors.Is(err, migrate.ErrUnknownVersion), err)
+}
+
+func TestRunner_Status(t *testing.T) {
+	color.NoColor = true
+
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	expectVersion(mock, 2, true)
+
+	status, err := migrate.NewRunner(mock, loadMigrations(t)).Status(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	var out bytes.Buffer
+	assert.Nil(t, migrate.WriteStatus(&out, status))
+	assert.Equal(t, `Version: 2 (dirty)
+applied  1_users
+applied  2_email
+pending  3_email_index
+`, out.String())
+	assert.Equal(t, filepath.Base(status.Migrations[2].Up), "3_email_index.up.sql")
+}
@@ -0,0 +1,290 @@
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// SumFile is written into the migration directory, recording the hash of
+	// every migration file.
+	SumFile = "dbgen.sum"
+	// AtlasSumFile is the sum file of the atlas migration directories.
+	AtlasSumFile = "atlas.sum"
+
+	hashPrefix = "h1:"
+)
+
+var (
+	ErrSumNotExists = errors.New("migration directory has migrations but no " + SumFile)
+	ErrSumCorrupted = errors.New(SumFile + " was edited by hand")
+)
+
+// Sum is the hash of every migration file of a directory, in the order they
+// are applied, along with a hash combining them so the sum file itself
+// cannot be edited unnoticed.
+type Sum struct {
+	Hash  string
+	Files []*FileSum
+}
+
+type FileSum struct {
+	Name string
+	Hash string
+}
+
+// SumError reports the migration files which do not match the sum file.
+// Inserted files are new files applied before recorded ones, which a
+// database already migrated would never apply.
+type SumError struct {
+	Edited   []string
+	Deleted  []string
+	Inserted []string
+	Added    []string
+}
+
+func (e *SumError) Error() string {
+	problems := make([]string, 0, 4)
+	add := func(problem string, files []string) {
+		if len(files) > 0 {
+			problems = append(problems, fmt.Sprintf("%s %s", problem, strings.Join(files, ", ")))
+		}
+	}
+	add("edited", e.Edited)
+	add("deleted", e.Deleted)
+	add("inserted before recorded migrations", e.Inserted)
+	add("not recorded", e.Added)
+	return fmt.Sprintf("migration directory does not match %s: %s", SumFile, strings.Join(problems, "; "))
+}
+
+// HashDir hashes the .sql files of the directory, along with the .sql.conf
+// files flyway configures them with.
+func HashDir(dir string) (*Sum, error) {
+	sqlFiles, err := readFiles(dir, ".sql", ".sql.conf")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*FileSum, 0, len(sqlFiles))
+	for _, file := range sqlFiles {
+		files = append(files, &FileSum{Name: file.name, Hash: hash(file.content)})
+	}
+	return newSum(files), nil
+}
+
+// WriteAtlasSum writes the atlas.sum file atlas checks the directory
+// against. Unlike the sum file, the hash of a file covers the files before
+// it, and the combined hash covers the names and hashes of every file.
+func WriteAtlasSum(dir string) error {
+	sqlFiles, err := readFiles(dir, ".sql")
+	if err != nil {
+		return err
+	}
+
+	var lines bytes.Buffer
+	h, combined := sha256.New(), sha256.New()
+	for _, file := range sqlFiles {
+		h.Write([]byte(file.name))
+		h.Write(file.content)
+		fileHash := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+		combined.Write([]byte(file.name))
+		combined.Write([]byte(fileHash))
+		fmt.Fprintf(&lines, "%s %s%s\n", file.name, hashPrefix, fileHash)
+	}
+
+	content := append([]byte(hashPrefix+base64.StdEncoding.EncodeToString(combined.Sum(nil))+"\n"), lines.Bytes()...)
+	return os.WriteFile(filepath.Join(dir, AtlasSumFile), content, 0644)
+}
+
+type sqlFile struct {
+	name    string
+	content []byte
+}
+
+// readFiles reads the files of the directory ending with one of the suffixes
+// sorted by name, which is the order of their version prefixes.
+func readFiles(dir string, suffixes ...string) ([]*sqlFile, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make([]*sqlFile, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*sqlFile, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !hasSuffix(entry.Name(), suffixes) {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &sqlFile{name: entry.Name(), content: b})
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].name < files[j].name
+	})
+	return files, nil
+}
+
+func hasSuffix(name string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newSum(files []*FileSum) *Sum {
+	return &Sum{Hash: hash(sumLines(files)), Files: files}
+}
+
+// WriteSum hashes the directory and writes its sum file.
+func WriteSum(dir string) error {
+	sum, err := HashDir(dir)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, SumFile), sum.Bytes(), 0644)
+}
+
+// ReadSum reads the sum file of the directory, nil when there is none.
+func ReadSum(dir string) (*Sum, error) {
+	b, err := os.ReadFile(filepath.Join(dir, SumFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseSum(b)
+}
+
+// ParseSum parses a sum file, refusing the ones whose combined hash does not
+// match their files.
+func ParseSum(b []byte) (*Sum, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	if !scanner.Scan() {
+		return nil, ErrSumCorrupted
+	}
+	combined := scanner.Text()
+
+	files := make([]*FileSum, 0)
+	for scanner.Scan() {
+		name, fileHash, found := strings.Cut(scanner.Text(), " ")
+		if !found || !strings.HasPrefix(fileHash, hashPrefix) {
+			return nil, ErrSumCorrupted
+		}
+		files = append(files, &FileSum{Name: name, Hash: fileHash})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sum := newSum(files)
+	if sum.Hash != combined {
+		return nil, ErrSumCorrupted
+	}
+	return sum, nil
+}
+
+// Bytes renders the sum file: the combined hash, then a line per file.
+func (s *Sum) Bytes() []byte {
+	return append([]byte(s.Hash+"\n"), sumLines(s.Files)...)
+}
+
+// Verify checks the migration files of the directory against its sum file.
+// A directory without migrations needs no sum file.
+func Verify(dir string) error {
+	actual, err := HashDir(dir)
+	if err != nil {
+		return err
+	}
+
+	recorded, err := ReadSum(dir)
+	if err != nil {
+		return err
+	}
+	if recorded == nil {
+		if len(actual.Files) == 0 {
+			return nil
+		}
+		return ErrSumNotExists
+	}
+	return Compare(recorded, actual)
+}
+
+// Compare returns a SumError when the actual sum differs from the recorded
+// one.
+func Compare(recorded, actual *Sum) error {
+	if recorded.Hash == actual.Hash {
+		return nil
+	}
+
+	hashes := make(map[string]string, len(actual.Files))
+	for _, file := range actual.Files {
+		hashes[file.Name] = file.Hash
+	}
+
+	sumErr := &SumError{}
+	lastRecorded := ""
+	recordedNames := make(map[string]bool, len(recorded.Files))
+	for _, file := range recorded.Files {
+		recordedNames[file.Name] = true
+		if file.Name > lastRecorded {
+			lastRecorded = file.Name
+		}
+
+		fileHash, ok := hashes[file.Name]
+		switch {
+		case !ok:
+			sumErr.Deleted = append(sumErr.Deleted, file.Name)
+		case fileHash != file.Hash:
+			sumErr.Edited = append(sumErr.Edited, file.Name)
+		}
+	}
+
+	for _, file := range actual.Files {
+		switch {
+		case recordedNames[file.Name]:
+		case file.Name < lastRecorded:
+			sumErr.Inserted = append(sumErr.Inserted, file.Name)
+		default:
+			sumErr.Added = append(sumErr.Added, file.Name)
+		}
+	}
+	return sumErr
+}
+
+func sumLines(files []*FileSum) []byte {
+	var buf bytes.Buffer
+	for _, file := range files {
+		fmt.Fprintf(&buf, "%s %s\n", file.Name, file.Hash)
+	}
+	return buf.Bytes()
+}
+
+func hash(b []byte) string {
+	h := sha256.Sum256(b)
+	return hashPrefix + base64.StdEncoding.EncodeToString(h[:])
+}
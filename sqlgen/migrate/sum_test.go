@@ -0,0 +1,114 @@
+package migrate_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/migrate"
+)
+
+func TestWriteSum(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"2_email.up.sql":        `ALTER TABLE "users" ADD COLUMN "email" TEXT;`,
+		"1_users.up.sql":        `CREATE TABLE "users" ("id" BIGSERIAL PRIMARY KEY);`,
+		"1_users.down.sql":      `DROP TABLE "users";`,
+		"1_users.snapshot.json": `[]`,
+	})
+	assert.Nil(t, migrate.WriteSum(dir))
+
+	sum, err := migrate.ReadSum(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []*migrate.FileSum{
+		{Name: "1_users.down.sql", Hash: "h1:PhbvYIpnaWN9zS04rcN/PNj762r9+UEsI3ApBpr2qu8="},
+		{Name: "1_users.up.sql", Hash: "h1:jHbt6RgzsxJ9m6wmZ3aRtX1eyujN/Xk1rX8stlI8M3g="},
+		{Name: "2_email.up.sql", Hash: "h1:DBquoVo5cxV7qV+rpYsg8BYwf3156YOe2QEnS4dnD8o="},
+	}, sum.Files)
+	assert.Nil(t, migrate.Verify(dir))
+}
+
+func TestVerify(t *testing.T) {
+	testCases := map[string]struct {
+		change func(dir string) error
+		err    *migrate.SumError
+	}{
+		"edited": {
+			change: func(dir string) error {
+				return os.WriteFile(filepath.Join(dir, "1_users.up.sql"), []byte(`CREATE TABLE "users" ("id" BIGINT);`), 0644)
+			},
+			err: &migrate.SumError{Edited: []string{"1_users.up.sql"}},
+		},
+		"deleted": {
+			change: func(dir string) error {
+				return os.Remove(filepath.Join(dir, "1_users.down.sql"))
+			},
+			err: &migrate.SumError{Deleted: []string{"1_users.down.sql"}},
+		},
+		"reordered": {
+			change: func(dir string) error {
+				return os.Rename(filepath.Join(dir, "3_logs.up.sql"), filepath.Join(dir, "0_logs.up.sql"))
+			},
+			err: &migrate.SumError{Deleted: []string{"3_logs.up.sql"}, Inserted: []string{"0_logs.up.sql"}},
+		},
+		"added": {
+			change: func(dir string) error {
+				return os.WriteFile(filepath.Join(dir, "4_audit.up.sql"), []byte(`CREATE TABLE "audit" ("id" BIGINT);`), 0644)
+			},
+			err: &migrate.SumError{Added: []string{"4_audit.up.sql"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dir := writeMigrations(t, map[string]string{
+				"1_users.up.sql":   `CREATE TABLE "users" ("id" BIGSERIAL PRIMARY KEY);`,
+				"1_users.down.sql": `DROP TABLE "users";`,
+				"3_logs.up.sql":    `CREATE TABLE "logs" ("id" BIGINT);`,
+			})
+			assert.Nil(t, migrate.WriteSum(dir))
+			assert.Nil(t, tc.change(dir))
+
+			err := migrate.Verify(dir)
+			assert.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestVerify_SumFile(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"1_users.up.sql": `CREATE TABLE "users" ("id" BIGSERIAL PRIMARY KEY);`,
+	})
+	assert.True(t, errors.Is(migrate.Verify(dir), migrate.ErrSumNotExists))
+	assert.Nil(t, migrate.Verify(t.TempDir()))
+
+	assert.Nil(t, migrate.WriteSum(dir))
+	sum, err := os.ReadFile(filepath.Join(dir, migrate.SumFile))
+	assert.Nil(t, err)
+
+	tampered := append(sum, []byte("2_email.up.sql h1:DBquoVo5cxV7qV+rpYsg8BYwf3156YOe2QEnS4dnD8o=\n")...)
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, migrate.SumFile), tampered, 0644))
+	assert.True(t, errors.Is(migrate.Verify(dir), migrate.ErrSumCorrupted))
+}
+
+func TestSumError_Error(t *testing.T) {
+	err := &migrate.SumError{Edited: []string{"1_users.up.sql"}, Deleted: []string{"2_email.up.sql", "2_email.down.sql"}}
+	assert.EqualError(t, err, "migration directory does not match dbgen.sum: edited 1_users.up.sql; deleted 2_email.up.sql, 2_email.down.sql")
+}
+
+func TestWriteAtlasSum(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"2_email.sql":   `ALTER TABLE "users" ADD COLUMN "email" TEXT;`,
+		"1_users.sql":   `CREATE TABLE "users" ("id" BIGSERIAL PRIMARY KEY);`,
+		migrate.SumFile: "",
+	})
+	assert.Nil(t, migrate.WriteAtlasSum(dir))
+
+	sum, err := os.ReadFile(filepath.Join(dir, migrate.AtlasSumFile))
+	assert.Nil(t, err)
+	assert.Equal(t, `h1:zV4m0ICsJNU0ojKUPGqFyTTnpAlsiv+yAF73fekypdk=
+1_users.sql h1:1AjYBgKPGLeGBJ0OiPusra5HPNjY2bDyvZZBcYOcwZE=
+2_email.sql h1:pr0zbmur+VggVVPSFGFxqA2oNg94mLEFO/h22q+Csi4=
+`, string(sum))
+}
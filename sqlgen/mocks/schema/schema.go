@@ -5,6 +5,7 @@
 package mock_schema
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,77 +36,121 @@ func (m *MockSchema) EXPECT() *MockSchemaMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockSchema) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockSchemaMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockSchema)(nil).Close))
+}
+
 // GetFields mocks base method.
-func (m *MockSchema) GetFields(arg0 string) ([]*config.Field, error) {
+func (m *MockSchema) GetFields(arg0 context.Context, arg1 string) ([]*config.Field, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFields", arg0)
+	ret := m.ctrl.Call(m, "GetFields", arg0, arg1)
 	ret0, _ := ret[0].([]*config.Field)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFields indicates an expected call of GetFields.
-func (mr *MockSchemaMockRecorder) GetFields(arg0 interface{}) *gomock.Call {
+func (mr *MockSchemaMockRecorder) GetFields(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFields", reflect.TypeOf((*MockSchema)(nil).GetFields), arg0, arg1)
+}
+
+// GetGrants mocks base method.
+func (m *MockSchema) GetGrants(arg0 context.Context) (map[string][]*config.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrants", arg0)
+	ret0, _ := ret[0].(map[string][]*config.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrants indicates an expected call of GetGrants.
+func (mr *MockSchemaMockRecorder) GetGrants(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFields", reflect.TypeOf((*MockSchema)(nil).GetFields), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrants", reflect.TypeOf((*MockSchema)(nil).GetGrants), arg0)
 }
 
 // GetIndices mocks base method.
-func (m *MockSchema) GetIndices() (map[string]*schema.Indices, error) {
+func (m *MockSchema) GetIndices(arg0 context.Context) (map[string]*schema.Indices, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetIndices")
+	ret := m.ctrl.Call(m, "GetIndices", arg0)
 	ret0, _ := ret[0].(map[string]*schema.Indices)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetIndices indicates an expected call of GetIndices.
-func (mr *MockSchemaMockRecorder) GetIndices() *gomock.Call {
+func (mr *MockSchemaMockRecorder) GetIndices(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIndices", reflect.TypeOf((*MockSchema)(nil).GetIndices))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIndices", reflect.TypeOf((*MockSchema)(nil).GetIndices), arg0)
 }
 
 // GetPrimaryKeys mocks base method.
-func (m *MockSchema) GetPrimaryKeys() (map[string]*schema.PrimaryKey, error) {
+func (m *MockSchema) GetPrimaryKeys(arg0 context.Context) (map[string]*schema.PrimaryKey, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPrimaryKeys")
+	ret := m.ctrl.Call(m, "GetPrimaryKeys", arg0)
 	ret0, _ := ret[0].(map[string]*schema.PrimaryKey)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPrimaryKeys indicates an expected call of GetPrimaryKeys.
-func (mr *MockSchemaMockRecorder) GetPrimaryKeys() *gomock.Call {
+func (mr *MockSchemaMockRecorder) GetPrimaryKeys(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrimaryKeys", reflect.TypeOf((*MockSchema)(nil).GetPrimaryKeys))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrimaryKeys", reflect.TypeOf((*MockSchema)(nil).GetPrimaryKeys), arg0)
 }
 
 // GetSchemas mocks base method.
-func (m *MockSchema) GetSchemas() ([]*config.Schema, error) {
+func (m *MockSchema) GetSchemas(arg0 context.Context) ([]*config.Schema, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSchemas")
+	ret := m.ctrl.Call(m, "GetSchemas", arg0)
 	ret0, _ := ret[0].([]*config.Schema)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSchemas indicates an expected call of GetSchemas.
-func (mr *MockSchemaMockRecorder) GetSchemas() *gomock.Call {
+func (mr *MockSchemaMockRecorder) GetSchemas(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchemas", reflect.TypeOf((*MockSchema)(nil).GetSchemas), arg0)
+}
+
+// GetStorages mocks base method.
+func (m *MockSchema) GetStorages(arg0 context.Context) (map[string]*config.Storage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStorages", arg0)
+	ret0, _ := ret[0].(map[string]*config.Storage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStorages indicates an expected call of GetStorages.
+func (mr *MockSchemaMockRecorder) GetStorages(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchemas", reflect.TypeOf((*MockSchema)(nil).GetSchemas))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStorages", reflect.TypeOf((*MockSchema)(nil).GetStorages), arg0)
 }
 
 // GetTables mocks base method.
-func (m *MockSchema) GetTables() ([]string, error) {
+func (m *MockSchema) GetTables(arg0 context.Context) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTables")
+	ret := m.ctrl.Call(m, "GetTables", arg0)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTables indicates an expected call of GetTables.
-func (mr *MockSchemaMockRecorder) GetTables() *gomock.Call {
+func (mr *MockSchemaMockRecorder) GetTables(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTables", reflect.TypeOf((*MockSchema)(nil).GetTables))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTables", reflect.TypeOf((*MockSchema)(nil).GetTables), arg0)
 }
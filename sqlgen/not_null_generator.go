@@ -0,0 +1,115 @@
+package sqlgen
+
+import (
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dialect"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/exp"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/sb"
+)
+
+// NotNullGenerator sets a column NOT NULL without scanning the table under an
+// exclusive lock: a CHECK constraint is added NOT VALID, validated while
+// writes go on, then SET NOT NULL relies on it and the constraint is dropped.
+type NotNullGenerator interface {
+	Dialect() string
+	DialectOptions() *dialect.DialectOption
+	ExpressionSQLGenerator() exp.ExpressionSQLGenerator
+	AddCheck(b sb.SQLBuilder, tblName, column string)
+	ValidateCheck(b sb.SQLBuilder, tblName, column string)
+	DropCheck(b sb.SQLBuilder, tblName, column string)
+	SetNotNull(b sb.SQLBuilder, tblName, column string)
+	DropNotNull(b sb.SQLBuilder, tblName, column string)
+}
+
+type notNullGenerator struct {
+	dialect        string
+	esg            exp.ExpressionSQLGenerator
+	dialectOptions *dialect.DialectOption
+}
+
+func NewNotNullGenerator(dialect string, do *dialect.DialectOption) NotNullGenerator {
+	return &notNullGenerator{
+		dialect:        dialect,
+		dialectOptions: do,
+		esg:            exp.NewExpressionSQLGenerator(dialect, do),
+	}
+}
+
+func (nng *notNullGenerator) Dialect() string {
+	return nng.dialect
+}
+
+func (nng *notNullGenerator) DialectOptions() *dialect.DialectOption {
+	return nng.dialectOptions
+}
+
+func (nng *notNullGenerator) ExpressionSQLGenerator() exp.ExpressionSQLGenerator {
+	return nng.esg
+}
+
+func (nng *notNullGenerator) AddCheck(b sb.SQLBuilder, tblName, column string) {
+	nng.alterTableTemplate(b, tblName)
+	b.Write(nng.dialectOptions.AddFragment)
+	b.Write(nng.dialectOptions.ConstraintFragment)
+	nng.ExpressionSQLGenerator().LiteralExpression(b, nng.checkName(tblName, column))
+	b.Write(nng.dialectOptions.CheckFragment)
+	b.WriteRunes(nng.dialectOptions.LeftParenRune)
+	nng.ExpressionSQLGenerator().LiteralExpression(b, column)
+	b.Write(nng.dialectOptions.IsNotNullFragment)
+	b.WriteRunes(nng.dialectOptions.RightParenRune)
+	b.Write(nng.dialectOptions.NotValidFragment)
+	b.WriteRunes(nng.dialectOptions.SemiColonRune)
+}
+
+func (nng *notNullGenerator) ValidateCheck(b sb.SQLBuilder, tblName, column string) {
+	nng.alterTableTemplate(b, tblName)
+	b.Write(nng.dialectOptions.ValidateFragment)
+	b.Write(nng.dialectOptions.ConstraintFragment)
+	nng.ExpressionSQLGenerator().LiteralExpression(b, nng.checkName(tblName, column))
+	b.WriteRunes(nng.dialectOptions.SemiColonRune)
+}
+
+func (nng *notNullGenerator) DropCheck(b sb.SQLBuilder, tblName, column string) {
+	nng.alterTableTemplate(b, tblName)
+	b.Write(nng.dialectOptions.DropFragment)
+	b.Write(nng.dialectOptions.ConstraintFragment)
+	if nng.dialectOptions.SupportIfExistsOnAlter {
+		b.Write(nng.dialectOptions.IfExistsFragment)
+	}
+	nng.ExpressionSQLGenerator().LiteralExpression(b, nng.checkName(tblName, column))
+	b.WriteRunes(nng.dialectOptions.SemiColonRune)
+}
+
+func (nng *notNullGenerator) SetNotNull(b sb.SQLBuilder, tblName, column string) {
+	nng.alterColumn(b, tblName, column)
+	b.Write(nng.dialectOptions.SetFragment)
+	b.Write(nng.dialectOptions.NotNullFragment)
+	b.WriteRunes(nng.dialectOptions.SemiColonRune)
+}
+
+func (nng *notNullGenerator) DropNotNull(b sb.SQLBuilder, tblName, column string) {
+	nng.alterColumn(b, tblName, column)
+	b.Write(nng.dialectOptions.DropFragment)
+	b.Write(nng.dialectOptions.NotNullFragment)
+	b.WriteRunes(nng.dialectOptions.SemiColonRune)
+}
+
+func (nng *notNullGenerator) checkName(tblName, column string) string {
+	return tblName + "_" + column + nng.dialectOptions.NotNullCheckSuffix
+}
+
+func (nng *notNullGenerator) alterColumn(b sb.SQLBuilder, tblName, column string) {
+	nng.alterTableTemplate(b, tblName)
+	b.Write(nng.dialectOptions.AlterColumnTemplate())
+	nng.ExpressionSQLGenerator().LiteralExpression(b, column)
+	b.WriteRunes(nng.dialectOptions.SpaceRune)
+}
+
+func (nng *notNullGenerator) alterTableTemplate(b sb.SQLBuilder, name string) {
+	b.Write(nng.dialectOptions.AlterClause)
+	b.Write(nng.dialectOptions.TableFragment)
+	if nng.dialectOptions.SupportIfExistsOnAlter {
+		b.Write(nng.dialectOptions.IfExistsFragment)
+	}
+	nng.ExpressionSQLGenerator().LiteralExpression(b, name)
+	b.WriteRunes(nng.dialectOptions.SpaceRune)
+}
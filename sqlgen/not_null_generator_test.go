@@ -0,0 +1,71 @@
+package sqlgen_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dialect"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/sb"
+)
+
+func TestNotNullGenerator_Dialect(t *testing.T) {
+	dial := "postgres"
+	do := dialect.DefaultDialectOption()
+
+	sqlGen := sqlgen.NewNotNullGenerator(dial, do)
+	assert.Equal(t, dial, sqlGen.Dialect())
+}
+
+func TestNotNullGenerator_DialectOptions(t *testing.T) {
+	dial := "postgres"
+	do := dialect.DefaultDialectOption()
+
+	sqlGen := sqlgen.NewNotNullGenerator(dial, do)
+	assert.Equal(t, do, sqlGen.DialectOptions())
+}
+
+func TestNotNullGenerator_ExpressionSQLGenerator(t *testing.T) {
+	dial := "postgres"
+	do := dialect.DefaultDialectOption()
+
+	sqlGen := sqlgen.NewNotNullGenerator(dial, do)
+	assert.NotNil(t, sqlGen.ExpressionSQLGenerator())
+}
+
+func TestNotNullGenerator_Generate(t *testing.T) {
+	sqlGen := sqlgen.NewNotNullGenerator("postgres", dialect.DefaultDialectOption())
+	testCases := map[string]struct {
+		generate func(sb.SQLBuilder, string, string)
+		result   string
+	}{
+		"add check": {
+			generate: sqlGen.AddCheck,
+			result:   `ALTER TABLE IF EXISTS "users" ADD CONSTRAINT "users_name_not_null" CHECK ("name" IS NOT NULL) NOT VALID;`,
+		},
+		"validate check": {
+			generate: sqlGen.ValidateCheck,
+			result:   `ALTER TABLE IF EXISTS "users" VALIDATE CONSTRAINT "users_name_not_null";`,
+		},
+		"drop check": {
+			generate: sqlGen.DropCheck,
+			result:   `ALTER TABLE IF EXISTS "users" DROP CONSTRAINT IF EXISTS "users_name_not_null";`,
+		},
+		"set not null": {
+			generate: sqlGen.SetNotNull,
+			result:   `ALTER TABLE IF EXISTS "users" ALTER COLUMN "name" SET NOT NULL;`,
+		},
+		"drop not null": {
+			generate: sqlGen.DropNotNull,
+			result:   `ALTER TABLE IF EXISTS "users" ALTER COLUMN "name" DROP NOT NULL;`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			buf := sb.NewSQLBuilder()
+			tc.generate(buf, "users", "name")
+			assert.Equal(t, tc.result, buf.String())
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package sqlgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/sb"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
+)
+
+var ErrOnlineRewrite = errors.New("online migration rewrites tables")
+
+// NothingToRollBack is the down migration of a migration which cannot be
+// undone, such as the validation of a constraint.
+var NothingToRollBack = []byte("-- nothing to roll back")
+
+// Migration is one migration of a plan, its Name is appended to the output
+// target and is empty for the main migration.
+type Migration struct {
+	Name string
+	Up   []byte
+	Down []byte
+
+	// Transactional migrations are wrapped in a transaction, the others
+	// hold a single statement which cannot run in one.
+	Transactional bool
+}
+
+// Migrations returns the migrations of the plan, a single one unless the
+// migration is online.
+func (gen *SqlGenerator) Migrations(plan *step.MigrationPlanner) []*Migration {
+	if gen.flag.Online {
+		return gen.OnlineMigrations(plan)
+	}
+
+	migration := &Migration{Up: gen.UpContent(plan), Down: gen.DownContent(plan), Transactional: gen.dialectOption.SupportTransaction}
+	if len(migration.Up) == 0 {
+		return nil
+	}
+	return append([]*Migration{migration}, gen.BackfillMigrations(plan)...)
+}
+
+// BackfillMigrations fill the columns expanded by the plan, one migration per
+// column since a backfill commits its batches and cannot share a transaction.
+func (gen *SqlGenerator) BackfillMigrations(plan *step.MigrationPlanner) []*Migration {
+	migrations := make([]*Migration, 0)
+	for _, as := range plan.AlteredTables() {
+		for _, transition := range as.ExpandedColumns {
+			b := sb.NewSQLBuilder()
+			gen.TransitionGenerator().Backfill(b, as.Name, as.PrimaryKey(), transition, gen.flag.BackfillBatchSize)
+			migrations = append(migrations, &Migration{
+				Name: fmt.Sprintf("backfill_%s_%s", as.Name, transition.To.Name),
+				Up:   b.Bytes(),
+				Down: NothingToRollBack,
+			})
+		}
+	}
+	return migrations
+}
+
+// OnlineMigrations splits the plan into migrations run one after the other,
+// none of them holding an exclusive lock while scanning a table, the plans
+// rewriting one being refused by OnlineGuard:
+//   - expand creates tables and columns, alters column types, defaults and
+//     grants, adds the NOT NULL constraints as NOT VALID checks and starts
+//     the column transitions
+//   - every expanded column is backfilled in its own migration
+//   - every index is created or dropped CONCURRENTLY in its own migration
+//   - validate validates the checks without blocking writes
+//   - contract sets the columns NOT NULL relying on the validated checks,
+//     then finishes the column transitions and drops columns and tables
+//
+// The transactional migrations give up waiting for their locks after the lock
+// timeout instead of queueing the queries behind them.
+func (gen *SqlGenerator) OnlineMigrations(plan *step.MigrationPlanner) []*Migration {
+	expand := make(map[string]*step.AlterSchema)
+	contract := make(map[string]*step.AlterSchema)
+	notNull := make(map[string][]string)
+	for name, as := range plan.AlterSchema {
+		expanded, columns := expandSchema(as)
+		expand[name] = expanded
+		notNull[name] = columns
+
+		contracted := step.NewAlterSchema(as.Name)
+		contracted.DroppedColumns = as.DroppedColumns
+		contracted.ContractedColumns = as.ContractedColumns
+		contract[name] = contracted
+	}
+
+	migrations := make([]*Migration, 0)
+	migrations = append(migrations, &Migration{
+		Name: "",
+		Up: getContents(
+			gen.GenerateCreateTables(plan.CreateTable),
+			gen.AlterTableUp(expand),
+			gen.notNullStatements(plan, notNull, gen.NotNullGenerator().AddCheck),
+		),
+		Down: getContents(
+			gen.notNullStatements(plan, notNull, gen.NotNullGenerator().DropCheck),
+			gen.AlterTableDown(expand),
+			gen.GenerateDropTables(reverseSchemas(plan.CreateTable)),
+		),
+		Transactional: true,
+	})
+	migrations = append(migrations, gen.BackfillMigrations(plan)...)
+
+	for _, as := range plan.AlteredTables() {
+		for _, idx := range as.DroppedIndices {
+			up, down := sb.NewSQLBuilder(), sb.NewSQLBuilder()
+			gen.writeLock(up, step.DropIndexLock(as.Name, idx, true))
+			gen.generators.concurrentDig.Generate(up, as.Name, idx)
+			gen.writeLock(down, step.CreateIndexLock(as.Name, idx, true))
+			gen.generators.concurrentCig.Generate(down, as.Name, idx)
+			migrations = append(migrations, &Migration{Name: "drop_" + idx.Name, Up: up.Bytes(), Down: down.Bytes()})
+		}
+		for _, idx := range as.AddedIndices {
+			up, down := sb.NewSQLBuilder(), sb.NewSQLBuilder()
+			gen.writeLock(up, step.CreateIndexLock(as.Name, idx, true))
+			gen.generators.concurrentCig.Generate(up, as.Name, idx)
+			gen.writeLock(down, step.DropIndexLock(as.Name, idx, true))
+			gen.generators.concurrentDig.Generate(down, as.Name, idx)
+			migrations = append(migrations, &Migration{Name: "create_" + idx.Name, Up: up.Bytes(), Down: down.Bytes()})
+		}
+	}
+
+	migrations = append(migrations, &Migration{
+		Name:          "validate",
+		Up:            gen.notNullStatements(plan, notNull, gen.NotNullGenerator().ValidateCheck),
+		Transactional: true,
+	})
+
+	dropTables, recreateTables := []byte{}, []byte{}
+	if !gen.flag.SkipDropTable {
+		dropTables = gen.GenerateDropTables(plan.DropTable)
+		recreateTables = gen.GenerateCreateTables(reverseSchemas(plan.DropTable))
+	}
+	migrations = append(migrations, &Migration{
+		Name: "contract",
+		Up: getContents(
+			gen.notNullStatements(plan, notNull, gen.NotNullGenerator().SetNotNull, gen.NotNullGenerator().DropCheck),
+			gen.AlterTableUp(contract),
+			dropTables,
+		),
+		Down: getContents(
+			recreateTables,
+			gen.AlterTableDown(contract),
+			gen.notNullStatements(plan, notNull, gen.NotNullGenerator().AddCheck, gen.NotNullGenerator().DropNotNull),
+		),
+		Transactional: true,
+	})
+
+	online := make([]*Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		if len(migration.Up) == 0 {
+			continue
+		}
+		if migration.Transactional {
+			migration.Up = gen.wrapOnlineTransaction(migration.Up)
+			migration.Down = gen.wrapOnlineTransaction(migration.Down)
+		}
+		if len(migration.Down) == 0 {
+			migration.Down = NothingToRollBack
+		}
+		online = append(online, migration)
+	}
+	return online
+}
+
+// OnlineGuard refuses the online migrations whose expand phase would rewrite
+// a table under its exclusive lock, such as a type change copying every row,
+// which renamed_from moves in phases instead.
+func (gen *SqlGenerator) OnlineGuard(plan *step.MigrationPlanner) error {
+	if !gen.flag.Online {
+		return nil
+	}
+
+	refused := make([]*step.Lock, 0)
+	for _, as := range plan.AlteredTables() {
+		expanded, _ := expandSchema(as)
+		if lock := expanded.AlterLock(); lock != nil && lock.Rewrite {
+			refused = append(refused, lock)
+		}
+	}
+	if len(refused) == 0 {
+		return nil
+	}
+
+	fmt.Println(color.RedString("Rewrites in online migration, the following statements would hold the tables:"))
+	for _, lock := range refused {
+		fmt.Printf("\t%s\n", lock)
+	}
+	fmt.Println()
+
+	return fmt.Errorf("%w: %d statement(s) would rewrite a table, use renamed_from to change the column types or generate them without --online", ErrOnlineRewrite, len(refused))
+}
+
+// expandSchema returns the changes of the table which neither drop anything
+// nor need an index, along with the columns set NOT NULL by a check instead.
+func expandSchema(as *step.AlterSchema) (*step.AlterSchema, []string) {
+	expanded := step.NewAlterSchema(as.Name)
+	expanded.AddedColumns = as.AddedColumns
+	expanded.ExpandedColumns = as.ExpandedColumns
+	expanded.GrantedPrivileges = as.GrantedPrivileges
+	expanded.RevokedPrivileges = as.RevokedPrivileges
+	expanded.Storage = as.Storage
+
+	notNull := make([]string, 0)
+	for _, column := range as.AlteredColumns {
+		altered := *column
+		altered.ChangedOptions = make([]step.OptionAction, 0, len(column.ChangedOptions))
+		for _, option := range column.ChangedOptions {
+			if option == step.SetNotNull {
+				notNull = append(notNull, column.Name)
+				continue
+			}
+			altered.ChangedOptions = append(altered.ChangedOptions, option)
+		}
+
+		if altered.HasChanges() || altered.ChangedDefaultValue {
+			expanded.AlteredColumns = append(expanded.AlteredColumns, &altered)
+		}
+	}
+	return expanded, notNull
+}
+
+func (gen *SqlGenerator) notNullStatements(plan *step.MigrationPlanner, columns map[string][]string, statements ...func(sb.SQLBuilder, string, string)) []byte {
+	b := sb.NewSQLBuilder()
+	for _, as := range plan.AlteredTables() {
+		for _, column := range columns[as.Name] {
+			for _, statement := range statements {
+				statement(b, as.Name, column)
+				b.WriteNewLine()
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+func (gen *SqlGenerator) wrapOnlineTransaction(content []byte) []byte {
+	if len(content) == 0 {
+		return content
+	}
+
+	lockTimeout := []byte{}
+	if gen.flag.LockTimeout > 0 {
+		lockTimeout = []byte(fmt.Sprintf("%s'%dms';", gen.dialectOption.LockTimeoutClause, gen.flag.LockTimeout.Milliseconds()))
+	}
+	return getContents(gen.dialectOption.BeginClause, lockTimeout, content, gen.dialectOption.CommitClause)
+}
+
+// unwrapTransaction returns the migration without the BEGIN and COMMIT
+// wrapping it, for the tools running every migration in a transaction.
+func (gen *SqlGenerator) unwrapTransaction(migration *Migration) *Migration {
+	if !migration.Transactional {
+		return migration
+	}
+
+	unwrap := func(content []byte) []byte {
+		content = bytes.TrimPrefix(content, gen.dialectOption.BeginClause)
+		content = bytes.TrimSuffix(content, gen.dialectOption.CommitClause)
+		return bytes.TrimSpace(content)
+	}
+
+	unwrapped := *migration
+	unwrapped.Up = unwrap(migration.Up)
+	unwrapped.Down = unwrap(migration.Down)
+	return &unwrapped
+}
+
+// PhasedMigration writes the migrations of the plan in the layout of the
+// format, every one with its own version so they are applied in order.
+func (gen *SqlGenerator) PhasedMigration(plan *step.MigrationPlanner) error {
+	fmt.Println("🚀 Generating phased database migration files")
+
+	migrations := gen.Migrations(plan)
+	if len(migrations) == 0 {
+		fmt.Println(color.YellowString("No changes being detected, skipping..."))
+		return nil
+	}
+
+	for i, migration := range migrations {
+		if gen.writer.RunsTransactions() {
+			migration = gen.unwrapTransaction(migration)
+		}
+
+		for j, file := range gen.writer.Files(phaseTarget(gen.flag.OutputTarget, i, migration.Name), migration) {
+			filename := fmt.Sprintf("%s/%s", gen.flag.OutputDirectory, file.Name)
+			if j == 0 {
+				fmt.Printf("Target file: %s\n", color.HiBlueString(filename))
+			}
+
+			err := gen.Writer(filename, file.Content)
+			if err != nil {
+				fmt.Println(color.RedString("Failed"))
+				return err
+			}
+		}
+	}
+
+	gen.upWritten = true
+	fmt.Println(color.GreenString("Succeeded"))
+	return nil
+}
+
+// phaseTarget names the nth migration of an online migration, the version of
+// the target taking the nth second after it so the migrations keep their
+// order. The seconds are kept free by the version of the next generation.
+func phaseTarget(target string, n int, name string) string {
+	if name != "" {
+		target = fmt.Sprintf("%s_%s", target, name)
+	}
+	if n == 0 {
+		return target
+	}
+
+	version, rest, found := strings.Cut(target, "_")
+	if t, err := time.Parse(VersionLayout, version); found && err == nil {
+		return fmt.Sprintf("%s_%s", t.Add(time.Duration(n)*time.Second).Format(VersionLayout), rest)
+	}
+
+	v, err := strconv.ParseUint(version, 10, 64)
+	if !found || err != nil {
+		return fmt.Sprintf("%d_%s", n, target)
+	}
+	return fmt.Sprintf("%0*d_%s", len(version), v+uint64(n), rest)
+}
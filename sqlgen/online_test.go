@@ -0,0 +1,214 @@
+package sqlgen_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	mock_schema "gitlab.com/wartek-id/core/tools/dbgen/sqlgen/mocks/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/registry"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+)
+
+func onlineSchemas() ([]*config.Schema, []*config.Schema) {
+	current := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "name", Type: "varchar", Limit: 100},
+				{Name: "legacy", Type: "text"},
+			},
+			Index: []*config.Index{
+				{Name: "index_users_on_legacy", Fields: []*config.IndexField{{Column: "legacy"}}},
+			},
+		},
+	}
+	target := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "name", Type: "varchar", Limit: 100, Options: []field_option.FieldOption{field_option.NotNull}},
+				{Name: "email", Type: "varchar", Limit: 200},
+			},
+			Index: []*config.Index{
+				{Name: "index_users_on_email", Fields: []*config.IndexField{{Column: "email"}}, Unique: true},
+			},
+		},
+	}
+	return current, target
+}
+
+func TestSqlGenerator_GenerateOnline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	current, target := onlineSchemas()
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return(current, nil).AnyTimes()
+
+	dir := t.TempDir()
+	gen := sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{
+		FullSchemaFile:   fullSchemaFile(t),
+		OutputDirectory:  dir,
+		OutputTarget:     "20240102030405_users",
+		AllowDestructive: true,
+		Online:           true,
+		LockTimeout:      5 * time.Second,
+	})
+	err := gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	assert.NoError(t, err)
+	sort.Strings(files)
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	assert.Equal(t, []string{
+		"20240102030405_users.up.sql",
+		"20240102030406_users_drop_index_users_on_legacy.up.sql",
+		"20240102030407_users_create_index_users_on_email.up.sql",
+		"20240102030408_users_validate.up.sql",
+		"20240102030409_users_contract.up.sql",
+	}, files)
+
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, "BEGIN;\n\n"+
+		"SET LOCAL lock_timeout = '5000ms';\n\n"+
+		"ALTER TABLE IF EXISTS \"users\"\n\tADD COLUMN \"email\" VARCHAR(200);\n\n"+
+		"ALTER TABLE IF EXISTS \"users\" ADD CONSTRAINT \"users_name_not_null\" CHECK (\"name\" IS NOT NULL) NOT VALID;\n\n"+
+		"COMMIT;", read("20240102030405_users.up.sql"))
+	assert.Equal(t, "BEGIN;\n\n"+
+		"SET LOCAL lock_timeout = '5000ms';\n\n"+
+		"ALTER TABLE IF EXISTS \"users\" DROP CONSTRAINT IF EXISTS \"users_name_not_null\";\n\n"+
+		"ALTER TABLE IF EXISTS \"users\"\n\tDROP COLUMN \"email\";\n\n"+
+		"COMMIT;", read("20240102030405_users.down.sql"))
+
+	assert.Equal(t, `DROP INDEX CONCURRENTLY IF EXISTS "index_users_on_legacy";`, read("20240102030406_users_drop_index_users_on_legacy.up.sql"))
+	assert.Equal(t, `CREATE INDEX CONCURRENTLY IF NOT EXISTS "index_users_on_legacy" ON "users"("legacy");`, read("20240102030406_users_drop_index_users_on_legacy.down.sql"))
+	assert.Equal(t, `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS "index_users_on_email" ON "users"("email");`, read("20240102030407_users_create_index_users_on_email.up.sql"))
+	assert.Equal(t, `DROP INDEX CONCURRENTLY IF EXISTS "index_users_on_email";`, read("20240102030407_users_create_index_users_on_email.down.sql"))
+
+	assert.Contains(t, read("20240102030408_users_validate.up.sql"), "ALTER TABLE IF EXISTS \"users\" VALIDATE CONSTRAINT \"users_name_not_null\";")
+	assert.Equal(t, string(sqlgen.NothingToRollBack), read("20240102030408_users_validate.down.sql"))
+
+	assert.Equal(t, "BEGIN;\n\n"+
+		"SET LOCAL lock_timeout = '5000ms';\n\n"+
+		"ALTER TABLE IF EXISTS \"users\" ALTER COLUMN \"name\" SET NOT NULL;\n"+
+		"ALTER TABLE IF EXISTS \"users\" DROP CONSTRAINT IF EXISTS \"users_name_not_null\";\n\n"+
+		"ALTER TABLE IF EXISTS \"users\"\n\tDROP COLUMN \"legacy\";\n\n"+
+		"COMMIT;", read("20240102030409_users_contract.up.sql"))
+	assert.Equal(t, "BEGIN;\n\n"+
+		"SET LOCAL lock_timeout = '5000ms';\n\n"+
+		"ALTER TABLE IF EXISTS \"users\"\n\tADD COLUMN \"legacy\" TEXT;\n\n"+
+		"ALTER TABLE IF EXISTS \"users\" ADD CONSTRAINT \"users_name_not_null\" CHECK (\"name\" IS NOT NULL) NOT VALID;\n"+
+		"ALTER TABLE IF EXISTS \"users\" ALTER COLUMN \"name\" DROP NOT NULL;\n\n"+
+		"COMMIT;", read("20240102030409_users_contract.down.sql"))
+}
+
+func TestSqlGenerator_GenerateOnlineVersions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	current, target := onlineSchemas()
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return(current, nil).AnyTimes()
+
+	dir := t.TempDir()
+	gen := sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{
+		FullSchemaFile:   fullSchemaFile(t),
+		OutputDirectory:  dir,
+		OutputTarget:     "20240102235958_users",
+		AllowDestructive: true,
+		Online:           true,
+	})
+	err := gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	assert.NoError(t, err)
+	sort.Strings(files)
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	assert.Equal(t, []string{
+		"20240102235958_users.up.sql",
+		"20240102235959_users_drop_index_users_on_legacy.up.sql",
+		"20240103000000_users_create_index_users_on_email.up.sql",
+		"20240103000001_users_validate.up.sql",
+		"20240103000002_users_contract.up.sql",
+	}, files)
+}
+
+func TestSqlGenerator_ReportOnline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	current, target := onlineSchemas()
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return(current, nil).AnyTimes()
+
+	gen := sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{Online: true})
+	buf := &bytes.Buffer{}
+	err := gen.Report(context.Background(), buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "-- up\nBEGIN;")
+	assert.Contains(t, buf.String(), "-- create_index_users_on_email up\nCREATE UNIQUE INDEX CONCURRENTLY")
+	assert.Contains(t, buf.String(), "-- contract down\nBEGIN;")
+}
+
+func TestSqlGenerator_GenerateOnlineUnsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	current, target := onlineSchemas()
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return(current, nil).AnyTimes()
+
+	gen := sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: t.TempDir(), Dialect: "mysql", Online: true})
+	err := gen.Generate(context.Background())
+	assert.True(t, errors.Is(err, registry.ErrUnsupportedFeature))
+	assert.EqualError(t, err, "unsupported feature: online migrations for mysql dialect")
+}
+
+func TestSqlGenerator_GenerateOnlineRewrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	current, target := onlineSchemas()
+	mockCrawler := mock_schema.NewMockSchema(ctrl)
+	mockCrawler.EXPECT().GetSchemas(gomock.Any()).Return(current, nil).AnyTimes()
+
+	// widening a varchar keeps the rows, changing it to int rewrites them
+	target[0].Fields[1].Limit = 200
+	dir := t.TempDir()
+	gen := sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "20240102030405_users", AllowDestructive: true, Online: true})
+	err := gen.Generate(context.Background())
+	assert.NoError(t, err)
+
+	target[0].Fields[1] = &config.Field{Name: "name", Type: "int"}
+	dir = t.TempDir()
+	gen = sqlgen.NewGenerator(mockCrawler, target, &sqlgen.Flag{FullSchemaFile: fullSchemaFile(t), OutputDirectory: dir, OutputTarget: "20240102030405_users", AllowDestructive: true, Online: true})
+	err = gen.Generate(context.Background())
+	assert.True(t, errors.Is(err, sqlgen.ErrOnlineRewrite))
+	assert.EqualError(t, err, "online migration rewrites tables: 1 statement(s) would rewrite a table, use renamed_from to change the column types or generate them without --online")
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
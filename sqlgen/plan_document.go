@@ -0,0 +1,254 @@
+package sqlgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
+)
+
+// PlanFormatJSON is the format of the plan written as a PlanDocument.
+const PlanFormatJSON = "json"
+
+var ErrUnsupportedPlanFormat = errors.New("unsupported plan format")
+
+// PlanDocument is the machine readable form of a migration plan, for tools
+// reviewing or deploying a migration without parsing its SQL.
+type PlanDocument struct {
+	Dialect       string           `json:"dialect"`
+	HasChanges    bool             `json:"has_changes"`
+	CreatedTables []*TableStep     `json:"created_tables"`
+	DroppedTables []*TableStep     `json:"dropped_tables"`
+	AlteredTables []*AlterStep     `json:"altered_tables"`
+	Changes       []*ChangeStep    `json:"changes"`
+	Migrations    []*MigrationStep `json:"migrations"`
+}
+
+// TableStep is a created or dropped table along with the SQL of the step.
+type TableStep struct {
+	Name   string         `json:"name"`
+	Schema *config.Schema `json:"schema"`
+	Up     string         `json:"up"`
+	Down   string         `json:"down"`
+}
+
+// AlterStep is the changes of an altered table along with the SQL of the step.
+type AlterStep struct {
+	Name              string            `json:"name"`
+	AddedColumns      []*config.Field   `json:"added_columns"`
+	AlteredColumns    []*ColumnStep     `json:"altered_columns"`
+	DroppedColumns    []*config.Field   `json:"dropped_columns"`
+	ExpandedColumns   []*TransitionStep `json:"expanded_columns"`
+	ContractedColumns []*TransitionStep `json:"contracted_columns"`
+	AddedIndices      []*config.Index   `json:"added_indices"`
+	DroppedIndices    []*config.Index   `json:"dropped_indices"`
+	GrantedPrivileges []*config.Grant   `json:"granted_privileges"`
+	RevokedPrivileges []*config.Grant   `json:"revoked_privileges"`
+	Storage           *StorageStep      `json:"storage,omitempty"`
+	Up                string            `json:"up"`
+	Down              string            `json:"down"`
+}
+
+// ColumnStep is an altered column, Changes names what differs between the
+// fields: type, collation, default, set_not_null or drop_not_null.
+type ColumnStep struct {
+	Name    string        `json:"name"`
+	Before  *config.Field `json:"before"`
+	After   *config.Field `json:"after"`
+	Changes []string      `json:"changes"`
+}
+
+// TransitionStep is a column moved into another one by renamed_from.
+type TransitionStep struct {
+	From *config.Field `json:"from"`
+	To   *config.Field `json:"to"`
+}
+
+// StorageStep is the storage parameters set and reset on a table, along with
+// the tablespace and logging when they change.
+type StorageStep struct {
+	Set        map[string]string `json:"set"`
+	Reset      []string          `json:"reset"`
+	Tablespace *string           `json:"tablespace,omitempty"`
+	Unlogged   *bool             `json:"unlogged,omitempty"`
+}
+
+// ChangeStep is a step of the plan classified by its risk.
+type ChangeStep struct {
+	Table        string `json:"table"`
+	Column       string `json:"column,omitempty"`
+	Action       string `json:"action"`
+	Risk         string `json:"risk"`
+	Reason       string `json:"reason,omitempty"`
+	Acknowledged bool   `json:"acknowledged"`
+}
+
+// MigrationStep is a migration file written for the plan, its Name being
+// appended to the output target.
+type MigrationStep struct {
+	Name          string `json:"name"`
+	Up            string `json:"up"`
+	Down          string `json:"down"`
+	Transactional bool   `json:"transactional"`
+}
+
+// WritePlan writes the plan in the format without writing any migration file.
+func (gen *SqlGenerator) WritePlan(ctx context.Context, w io.Writer, format string) error {
+	if format != PlanFormatJSON {
+		return fmt.Errorf("%w: %s", ErrUnsupportedPlanFormat, format)
+	}
+
+	plan, err := gen.Plan(ctx)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(gen.PlanDocument(plan))
+}
+
+// PlanDocument describes the plan and the SQL of each of its steps, the SQL
+// of a step being the statements it adds to the migration, outside of the
+// transaction wrapping the migration.
+func (gen *SqlGenerator) PlanDocument(plan *step.MigrationPlanner) *PlanDocument {
+	doc := &PlanDocument{
+		Dialect:       gen.dialect,
+		CreatedTables: make([]*TableStep, 0),
+		DroppedTables: make([]*TableStep, 0),
+		AlteredTables: make([]*AlterStep, 0),
+		Changes:       make([]*ChangeStep, 0),
+		Migrations:    make([]*MigrationStep, 0),
+	}
+
+	for _, sc := range plan.CreateTable {
+		schemas := []*config.Schema{sc}
+		doc.CreatedTables = append(doc.CreatedTables, &TableStep{
+			Name:   sc.Name,
+			Schema: sc,
+			Up:     string(getContents(gen.GenerateCreateTables(schemas))),
+			Down:   string(getContents(gen.GenerateDropTables(schemas))),
+		})
+	}
+
+	if !gen.flag.SkipDropTable {
+		for _, sc := range plan.DropTable {
+			schemas := []*config.Schema{sc}
+			doc.DroppedTables = append(doc.DroppedTables, &TableStep{
+				Name:   sc.Name,
+				Schema: sc,
+				Up:     string(getContents(gen.GenerateDropTables(schemas))),
+				Down:   string(getContents(gen.GenerateCreateTables(schemas))),
+			})
+		}
+	}
+
+	for _, as := range plan.AlteredTables() {
+		if !as.HasChanges() {
+			continue
+		}
+		doc.AlteredTables = append(doc.AlteredTables, gen.alterStep(as))
+	}
+
+	for _, change := range plan.Changes(gen.flag.SkipDropTable) {
+		doc.Changes = append(doc.Changes, &ChangeStep{
+			Table:        change.Table,
+			Column:       change.Column,
+			Action:       change.Action,
+			Risk:         change.Risk.String(),
+			Reason:       change.Reason,
+			Acknowledged: change.Acknowledged,
+		})
+	}
+
+	for _, migration := range gen.Migrations(plan) {
+		doc.Migrations = append(doc.Migrations, &MigrationStep{
+			Name:          migration.Name,
+			Up:            string(migration.Up),
+			Down:          string(migration.Down),
+			Transactional: migration.Transactional,
+		})
+	}
+	doc.HasChanges = len(doc.Migrations) > 0
+	return doc
+}
+
+func (gen *SqlGenerator) alterStep(as *step.AlterSchema) *AlterStep {
+	alterSchemas := map[string]*step.AlterSchema{as.Name: as}
+	alter := &AlterStep{
+		Name:              as.Name,
+		AddedColumns:      append([]*config.Field{}, as.AddedColumns...),
+		AlteredColumns:    make([]*ColumnStep, 0, len(as.AlteredColumns)),
+		DroppedColumns:    append([]*config.Field{}, as.DroppedColumns...),
+		ExpandedColumns:   transitionSteps(as.ExpandedColumns),
+		ContractedColumns: transitionSteps(as.ContractedColumns),
+		AddedIndices:      append([]*config.Index{}, as.AddedIndices...),
+		DroppedIndices:    append([]*config.Index{}, as.DroppedIndices...),
+		GrantedPrivileges: append([]*config.Grant{}, as.GrantedPrivileges...),
+		RevokedPrivileges: append([]*config.Grant{}, as.RevokedPrivileges...),
+		Up:                string(gen.AlterTableUp(alterSchemas)),
+		Down:              string(gen.AlterTableDown(alterSchemas)),
+	}
+
+	for _, column := range as.AlteredColumns {
+		alter.AlteredColumns = append(alter.AlteredColumns, &ColumnStep{
+			Name:    column.Name,
+			Before:  column.LastField,
+			After:   column.Field,
+			Changes: columnChanges(column),
+		})
+	}
+
+	if as.IsStorageChanged() {
+		alter.Storage = &StorageStep{Set: make(map[string]string), Reset: make([]string, 0)}
+		for _, param := range as.Storage.SetParameters() {
+			alter.Storage.Set[param.Name] = param.Value
+		}
+		for _, param := range as.Storage.ResetParameters() {
+			alter.Storage.Reset = append(alter.Storage.Reset, param.Name)
+		}
+		if as.Storage.IsTablespaceChanged() {
+			tablespace := as.Storage.Storage.GetTablespace()
+			alter.Storage.Tablespace = &tablespace
+		}
+		if as.Storage.IsUnloggedChanged() {
+			unlogged := as.Storage.Storage.IsUnlogged()
+			alter.Storage.Unlogged = &unlogged
+		}
+	}
+	return alter
+}
+
+func columnChanges(column *step.AlterColumn) []string {
+	changes := make([]string, 0)
+	if column.ChangedType {
+		changes = append(changes, "type")
+	}
+	if column.ChangedCollation {
+		changes = append(changes, "collation")
+	}
+	if column.ChangedDefaultValue {
+		changes = append(changes, "default")
+	}
+	for _, option := range column.ChangedOptions {
+		switch option {
+		case step.SetNotNull:
+			changes = append(changes, "set_not_null")
+		case step.DropNotNull:
+			changes = append(changes, "drop_not_null")
+		}
+	}
+	return changes
+}
+
+func transitionSteps(transitions []*step.ColumnTransition) []*TransitionStep {
+	steps := make([]*TransitionStep, 0, len(transitions))
+	for _, transition := range transitions {
+		steps = append(steps, &TransitionStep{From: transition.From, To: transition.To})
+	}
+	return steps
+}
@@ -0,0 +1,109 @@
+package sqlgen_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+)
+
+func TestSqlGenerator_WritePlan(t *testing.T) {
+	oldSchemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "name", Type: "varchar", Limit: 100},
+				{Name: "legacy", Type: "text"},
+			},
+		},
+		{
+			Name:   "logs",
+			Fields: []*config.Field{{Name: "id", Type: "bigint"}},
+		},
+	}
+	newSchemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "name", Type: "varchar", Limit: 200, Options: []field_option.FieldOption{field_option.NotNull}},
+				{Name: "email", Type: "varchar", Limit: 100},
+			},
+			Index: []*config.Index{
+				{Name: "index_users_on_email", Fields: []*config.IndexField{{Column: "email", Order: "ASC"}}},
+			},
+		},
+		{
+			Name:   "documents",
+			Fields: []*config.Field{{Name: "id", Type: "bigserial"}},
+		},
+	}
+
+	gen := sqlgen.NewGenerator(schema.NewStaticSchema(oldSchemas), newSchemas, &sqlgen.Flag{})
+	buf := bytes.Buffer{}
+	err := gen.WritePlan(context.Background(), &buf, sqlgen.PlanFormatJSON)
+	assert.NoError(t, err)
+
+	doc := sqlgen.PlanDocument{}
+	err = json.Unmarshal(buf.Bytes(), &doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres", doc.Dialect)
+	assert.True(t, doc.HasChanges)
+
+	assert.Len(t, doc.CreatedTables, 1)
+	assert.Equal(t, "documents", doc.CreatedTables[0].Name)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS \"documents\" (\n\t\"id\" BIGSERIAL\n);", doc.CreatedTables[0].Up)
+	assert.Equal(t, "DROP TABLE IF EXISTS \"documents\";", doc.CreatedTables[0].Down)
+
+	assert.Len(t, doc.DroppedTables, 1)
+	assert.Equal(t, "logs", doc.DroppedTables[0].Name)
+	assert.Equal(t, "DROP TABLE IF EXISTS \"logs\";", doc.DroppedTables[0].Up)
+
+	assert.Len(t, doc.AlteredTables, 1)
+	users := doc.AlteredTables[0]
+	assert.Equal(t, "users", users.Name)
+	assert.Equal(t, []*config.Field{newSchemas[0].Fields[2]}, users.AddedColumns)
+	assert.Equal(t, "legacy", users.DroppedColumns[0].Name)
+	assert.Equal(t, "index_users_on_email", users.AddedIndices[0].Name)
+	assert.Empty(t, users.DroppedIndices)
+	assert.Len(t, users.AlteredColumns, 1)
+	assert.Equal(t, "name", users.AlteredColumns[0].Name)
+	assert.Equal(t, 100, users.AlteredColumns[0].Before.Limit)
+	assert.Equal(t, 200, users.AlteredColumns[0].After.Limit)
+	assert.Equal(t, []string{"type", "set_not_null"}, users.AlteredColumns[0].Changes)
+	assert.Contains(t, users.Up, "\tADD COLUMN \"email\" VARCHAR(100)")
+	assert.Contains(t, users.Up, "CREATE INDEX IF NOT EXISTS \"index_users_on_email\" ON \"users\"(\"email\" ASC);")
+	assert.Contains(t, users.Down, "\tADD COLUMN \"legacy\" TEXT")
+	assert.NotContains(t, users.Up, "BEGIN;")
+
+	assert.Contains(t, doc.Changes, &sqlgen.ChangeStep{
+		Table:  "logs",
+		Action: "drop table",
+		Risk:   "destructive",
+		Reason: "every row is lost",
+	})
+	assert.Contains(t, doc.Changes, &sqlgen.ChangeStep{Table: "users", Column: "email", Action: "add column", Risk: "safe"})
+
+	assert.Len(t, doc.Migrations, 1)
+	assert.Equal(t, "", doc.Migrations[0].Name)
+	assert.Contains(t, doc.Migrations[0].Up, "BEGIN;")
+	assert.Contains(t, doc.Migrations[0].Down, "COMMIT;")
+
+	buf.Reset()
+	gen = sqlgen.NewGenerator(schema.NewStaticSchema(newSchemas), newSchemas, &sqlgen.Flag{})
+	err = gen.WritePlan(context.Background(), &buf, sqlgen.PlanFormatJSON)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "\"has_changes\": false,\n  \"created_tables\": [],")
+
+	err = gen.WritePlan(context.Background(), &buf, "yaml")
+	assert.True(t, errors.Is(err, sqlgen.ErrUnsupportedPlanFormat))
+	assert.EqualError(t, err, "unsupported plan format: yaml")
+}
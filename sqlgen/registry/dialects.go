@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dialect"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+)
+
+const (
+	Postgres  = "postgres"
+	MySQL     = "mysql"
+	SQLite    = "sqlite"
+	Cockroach = "cockroach"
+)
+
+func init() {
+	Register(&Dialect{
+		Name:         Postgres,
+		Drivers:      []string{"postgresql", "postgres"},
+		QueryDialect: "postgres",
+		SqlcEngine:   "postgresql",
+		TypeMapper:   schema.FieldTypeMapper,
+		Options:      dialect.DefaultDialectOption,
+		NewCrawler:   schema.ConnectPostgres,
+	})
+
+	Register(&Dialect{
+		Name:         MySQL,
+		Drivers:      []string{"mysql"},
+		QueryDialect: "mysql",
+		SqlcEngine:   "mysql",
+		TypeMapper:   schema.MySQLFieldTypeMapper,
+		Options:      dialect.MySQLDialectOption,
+		NewCrawler:   schema.ConnectMySQL,
+	})
+
+	Register(&Dialect{
+		Name:         SQLite,
+		Drivers:      []string{"sqlite", "sqlite3"},
+		QueryDialect: "sqlite3",
+		TypeMapper:   schema.SQLiteFieldTypeMapper,
+		Options:      dialect.SQLiteDialectOption,
+		NewCrawler:   schema.ConnectSQLite,
+	})
+
+	Register(&Dialect{
+		Name:         Cockroach,
+		Drivers:      []string{"cockroachdb", "cockroach"},
+		QueryDialect: "postgres",
+		SqlcEngine:   "postgresql",
+		TypeMapper:   schema.FieldTypeMapper,
+		Options:      dialect.CockroachDialectOption,
+		NewCrawler:   schema.ConnectCockroach,
+	})
+}
@@ -0,0 +1,169 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dialect"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_type"
+)
+
+var (
+	ErrUnknownDialect     = errors.New("unknown dialect")
+	ErrUnsupportedType    = errors.New("unsupported field type")
+	ErrUnsupportedOption  = errors.New("unsupported field option")
+	ErrUnsupportedFeature = errors.New("unsupported feature")
+	ErrImpossibleCast     = errors.New("impossible type conversion")
+	ErrMissingPrimaryKey  = errors.New("table has no primary key")
+)
+
+// Dialect describes a database supported by the generators.
+type Dialect struct {
+	// Name is the value of the --dialect flag.
+	Name string
+	// Drivers are the connection string schemes handled by the dialect.
+	Drivers []string
+	// QueryDialect is the goqu dialect used to generate CRUD queries.
+	QueryDialect string
+	// SqlcEngine is the sqlc engine, empty when sqlc cannot generate the code.
+	SqlcEngine string
+	// TypeMapper maps database types into field types when crawling.
+	TypeMapper map[string]field_type.FieldType
+
+	Options    func() *dialect.DialectOption
+	NewCrawler func(ctx context.Context, connString string, opts schema.ConnectOptions) (schema.Schema, error)
+}
+
+var dialects = make(map[string]*Dialect)
+
+func Register(d *Dialect) {
+	dialects[d.Name] = d
+}
+
+func Get(name string) (*Dialect, error) {
+	d := dialects[name]
+	if d == nil {
+		return nil, fmt.Errorf("%w %q, available dialects: %s", ErrUnknownDialect, name, strings.Join(Names(), ", "))
+	}
+	return d, nil
+}
+
+// GetByDriver returns the dialect handling the scheme of the connection string.
+func GetByDriver(connString string) (*Dialect, error) {
+	driver := schema.GetDriver(connString)
+	for _, name := range Names() {
+		for _, d := range dialects[name].Drivers {
+			if d == driver {
+				return dialects[name], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w %q", schema.ErrUnsupportedDriver, driver)
+}
+
+// Resolve returns the named dialect, or the one handling the connection
+// string when name is empty.
+func Resolve(name, connString string) (*Dialect, error) {
+	if name != "" {
+		return Get(name)
+	}
+	return GetByDriver(connString)
+}
+
+func Names() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate returns an error for the first type, option or feature used by
+// the schemas which the dialect cannot express.
+func (d *Dialect) Validate(schemas []*config.Schema) error {
+	do := d.Options()
+	for _, sc := range schemas {
+		for _, field := range sc.Fields {
+			if len(do.DataTypesLookup[field.Type]) == 0 {
+				return fmt.Errorf("%w %q on %s.%s for %s dialect", ErrUnsupportedType, field.Type, sc.Name, field.Name, d.Name)
+			}
+
+			if field.Using != "" && !do.SupportUsing {
+				return fmt.Errorf("%w: using on %s.%s for %s dialect", ErrUnsupportedFeature, sc.Name, field.Name, d.Name)
+			}
+
+			if field.DownUsing != "" && !do.SupportUsing {
+				return fmt.Errorf("%w: down_using on %s.%s for %s dialect", ErrUnsupportedFeature, sc.Name, field.Name, d.Name)
+			}
+
+			if field.RenamedFrom != "" && !do.SupportOnline {
+				return fmt.Errorf("%w: renamed_from on %s.%s for %s dialect", ErrUnsupportedFeature, sc.Name, field.Name, d.Name)
+			}
+
+			for _, option := range field.Options {
+				if len(do.FieldOptionsLookup[option]) == 0 {
+					return fmt.Errorf("%w %q on %s.%s for %s dialect", ErrUnsupportedOption, option, sc.Name, field.Name, d.Name)
+				}
+			}
+		}
+
+		if len(sc.Grants) > 0 && !do.SupportGrant {
+			return fmt.Errorf("%w: grants on %s for %s dialect", ErrUnsupportedFeature, sc.Name, d.Name)
+		}
+
+		if sc.Storage != nil && !do.SupportStorage {
+			return fmt.Errorf("%w: storage on %s for %s dialect", ErrUnsupportedFeature, sc.Name, d.Name)
+		}
+
+		if sc.Large && !do.SupportLockAnalysis {
+			return fmt.Errorf("%w: large on %s for %s dialect", ErrUnsupportedFeature, sc.Name, d.Name)
+		}
+	}
+	return nil
+}
+
+// ValidatePlan returns an error for the first column type change or column
+// transition the dialect cannot convert, unless the schema gives a USING
+// expression, and for the transitions of a table without a primary key,
+// which the backfill walks.
+func (d *Dialect) ValidatePlan(plan *step.MigrationPlanner) error {
+	do := d.Options()
+	for _, as := range plan.AlteredTables() {
+		for _, column := range as.AlteredColumns {
+			if !column.ChangedType {
+				continue
+			}
+
+			if column.Field.Using == "" && do.Cast(column.LastField.Type, column.Field.Type).Kind == dialect.CastImpossible {
+				return d.impossibleCast(as.Name, column.LastField, column.Field)
+			}
+			// the down migration restores the previous type
+			if column.Field.DownUsing == "" && do.Cast(column.Field.Type, column.LastField.Type).Kind == dialect.CastImpossible {
+				return fmt.Errorf("%w from %s back to %s on %s.%s for %s dialect, set down_using on the field to convert the values back",
+					ErrImpossibleCast, step.TypeName(column.Field), step.TypeName(column.LastField), as.Name, column.Field.Name, d.Name)
+			}
+		}
+
+		for _, transition := range as.ExpandedColumns {
+			if len(as.PrimaryKey()) == 0 {
+				return fmt.Errorf("%w: renamed_from on %s.%s needs it to backfill the rows in batches", ErrMissingPrimaryKey, as.Name, transition.To.Name)
+			}
+			if transition.To.Using == "" && do.Cast(transition.From.Type, transition.To.Type).Kind == dialect.CastImpossible {
+				return d.impossibleCast(as.Name, transition.From, transition.To)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Dialect) impossibleCast(table string, from, to *config.Field) error {
+	return fmt.Errorf("%w from %s to %s on %s.%s for %s dialect, set using on the field to convert the values",
+		ErrImpossibleCast, step.TypeName(from), step.TypeName(to), table, to.Name, d.Name)
+}
@@ -0,0 +1,279 @@
+package registry_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/registry"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+func TestGet(t *testing.T) {
+	d, err := registry.Get("mysql")
+	assert.Nil(t, err)
+	assert.Equal(t, "mysql", d.Name)
+
+	d, err = registry.Get("oracle")
+	assert.True(t, errors.Is(err, registry.ErrUnknownDialect))
+	assert.EqualError(t, err, "unknown dialect \"oracle\", available dialects: cockroach, mysql, postgres, sqlite")
+	assert.Nil(t, d)
+}
+
+func TestResolve(t *testing.T) {
+	testCases := map[string]struct {
+		name       string
+		connString string
+		result     string
+		err        error
+	}{
+		"by driver": {
+			connString: "postgresql://postgres@localhost:5432/playground",
+			result:     "postgres",
+		},
+		"by driver alias": {
+			connString: "sqlite3://local.db",
+			result:     "sqlite",
+		},
+		"by name": {
+			name:       "mysql",
+			connString: "postgresql://postgres@localhost:5432/playground",
+			result:     "mysql",
+		},
+		"unsupported driver": {
+			connString: "sqlserver://sa@localhost:1433/playground",
+			err:        schema.ErrUnsupportedDriver,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d, err := registry.Resolve(tc.name, tc.connString)
+			if tc.err != nil {
+				assert.True(t, errors.Is(err, tc.err))
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.result, d.Name)
+		})
+	}
+}
+
+func TestDialect_Validate(t *testing.T) {
+	testCases := map[string]struct {
+		dialect string
+		schema  *config.Schema
+		err     string
+	}{
+		"valid": {
+			dialect: "mysql",
+			schema: &config.Schema{
+				Name: "users",
+				Fields: []*config.Field{
+					{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+					{Name: "bio", Type: "text"},
+				},
+			},
+		},
+		"unsupported type": {
+			dialect: "postgres",
+			schema: &config.Schema{
+				Name:   "users",
+				Fields: []*config.Field{{Name: "id", Type: "uuid"}},
+			},
+			err: "unsupported field type \"uuid\" on users.id for postgres dialect",
+		},
+		"unsupported sqlite serial": {
+			dialect: "sqlite",
+			schema: &config.Schema{
+				Name:   "users",
+				Fields: []*config.Field{{Name: "id", Type: "smallserial", Options: []field_option.FieldOption{field_option.PrimaryKey}}},
+			},
+			err: "unsupported field type \"smallserial\" on users.id for sqlite dialect",
+		},
+		"unsupported jsonb": {
+			dialect: "mysql",
+			schema: &config.Schema{
+				Name:   "users",
+				Fields: []*config.Field{{Name: "payload", Type: "jsonb"}},
+			},
+			err: "unsupported field type \"jsonb\" on users.payload for mysql dialect",
+		},
+		"unsupported serial": {
+			dialect: "cockroach",
+			schema: &config.Schema{
+				Name:   "users",
+				Fields: []*config.Field{{Name: "id", Type: "serial"}},
+			},
+			err: "unsupported field type \"serial\" on users.id for cockroach dialect",
+		},
+		"unsupported option": {
+			dialect: "postgres",
+			schema: &config.Schema{
+				Name:   "users",
+				Fields: []*config.Field{{Name: "id", Type: "int", Options: []field_option.FieldOption{"zerofill"}}},
+			},
+			err: "unsupported field option \"zerofill\" on users.id for postgres dialect",
+		},
+		"unsupported grants": {
+			dialect: "sqlite",
+			schema: &config.Schema{
+				Name:   "users",
+				Grants: []*config.Grant{{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}}},
+			},
+			err: "unsupported feature: grants on users for sqlite dialect",
+		},
+		"unsupported storage": {
+			dialect: "mysql",
+			schema: &config.Schema{
+				Name:    "users",
+				Storage: &config.Storage{Fillfactor: 70},
+			},
+			err: "unsupported feature: storage on users for mysql dialect",
+		},
+		"unsupported using": {
+			dialect: "mysql",
+			schema: &config.Schema{
+				Name:   "users",
+				Fields: []*config.Field{{Name: "age", Type: "int", Using: "CAST(age AS SIGNED)"}},
+			},
+			err: "unsupported feature: using on users.age for mysql dialect",
+		},
+		"unsupported renamed_from": {
+			dialect: "sqlite",
+			schema: &config.Schema{
+				Name:   "users",
+				Fields: []*config.Field{{Name: "email", Type: "text", RenamedFrom: "mail"}},
+			},
+			err: "unsupported feature: renamed_from on users.email for sqlite dialect",
+		},
+		"unsupported large": {
+			dialect: "mysql",
+			schema:  &config.Schema{Name: "users", Large: true},
+			err:     "unsupported feature: large on users for mysql dialect",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d, err := registry.Get(tc.dialect)
+			assert.Nil(t, err)
+
+			err = d.Validate([]*config.Schema{tc.schema})
+			if tc.err == "" {
+				assert.Nil(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestDialect_ValidatePlan(t *testing.T) {
+	testCases := map[string]struct {
+		dialect string
+		from    *config.Field
+		to      *config.Field
+		expand  bool
+		// noKey leaves the table of the transition without a primary key
+		noKey bool
+		err   string
+	}{
+		"implicit": {
+			dialect: "postgres",
+			from:    &config.Field{Name: "age", Type: "int"},
+			to:      &config.Field{Name: "age", Type: "bigint"},
+		},
+		"using": {
+			dialect: "postgres",
+			from:    &config.Field{Name: "age", Type: "varchar", Limit: 10},
+			to:      &config.Field{Name: "age", Type: "int"},
+		},
+		"impossible": {
+			dialect: "postgres",
+			from:    &config.Field{Name: "age", Type: "int"},
+			to:      &config.Field{Name: "age", Type: "timestamptz"},
+			err:     "impossible type conversion from int to timestamptz on users.age for postgres dialect, set using on the field to convert the values",
+		},
+		"impossible rollback": {
+			dialect: "postgres",
+			from:    &config.Field{Name: "age", Type: "int"},
+			to:      &config.Field{Name: "age", Type: "timestamptz", Using: "to_timestamp(age)"},
+			err:     "impossible type conversion from timestamptz back to int on users.age for postgres dialect, set down_using on the field to convert the values back",
+		},
+		"impossible with using": {
+			dialect: "postgres",
+			from:    &config.Field{Name: "age", Type: "int"},
+			to:      &config.Field{Name: "age", Type: "timestamptz", Using: "to_timestamp(age)", DownUsing: "extract(epoch from age)::int"},
+		},
+		"impossible expand": {
+			dialect: "postgres",
+			from:    &config.Field{Name: "joined", Type: "int"},
+			to:      &config.Field{Name: "joined_at", Type: "timestamptz", RenamedFrom: "joined"},
+			expand:  true,
+			err:     "impossible type conversion from int to timestamptz on users.joined_at for postgres dialect, set using on the field to convert the values",
+		},
+		"impossible expand with using": {
+			dialect: "postgres",
+			from:    &config.Field{Name: "joined", Type: "int"},
+			to:      &config.Field{Name: "joined_at", Type: "timestamptz", RenamedFrom: "joined", Using: "to_timestamp(joined)"},
+			expand:  true,
+		},
+		"expand without primary key": {
+			dialect: "postgres",
+			from:    &config.Field{Name: "joined", Type: "int"},
+			to:      &config.Field{Name: "joined_at", Type: "bigint", RenamedFrom: "joined"},
+			expand:  true,
+			noKey:   true,
+			err:     "table has no primary key: renamed_from on users.joined_at needs it to backfill the rows in batches",
+		},
+		"mysql": {
+			dialect: "mysql",
+			from:    &config.Field{Name: "age", Type: "int"},
+			to:      &config.Field{Name: "age", Type: "timestamptz"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d, err := registry.Get(tc.dialect)
+			assert.Nil(t, err)
+
+			plan := step.NewMigrationPlanner()
+			plan.AlterSchema["users"] = &step.AlterSchema{
+				Name: "users",
+				AlteredColumns: []*step.AlterColumn{
+					{Name: "age", Field: tc.to, LastField: tc.from, ChangedType: true},
+				},
+			}
+			if tc.expand {
+				lastSchema := &config.Schema{Name: "users", Fields: []*config.Field{tc.from}}
+				if !tc.noKey {
+					lastSchema.Fields = append(lastSchema.Fields, &config.Field{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}})
+				}
+				plan.AlterSchema["users"] = &step.AlterSchema{
+					Name:            "users",
+					ExpandedColumns: []*step.ColumnTransition{{From: tc.from, To: tc.to}},
+					LastSchema:      lastSchema,
+				}
+			}
+
+			err = d.ValidatePlan(plan)
+			if tc.err == "" {
+				assert.Nil(t, err)
+				return
+			}
+			if tc.noKey {
+				assert.True(t, errors.Is(err, registry.ErrMissingPrimaryKey))
+			} else {
+				assert.True(t, errors.Is(err, registry.ErrImpossibleCast))
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
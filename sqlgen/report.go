@@ -0,0 +1,218 @@
+package sqlgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
+)
+
+// Report writes a summary of the changes followed by the up and down
+// migrations, letting the impact be reviewed without writing any file.
+func (gen *SqlGenerator) Report(ctx context.Context, w io.Writer) error {
+	plan, err := gen.Plan(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = gen.writeReport(w, plan, false)
+	return err
+}
+
+// writeReport writes the summary of the plan, every change of the altered
+// tables when verbose, followed by the migrations. It tells whether the plan
+// has changes.
+func (gen *SqlGenerator) writeReport(w io.Writer, plan *step.MigrationPlanner, verbose bool) (bool, error) {
+	migrations := gen.Migrations(plan)
+	if len(migrations) == 0 {
+		_, err := fmt.Fprintln(w, "No changes being detected.")
+		return false, err
+	}
+
+	sections := append([]string{gen.Summary(plan, verbose)}, migrationSections(migrations)...)
+	_, err := fmt.Fprintln(w, strings.Join(sections, "\n\n"))
+	return true, err
+}
+
+// migrationSections labels the up and down content of every migration.
+func migrationSections(migrations []*Migration) []string {
+	sections := make([]string, 0, 2*len(migrations))
+	for _, migration := range migrations {
+		label := ""
+		if migration.Name != "" {
+			label = migration.Name + " "
+		}
+		sections = append(sections, fmt.Sprintf("-- %sup\n%s", label, migration.Up))
+		if len(migration.Down) > 0 {
+			sections = append(sections, fmt.Sprintf("-- %sdown\n%s", label, migration.Down))
+		}
+	}
+	return sections
+}
+
+// Summary lists the created, dropped and altered tables of the plan, the
+// altered ones with the count of their changes. Verbose summaries list every
+// change below its table: + for what is added, - for what is dropped and ~
+// for what is altered.
+func (gen *SqlGenerator) Summary(plan *step.MigrationPlanner, verbose bool) string {
+	lines := make([]string, 0)
+	for _, sc := range plan.CreateTable {
+		lines = append(lines, summaryLine("+", sc.Name+": created"))
+	}
+
+	if !gen.flag.SkipDropTable {
+		for _, sc := range plan.DropTable {
+			lines = append(lines, summaryLine("-", sc.Name+": dropped"))
+		}
+	}
+
+	for _, as := range plan.AlteredTables() {
+		changes := tableChanges(as)
+		if len(changes) == 0 {
+			continue
+		}
+
+		lines = append(lines, summaryLine("~", fmt.Sprintf("%s: %s", as.Name, strings.Join(countChanges(changes), ", "))))
+		if !verbose {
+			continue
+		}
+		for _, change := range changes {
+			for _, detail := range change.details {
+				lines = append(lines, "    "+summaryLine(change.marker, detail))
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// tableChange is a change of an object of an altered table, counted by its
+// object and action, and described by its details in verbose summaries.
+type tableChange struct {
+	object  string
+	action  string
+	marker  string
+	details []string
+}
+
+func tableChanges(as *step.AlterSchema) []*tableChange {
+	changes := make([]*tableChange, 0)
+	add := func(object, action, marker string, details ...string) {
+		changes = append(changes, &tableChange{object: object, action: action, marker: marker, details: details})
+	}
+
+	for _, field := range as.AddedColumns {
+		add("column", "added", "+", fmt.Sprintf("column %s %s", field.Name, step.TypeName(field)))
+	}
+	for _, column := range as.AlteredColumns {
+		add("column", "altered", "~", columnDetails(column)...)
+	}
+	for _, field := range as.DroppedColumns {
+		add("column", "dropped", "-", "column "+field.Name)
+	}
+	for _, transition := range as.ExpandedColumns {
+		add("column", "expanded", "+", fmt.Sprintf("column %s %s from %s", transition.To.Name, step.TypeName(transition.To), transition.From.Name))
+	}
+	for _, transition := range as.ContractedColumns {
+		add("column", "contracted", "-", fmt.Sprintf("column %s into %s", transition.From.Name, transition.To.Name))
+	}
+	for _, index := range as.AddedIndices {
+		add("index", "added", "+", "index "+index.Name)
+	}
+	for _, index := range as.DroppedIndices {
+		add("index", "dropped", "-", "index "+index.Name)
+	}
+	for _, grant := range as.GrantedPrivileges {
+		add("grant", "added", "+", "grant "+summaryGrant(grant))
+	}
+	for _, grant := range as.RevokedPrivileges {
+		add("grant", "revoked", "-", "grant "+summaryGrant(grant))
+	}
+	if as.IsStorageChanged() {
+		add("storage", "altered", "~", "storage")
+	}
+	return changes
+}
+
+// columnDetails describes every change of the altered column.
+func columnDetails(column *step.AlterColumn) []string {
+	details := make([]string, 0)
+	if column.ChangedType {
+		details = append(details, fmt.Sprintf("column %s type %s → %s", column.Name, step.TypeName(column.LastField), step.TypeName(column.Field)))
+	}
+	if column.ChangedCollation {
+		details = append(details, fmt.Sprintf("column %s collation %s → %s", column.Name, summaryValue(column.LastField.Collation), summaryValue(column.Field.Collation)))
+	}
+	if column.ChangedDefaultValue {
+		details = append(details, fmt.Sprintf("column %s default %s → %s", column.Name, summaryValue(column.LastField.Default), summaryValue(column.Field.Default)))
+	}
+	for _, option := range column.ChangedOptions {
+		if option == step.SetNotNull {
+			details = append(details, fmt.Sprintf("column %s set not null", column.Name))
+		} else {
+			details = append(details, fmt.Sprintf("column %s drop not null", column.Name))
+		}
+	}
+	return details
+}
+
+// countChanges counts the changes by object and action, in the order they
+// are first found, e.g. 2 columns added.
+func countChanges(changes []*tableChange) []string {
+	keys := make([]string, 0)
+	counts := make(map[string]int)
+	for _, change := range changes {
+		key := change.object + " " + change.action
+		if counts[key] == 0 {
+			keys = append(keys, key)
+		}
+		counts[key]++
+	}
+
+	results := make([]string, 0, len(keys))
+	for _, key := range keys {
+		object, action, _ := strings.Cut(key, " ")
+		switch {
+		case object == "storage":
+			results = append(results, key)
+		case counts[key] > 1:
+			results = append(results, fmt.Sprintf("%d %s %s", counts[key], pluralize(object), action))
+		default:
+			results = append(results, fmt.Sprintf("%d %s %s", counts[key], object, action))
+		}
+	}
+	return results
+}
+
+func pluralize(object string) string {
+	if object == "index" {
+		return "indices"
+	}
+	return object + "s"
+}
+
+func summaryLine(marker, text string) string {
+	line := marker + " " + text
+	switch marker {
+	case "+":
+		return color.GreenString(line)
+	case "-":
+		return color.RedString(line)
+	}
+	return color.YellowString(line)
+}
+
+func summaryGrant(grant *config.Grant) string {
+	return fmt.Sprintf("%s %s", grant.Role, grantPrivileges(grant))
+}
+
+func summaryValue(value interface{}) string {
+	if value == nil || value == "" {
+		return "none"
+	}
+	return fmt.Sprint(value)
+}
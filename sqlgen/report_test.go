@@ -0,0 +1,63 @@
+package sqlgen_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+)
+
+func TestSqlGenerator_Report(t *testing.T) {
+	oldSchemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "name", Type: "varchar", Limit: 100},
+			},
+		},
+		{
+			Name:   "logs",
+			Fields: []*config.Field{{Name: "id", Type: "bigint"}},
+		},
+	}
+	newSchemas := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}},
+				{Name: "name", Type: "varchar", Limit: 200},
+				{Name: "email", Type: "varchar", Limit: 100},
+			},
+			Index: []*config.Index{
+				{Name: "index_users_on_email", Fields: []*config.IndexField{{Column: "email", Order: "ASC"}}},
+			},
+		},
+		{
+			Name:   "documents",
+			Fields: []*config.Field{{Name: "id", Type: "bigserial"}},
+		},
+	}
+
+	gen := sqlgen.NewGenerator(schema.NewStaticSchema(oldSchemas), newSchemas, &sqlgen.Flag{})
+	buf := bytes.Buffer{}
+	err := gen.Report(context.Background(), &buf)
+	assert.NoError(t, err)
+
+	report := buf.String()
+	assert.Contains(t, report, "+ documents: created\n- logs: dropped\n~ users: 1 column added, 1 column altered, 1 index added\n\n-- up\nBEGIN;")
+	assert.Contains(t, report, "\tADD COLUMN \"email\" VARCHAR(100)")
+	assert.Contains(t, report, "-- down\nBEGIN;")
+	assert.Contains(t, report, "DROP TABLE IF EXISTS \"documents\";")
+
+	buf.Reset()
+	gen = sqlgen.NewGenerator(schema.NewStaticSchema(newSchemas), newSchemas, &sqlgen.Flag{})
+	err = gen.Report(context.Background(), &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "No changes being detected.\n", buf.String())
+}
@@ -0,0 +1,33 @@
+package schema
+
+import (
+	"strings"
+)
+
+const (
+	RegexCockroachAutoIncrement = RegexAutoIncrement + `|unique_rowid\(\)`
+	CockroachPostgresScheme     = "postgresql"
+)
+
+// CockroachSystemRoles own every table, their privileges are not managed by the schemas.
+var CockroachSystemRoles = []string{"admin", "root"}
+
+// NewCockroachSchema crawls CockroachDB through its postgres compatible catalog:
+// serial columns default to unique_rowid(), tables without primary key have
+// a hidden rowid column, grants have no grantor and storage parameters are
+// not supported.
+func NewCockroachSchema(pool PgInterface) *postgresSchema {
+	s := NewPostgresSchema(pool)
+	s.autoIncrementPattern = RegexCockroachAutoIncrement
+	s.skipHiddenColumns = true
+	s.excludedGrantees = CockroachSystemRoles
+	s.skipStorages = true
+	return s
+}
+
+// CockroachConnString converts cockroachdb:// connection strings into
+// the postgresql:// ones understood by pgx.
+func CockroachConnString(connString string) string {
+	driver := GetDriver(connString)
+	return CockroachPostgresScheme + strings.TrimPrefix(connString, driver)
+}
@@ -0,0 +1,101 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+func TestCockroach_GetField(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	fieldsResults := pgxmock.NewRows([]string{
+		"table_name", "column_name", "column_default", "is_nullable", "data_type", "character_maximum_length",
+		"numeric_precision", "numeric_scale", "collation_name",
+	}).AddRow(
+		"example", "id", "unique_rowid()", "NO", "bigint", nil, 64, nil, nil,
+	).AddRow(
+		"example", "name", "'Alfred':::STRING", "YES", "character varying", "200", nil, nil, nil,
+	)
+	mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"columns\" WHERE (.+)\"is_hidden\" = 'NO'").
+		WillReturnRows(fieldsResults)
+
+	indicesResults := pgxmock.NewRows([]string{
+		"relname", "relname", "indexdef", "indisprimary",
+	}).AddRow(
+		"example", "example_pkey", "CREATE UNIQUE INDEX example_pkey ON playground.public.example USING btree (id ASC)", true,
+	)
+	mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_index\"").
+		WillReturnRows(indicesResults)
+
+	sc := schema.NewCockroachSchema(mock)
+	result, err := sc.GetFields(context.Background(), "example")
+	assert.Nil(t, err)
+	assert.Equal(t, []*config.Field{
+		{
+			Name:  "id",
+			Type:  "bigserial",
+			Limit: 64,
+			Options: []field_option.FieldOption{
+				field_option.PrimaryKey,
+				field_option.NotNull,
+			},
+		},
+		{
+			Name:    "name",
+			Type:    "varchar",
+			Limit:   200,
+			Default: "Alfred",
+			Options: []field_option.FieldOption{},
+		},
+	}, result)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroach_GetGrants(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	grantResults := pgxmock.NewRows([]string{
+		"table_name", "grantee", "privilege_type",
+	}).AddRow("example", "reporting", "SELECT")
+	mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"role_table_grants\" WHERE (.+)\"grantee\" NOT IN \\('admin', 'root'\\)").
+		WillReturnRows(grantResults)
+
+	sc := schema.NewCockroachSchema(mock)
+	result, err := sc.GetGrants(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]*config.Grant{
+		"example": {
+			{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+		},
+	}, result)
+}
+
+func TestCockroach_GetStorages(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	sc := schema.NewCockroachSchema(mock)
+	result, err := sc.GetStorages(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, result)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachConnString(t *testing.T) {
+	assert.Equal(t,
+		"postgresql://root@localhost:26257/playground?sslmode=disable",
+		schema.CockroachConnString("cockroachdb://root@localhost:26257/playground?sslmode=disable"),
+	)
+}
@@ -0,0 +1,934 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v2"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_type"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+var (
+	ErrTableExists      = errors.New("table already exists")
+	ErrTableNotExists   = errors.New("table is not exists")
+	ErrColumnExists     = errors.New("column already exists")
+	ErrColumnNotExists  = errors.New("column is not exists")
+	ErrIndexExists      = errors.New("index already exists")
+	ErrIndexNotExists   = errors.New("index is not exists")
+	ErrPrimaryKeyExists = errors.New("primary key already exists")
+)
+
+// DDLFieldTypeMapper maps the internal type names produced by the parser.
+var DDLFieldTypeMapper = map[string]field_type.FieldType{
+	"bool":        field_type.Boolean,
+	"varchar":     field_type.Varchar,
+	"text":        field_type.Text,
+	"int2":        field_type.SmallInt,
+	"int4":        field_type.Int,
+	"int8":        field_type.BigInt,
+	"float4":      field_type.Float,
+	"float8":      field_type.Float,
+	"numeric":     field_type.Decimal,
+	"json":        field_type.Json,
+	"jsonb":       field_type.Jsonb,
+	"timestamp":   field_type.Timestamp,
+	"timestamptz": field_type.Timestamptz,
+	"serial2":     field_type.SmallSerial,
+	"serial4":     field_type.Serial,
+	"serial8":     field_type.BigSerial,
+}
+
+var serialTypes = map[field_type.FieldType]field_type.FieldType{
+	field_type.SmallInt: field_type.SmallSerial,
+	field_type.Int:      field_type.Serial,
+	field_type.BigInt:   field_type.BigSerial,
+}
+
+// DDLModel is an in-memory model of the tables built by applying
+// CREATE, ALTER, DROP, RENAME and GRANT statements, statements which
+// do not change a table such as SET or CREATE SEQUENCE are ignored. As the
+// crawler reads the default schema only, the relations qualified with
+// another schema are ignored too.
+type DDLModel struct {
+	tables []*ddlTable
+}
+
+type ddlTable struct {
+	schema     *config.Schema
+	primaryKey string
+}
+
+func NewDDLModel() *DDLModel {
+	return &DDLModel{
+		tables: make([]*ddlTable, 0),
+	}
+}
+
+// NewDDLSchema reads a DDL file, such as pg_dump --schema-only output or
+// the full schema migration, and serves its tables without a database.
+func NewDDLSchema(path string) (*staticSchema, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas, err := ParseDDL(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return NewStaticSchema(schemas), nil
+}
+
+// ParseDDL applies the statements to an empty model and returns its tables.
+func ParseDDL(sql string) ([]*config.Schema, error) {
+	model := NewDDLModel()
+	err := model.Apply(sql)
+	if err != nil {
+		return nil, err
+	}
+	return model.Schemas(), nil
+}
+
+// Apply parses the statements and applies them in order.
+func (m *DDLModel) Apply(sql string) error {
+	result, err := pg_query.Parse(stripMetaCommands(sql))
+	if err != nil {
+		return err
+	}
+
+	for _, raw := range result.Stmts {
+		err := m.applyStmt(raw.GetStmt())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stripMetaCommands removes psql meta commands, e.g. \connect, which
+// pg_dump may write but are not SQL.
+func stripMetaCommands(sql string) string {
+	lines := strings.Split(sql, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "\\") {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Schemas returns a copy of the modelled tables in creation order.
+func (m *DDLModel) Schemas() []*config.Schema {
+	schemas := make([]*config.Schema, 0, len(m.tables))
+	for _, table := range m.tables {
+		schemas = append(schemas, table.copy())
+	}
+	return schemas
+}
+
+func (m *DDLModel) applyStmt(stmt *pg_query.Node) error {
+	switch {
+	case stmt.GetCreateStmt() != nil:
+		return m.createTable(stmt.GetCreateStmt())
+	case stmt.GetIndexStmt() != nil:
+		return m.createIndex(stmt.GetIndexStmt())
+	case stmt.GetAlterTableStmt() != nil:
+		return m.alterTable(stmt.GetAlterTableStmt())
+	case stmt.GetDropStmt() != nil:
+		return m.drop(stmt.GetDropStmt())
+	case stmt.GetRenameStmt() != nil:
+		return m.rename(stmt.GetRenameStmt())
+	case stmt.GetGrantStmt() != nil:
+		return m.grant(stmt.GetGrantStmt())
+	}
+	return nil
+}
+
+func (m *DDLModel) getTable(name string) *ddlTable {
+	for _, table := range m.tables {
+		if table.schema.Name == name {
+			return table
+		}
+	}
+	return nil
+}
+
+func (m *DDLModel) findIndex(name string) (*ddlTable, *config.Index) {
+	for _, table := range m.tables {
+		for _, index := range table.schema.Index {
+			if index.Name == name {
+				return table, index
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (m *DDLModel) createTable(stmt *pg_query.CreateStmt) error {
+	if !inDefaultSchema(stmt.GetRelation()) {
+		return nil
+	}
+
+	name := stmt.GetRelation().GetRelname()
+	if m.getTable(name) != nil {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return fmt.Errorf("create table %s: %w", name, ErrTableExists)
+	}
+
+	table := &ddlTable{
+		schema: &config.Schema{
+			Name:   name,
+			Fields: make([]*config.Field, 0),
+			Index:  make([]*config.Index, 0),
+		},
+	}
+
+	constraints := make([]*pg_query.Constraint, 0)
+	for _, elt := range stmt.TableElts {
+		switch {
+		case elt.GetColumnDef() != nil:
+			err := table.addColumn(elt.GetColumnDef())
+			if err != nil {
+				return fmt.Errorf("create table %s: %w", name, err)
+			}
+		case elt.GetConstraint() != nil:
+			constraints = append(constraints, elt.GetConstraint())
+		}
+	}
+	for _, constraint := range stmt.Constraints {
+		constraints = append(constraints, constraint.GetConstraint())
+	}
+
+	for _, constraint := range constraints {
+		err := table.addConstraint(constraint)
+		if err != nil {
+			return fmt.Errorf("create table %s: %w", name, err)
+		}
+	}
+
+	storage := table.getStorage()
+	storage.Unlogged = stmt.GetRelation().GetRelpersistence() == "u"
+	storage.Tablespace = stmt.Tablespacename
+	table.setParameters(stmt.Options)
+	table.cleanStorage()
+
+	m.tables = append(m.tables, table)
+	return nil
+}
+
+func (m *DDLModel) createIndex(stmt *pg_query.IndexStmt) error {
+	if !inDefaultSchema(stmt.GetRelation()) {
+		return nil
+	}
+
+	tableName := stmt.GetRelation().GetRelname()
+	table := m.getTable(tableName)
+	if table == nil {
+		return fmt.Errorf("create index on %s: %w", tableName, ErrTableNotExists)
+	}
+
+	index := &config.Index{
+		Name:   stmt.Idxname,
+		Fields: make([]*config.IndexField, 0),
+		Unique: stmt.Unique,
+	}
+	for _, param := range stmt.IndexParams {
+		elem := param.GetIndexElem()
+		index.Fields = append(index.Fields, &config.IndexField{
+			Column: elem.GetName(),
+			Order:  OrderMapper[elem.Ordering],
+		})
+	}
+	if index.Name == "" {
+		index.Name = fmt.Sprintf("%s_%s_idx", tableName, strings.Join(index.GetColumns(), "_"))
+	}
+
+	if _, existing := m.findIndex(index.Name); existing != nil {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return fmt.Errorf("create index %s: %w", index.Name, ErrIndexExists)
+	}
+
+	table.schema.Index = append(table.schema.Index, index)
+	return nil
+}
+
+func (m *DDLModel) alterTable(stmt *pg_query.AlterTableStmt) error {
+	if stmt.Relkind != pg_query.ObjectType_OBJECT_TABLE || !inDefaultSchema(stmt.GetRelation()) {
+		return nil
+	}
+
+	name := stmt.GetRelation().GetRelname()
+	table := m.getTable(name)
+	if table == nil {
+		if stmt.MissingOk {
+			return nil
+		}
+		return fmt.Errorf("alter table %s: %w", name, ErrTableNotExists)
+	}
+
+	for _, node := range stmt.Cmds {
+		err := table.alter(node.GetAlterTableCmd())
+		if err != nil {
+			return fmt.Errorf("alter table %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (m *DDLModel) drop(stmt *pg_query.DropStmt) error {
+	for _, object := range stmt.Objects {
+		names := object.GetList().GetItems()
+		if len(names) > 1 && names[len(names)-2].GetString_().GetStr() != DefaultSchema {
+			continue
+		}
+		name := lastName(names)
+
+		switch stmt.RemoveType {
+		case pg_query.ObjectType_OBJECT_TABLE:
+			if m.getTable(name) == nil {
+				if stmt.MissingOk {
+					continue
+				}
+				return fmt.Errorf("drop table %s: %w", name, ErrTableNotExists)
+			}
+			m.dropTable(name)
+		case pg_query.ObjectType_OBJECT_INDEX:
+			table, _ := m.findIndex(name)
+			if table == nil {
+				if stmt.MissingOk {
+					continue
+				}
+				return fmt.Errorf("drop index %s: %w", name, ErrIndexNotExists)
+			}
+			table.dropIndex(name)
+		}
+	}
+	return nil
+}
+
+func (m *DDLModel) dropTable(name string) {
+	tables := make([]*ddlTable, 0, len(m.tables))
+	for _, table := range m.tables {
+		if table.schema.Name != name {
+			tables = append(tables, table)
+		}
+	}
+	m.tables = tables
+}
+
+func (m *DDLModel) rename(stmt *pg_query.RenameStmt) error {
+	if !inDefaultSchema(stmt.GetRelation()) {
+		return nil
+	}
+
+	name := stmt.GetRelation().GetRelname()
+
+	switch stmt.RenameType {
+	case pg_query.ObjectType_OBJECT_TABLE:
+		table := m.getTable(name)
+		if table == nil {
+			if stmt.MissingOk {
+				return nil
+			}
+			return fmt.Errorf("rename table %s: %w", name, ErrTableNotExists)
+		}
+		if m.getTable(stmt.Newname) != nil {
+			return fmt.Errorf("rename table %s: %w", stmt.Newname, ErrTableExists)
+		}
+		table.schema.Name = stmt.Newname
+	case pg_query.ObjectType_OBJECT_COLUMN:
+		table := m.getTable(name)
+		if table == nil {
+			if stmt.MissingOk {
+				return nil
+			}
+			return fmt.Errorf("rename column on %s: %w", name, ErrTableNotExists)
+		}
+		err := table.renameColumn(stmt.Subname, stmt.Newname)
+		if err != nil {
+			return fmt.Errorf("rename column on %s: %w", name, err)
+		}
+	case pg_query.ObjectType_OBJECT_INDEX:
+		_, index := m.findIndex(name)
+		if index == nil {
+			if stmt.MissingOk {
+				return nil
+			}
+			return fmt.Errorf("rename index %s: %w", name, ErrIndexNotExists)
+		}
+		index.Name = stmt.Newname
+	case pg_query.ObjectType_OBJECT_TABCONSTRAINT:
+		table := m.getTable(name)
+		if table == nil {
+			return fmt.Errorf("rename constraint on %s: %w", name, ErrTableNotExists)
+		}
+		if table.primaryKey == stmt.Subname {
+			table.primaryKey = stmt.Newname
+		} else if index := table.getIndex(stmt.Subname); index != nil {
+			index.Name = stmt.Newname
+		}
+	}
+	return nil
+}
+
+func (m *DDLModel) grant(stmt *pg_query.GrantStmt) error {
+	if stmt.Targtype != pg_query.GrantTargetType_ACL_TARGET_OBJECT ||
+		stmt.Objtype != pg_query.ObjectType_OBJECT_TABLE {
+		return nil
+	}
+
+	privileges := privilege.SupportedPrivilege
+	if len(stmt.Privileges) > 0 {
+		privileges = make([]privilege.Privilege, 0, len(stmt.Privileges))
+		for _, node := range stmt.Privileges {
+			priv, err := privilege.Parse(node.GetAccessPriv().GetPrivName())
+			if err != nil {
+				continue
+			}
+			privileges = append(privileges, priv)
+		}
+	}
+
+	for _, object := range stmt.Objects {
+		if !inDefaultSchema(object.GetRangeVar()) {
+			continue
+		}
+
+		name := object.GetRangeVar().GetRelname()
+		table := m.getTable(name)
+		if table == nil {
+			return fmt.Errorf("grant on %s: %w", name, ErrTableNotExists)
+		}
+
+		for _, grantee := range stmt.Grantees {
+			role := grantee.GetRoleSpec().GetRolename()
+			if grantee.GetRoleSpec().GetRoletype() == pg_query.RoleSpecType_ROLESPEC_PUBLIC {
+				role = "PUBLIC"
+			}
+
+			if stmt.IsGrant {
+				table.schema.AddGrants(&config.Grant{Role: role, Privileges: privileges})
+			} else {
+				table.revoke(role, privileges)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *ddlTable) copy() *config.Schema {
+	sc := &config.Schema{
+		Name:   t.schema.Name,
+		Fields: make([]*config.Field, 0, len(t.schema.Fields)),
+		Index:  make([]*config.Index, 0, len(t.schema.Index)),
+	}
+
+	if t.schema.Storage != nil {
+		storage := *t.schema.Storage
+		if t.schema.Storage.Autovacuum != nil {
+			storage.Autovacuum = make(map[string]interface{}, len(t.schema.Storage.Autovacuum))
+			for name, value := range t.schema.Storage.Autovacuum {
+				storage.Autovacuum[name] = value
+			}
+		}
+		sc.Storage = &storage
+	}
+
+	for _, field := range t.schema.Fields {
+		f := *field
+		f.Options = make([]field_option.FieldOption, 0)
+		if hasOption(field, field_option.PrimaryKey) {
+			f.Options = append(f.Options, field_option.PrimaryKey)
+		}
+		if field.IsNotNull() {
+			f.Options = append(f.Options, field_option.NotNull)
+		}
+		sc.Fields = append(sc.Fields, &f)
+	}
+
+	for _, index := range t.schema.Index {
+		idx := *index
+		idx.Fields = append([]*config.IndexField{}, index.Fields...)
+		sc.Index = append(sc.Index, &idx)
+	}
+
+	for _, grant := range t.schema.Grants {
+		sc.Grants = append(sc.Grants, &config.Grant{
+			Role:       grant.Role,
+			Privileges: append([]privilege.Privilege{}, grant.Privileges...),
+		})
+	}
+
+	return sc
+}
+
+func (t *ddlTable) getIndex(name string) *config.Index {
+	for _, index := range t.schema.Index {
+		if index.Name == name {
+			return index
+		}
+	}
+	return nil
+}
+
+func (t *ddlTable) dropIndex(name string) {
+	indices := make([]*config.Index, 0, len(t.schema.Index))
+	for _, index := range t.schema.Index {
+		if index.Name != name {
+			indices = append(indices, index)
+		}
+	}
+	t.schema.Index = indices
+}
+
+func (t *ddlTable) addColumn(def *pg_query.ColumnDef) error {
+	if t.schema.GetField(def.Colname) != nil {
+		return fmt.Errorf("%s: %w", def.Colname, ErrColumnExists)
+	}
+
+	ft, limit, scale := ddlFieldType(def.GetTypeName())
+	field := &config.Field{
+		Name:      def.Colname,
+		Type:      ft,
+		Limit:     limit,
+		Scale:     scale,
+		Options:   make([]field_option.FieldOption, 0),
+		Collation: collationName(def.GetCollClause()),
+	}
+	if def.IsNotNull {
+		field.Options = append(field.Options, field_option.NotNull)
+	}
+	if def.RawDefault != nil {
+		t.setDefault(field, def.RawDefault)
+	}
+
+	t.schema.Fields = append(t.schema.Fields, field)
+
+	for _, node := range def.Constraints {
+		constraint := node.GetConstraint()
+		switch constraint.Contype {
+		case pg_query.ConstrType_CONSTR_NOTNULL:
+			setOption(field, field_option.NotNull)
+		case pg_query.ConstrType_CONSTR_NULL:
+			removeOption(field, field_option.NotNull)
+		case pg_query.ConstrType_CONSTR_DEFAULT:
+			t.setDefault(field, constraint.RawExpr)
+		case pg_query.ConstrType_CONSTR_PRIMARY, pg_query.ConstrType_CONSTR_UNIQUE:
+			constraint.Keys = []*pg_query.Node{pg_query.MakeStrNode(field.Name)}
+			err := t.addConstraint(constraint)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *ddlTable) addConstraint(constraint *pg_query.Constraint) error {
+	columns := make([]string, 0, len(constraint.Keys))
+	for _, key := range constraint.Keys {
+		columns = append(columns, key.GetString_().GetStr())
+	}
+
+	switch constraint.Contype {
+	case pg_query.ConstrType_CONSTR_PRIMARY:
+		if t.primaryKey != "" {
+			return ErrPrimaryKeyExists
+		}
+
+		t.primaryKey = constraint.Conname
+		if t.primaryKey == "" {
+			t.primaryKey = t.schema.Name + "_pkey"
+		}
+
+		for _, column := range columns {
+			field := t.schema.GetField(column)
+			if field == nil {
+				return fmt.Errorf("%s: %w", column, ErrColumnNotExists)
+			}
+			setOption(field, field_option.PrimaryKey)
+			setOption(field, field_option.NotNull)
+		}
+	case pg_query.ConstrType_CONSTR_UNIQUE:
+		// unique constraints are backed by an index, as reported by pg_indexes
+		index := &config.Index{
+			Name:   constraint.Conname,
+			Fields: make([]*config.IndexField, 0, len(columns)),
+			Unique: true,
+		}
+		for _, column := range columns {
+			index.Fields = append(index.Fields, &config.IndexField{
+				Column: column,
+				Order:  OrderMapper[pg_query.SortByDir_SORTBY_DEFAULT],
+			})
+		}
+		if index.Name == "" {
+			index.Name = fmt.Sprintf("%s_%s_key", t.schema.Name, strings.Join(columns, "_"))
+		}
+		if t.getIndex(index.Name) != nil {
+			return fmt.Errorf("%s: %w", index.Name, ErrIndexExists)
+		}
+		t.schema.Index = append(t.schema.Index, index)
+	}
+	return nil
+}
+
+func (t *ddlTable) dropConstraint(name string) {
+	if name != t.primaryKey {
+		t.dropIndex(name)
+		return
+	}
+
+	t.primaryKey = ""
+	for _, field := range t.schema.Fields {
+		removeOption(field, field_option.PrimaryKey)
+	}
+}
+
+func (t *ddlTable) alter(cmd *pg_query.AlterTableCmd) error {
+	if cmd.Subtype == pg_query.AlterTableType_AT_AddColumn {
+		def := cmd.GetDef().GetColumnDef()
+		if cmd.MissingOk && t.schema.GetField(def.Colname) != nil {
+			return nil
+		}
+		return t.addColumn(def)
+	}
+
+	if cmd.Subtype == pg_query.AlterTableType_AT_AddConstraint {
+		return t.addConstraint(cmd.GetDef().GetConstraint())
+	}
+
+	switch cmd.Subtype {
+	case pg_query.AlterTableType_AT_DropConstraint:
+		t.dropConstraint(cmd.Name)
+		return nil
+	case pg_query.AlterTableType_AT_SetRelOptions:
+		t.setParameters(cmd.GetDef().GetList().GetItems())
+		t.cleanStorage()
+		return nil
+	case pg_query.AlterTableType_AT_ResetRelOptions:
+		t.resetParameters(cmd.GetDef().GetList().GetItems())
+		t.cleanStorage()
+		return nil
+	case pg_query.AlterTableType_AT_SetTableSpace:
+		t.getStorage().Tablespace = cmd.Name
+		t.cleanStorage()
+		return nil
+	case pg_query.AlterTableType_AT_SetLogged, pg_query.AlterTableType_AT_SetUnLogged:
+		t.getStorage().Unlogged = cmd.Subtype == pg_query.AlterTableType_AT_SetUnLogged
+		t.cleanStorage()
+		return nil
+	}
+
+	field := t.schema.GetField(cmd.Name)
+
+	switch cmd.Subtype {
+	case pg_query.AlterTableType_AT_DropColumn,
+		pg_query.AlterTableType_AT_AlterColumnType,
+		pg_query.AlterTableType_AT_SetNotNull,
+		pg_query.AlterTableType_AT_DropNotNull,
+		pg_query.AlterTableType_AT_ColumnDefault:
+		if field == nil {
+			if cmd.MissingOk {
+				return nil
+			}
+			return fmt.Errorf("%s: %w", cmd.Name, ErrColumnNotExists)
+		}
+	default:
+		return nil
+	}
+
+	switch cmd.Subtype {
+	case pg_query.AlterTableType_AT_DropColumn:
+		t.dropColumn(cmd.Name)
+	case pg_query.AlterTableType_AT_AlterColumnType:
+		def := cmd.GetDef().GetColumnDef()
+		field.Type, field.Limit, field.Scale = ddlFieldType(def.GetTypeName())
+		if def.GetCollClause() != nil {
+			field.Collation = collationName(def.GetCollClause())
+		}
+	case pg_query.AlterTableType_AT_SetNotNull:
+		setOption(field, field_option.NotNull)
+	case pg_query.AlterTableType_AT_DropNotNull:
+		removeOption(field, field_option.NotNull)
+	case pg_query.AlterTableType_AT_ColumnDefault:
+		field.Default = nil
+		if cmd.Def != nil {
+			t.setDefault(field, cmd.Def)
+		}
+	}
+	return nil
+}
+
+// dropColumn removes the column along with the indices using it.
+func (t *ddlTable) dropColumn(name string) {
+	fields := make([]*config.Field, 0, len(t.schema.Fields))
+	for _, field := range t.schema.Fields {
+		if field.Name == name {
+			if hasOption(field, field_option.PrimaryKey) {
+				t.primaryKey = ""
+			}
+			continue
+		}
+		fields = append(fields, field)
+	}
+	t.schema.Fields = fields
+
+	indices := make([]*config.Index, 0, len(t.schema.Index))
+	for _, index := range t.schema.Index {
+		if !containsString(index.GetColumns(), name) {
+			indices = append(indices, index)
+		}
+	}
+	t.schema.Index = indices
+}
+
+func (t *ddlTable) renameColumn(from, to string) error {
+	field := t.schema.GetField(from)
+	if field == nil {
+		return fmt.Errorf("%s: %w", from, ErrColumnNotExists)
+	}
+	if t.schema.GetField(to) != nil {
+		return fmt.Errorf("%s: %w", to, ErrColumnExists)
+	}
+
+	field.Name = to
+	for _, index := range t.schema.Index {
+		for _, indexField := range index.Fields {
+			if indexField.Column == from {
+				indexField.Column = to
+			}
+		}
+	}
+	return nil
+}
+
+// setDefault sets the column default, a nextval() default turns the
+// integer column into its serial type as pg_dump splits serial columns.
+func (t *ddlTable) setDefault(field *config.Field, expr *pg_query.Node) {
+	value, autoIncrement := defaultValue(expr)
+	if autoIncrement {
+		if serial, ok := serialTypes[field.Type]; ok {
+			field.Type = serial
+		}
+		field.Default = nil
+		return
+	}
+	field.Default = value
+}
+
+func (t *ddlTable) revoke(role string, privileges []privilege.Privilege) {
+	grants := make([]*config.Grant, 0, len(t.schema.Grants))
+	for _, grant := range t.schema.Grants {
+		if grant.Role == role {
+			remaining := make([]privilege.Privilege, 0, len(grant.Privileges))
+			for _, priv := range grant.Privileges {
+				if !containsPrivilege(privileges, priv) {
+					remaining = append(remaining, priv)
+				}
+			}
+			if len(remaining) == 0 {
+				continue
+			}
+			grant.Privileges = remaining
+		}
+		grants = append(grants, grant)
+	}
+	t.schema.Grants = grants
+}
+
+func (t *ddlTable) getStorage() *config.Storage {
+	if t.schema.Storage == nil {
+		t.schema.Storage = &config.Storage{}
+	}
+	return t.schema.Storage
+}
+
+// cleanStorage unsets the storage when nothing differs from the defaults.
+func (t *ddlTable) cleanStorage() {
+	storage := t.schema.Storage
+	if storage == nil {
+		return
+	}
+
+	if storage.Fillfactor == 0 && len(storage.Autovacuum) == 0 &&
+		storage.Tablespace == "" && !storage.Unlogged {
+		t.schema.Storage = nil
+	}
+}
+
+func (t *ddlTable) setParameters(options []*pg_query.Node) {
+	for _, node := range options {
+		def := node.GetDefElem()
+		value := defElemValue(def)
+
+		switch {
+		case def.Defname == "fillfactor":
+			t.getStorage().Fillfactor, _ = strconv.Atoi(value)
+		case strings.HasPrefix(def.Defname, config.AutovacuumParameterPrefix):
+			storage := t.getStorage()
+			if storage.Autovacuum == nil {
+				storage.Autovacuum = make(map[string]interface{})
+			}
+			storage.Autovacuum[strings.TrimPrefix(def.Defname, config.AutovacuumParameterPrefix)] = value
+		}
+	}
+}
+
+func (t *ddlTable) resetParameters(options []*pg_query.Node) {
+	storage := t.getStorage()
+	for _, node := range options {
+		name := node.GetDefElem().GetDefname()
+
+		switch {
+		case name == "fillfactor":
+			storage.Fillfactor = 0
+		case strings.HasPrefix(name, config.AutovacuumParameterPrefix):
+			delete(storage.Autovacuum, strings.TrimPrefix(name, config.AutovacuumParameterPrefix))
+		}
+	}
+	if len(storage.Autovacuum) == 0 {
+		storage.Autovacuum = nil
+	}
+}
+
+func defElemValue(def *pg_query.DefElem) string {
+	arg := def.GetArg()
+	switch {
+	case arg.GetInteger() != nil:
+		return strconv.Itoa(int(arg.GetInteger().Ival))
+	case arg.GetFloat() != nil:
+		return arg.GetFloat().Str
+	case arg.GetString_() != nil:
+		return arg.GetString_().Str
+	case arg.GetTypeName() != nil:
+		return lastName(arg.GetTypeName().Names)
+	}
+	return ""
+}
+
+// ddlFieldType returns the field type, limit and scale of a column type.
+func ddlFieldType(typeName *pg_query.TypeName) (field_type.FieldType, int, int) {
+	name := lastName(typeName.GetNames())
+	ft, ok := DDLFieldTypeMapper[name]
+	if !ok {
+		ft = field_type.ParseString(name)
+	}
+
+	mods := make([]int, 0, len(typeName.GetTypmods()))
+	for _, mod := range typeName.GetTypmods() {
+		mods = append(mods, int(mod.GetAConst().GetVal().GetInteger().GetIval()))
+	}
+
+	limit, scale := 0, 0
+	if ft.HasLimit() && len(mods) > 0 {
+		limit = mods[0]
+	}
+	if ft.HasScale() && len(mods) > 1 {
+		scale = mods[1]
+	}
+	return ft, limit, scale
+}
+
+// defaultValue returns the value of a default expression and whether it is
+// a sequence, expressions other than constants have no value.
+func defaultValue(expr *pg_query.Node) (interface{}, bool) {
+	switch {
+	case expr.GetAConst() != nil:
+		val := expr.GetAConst().GetVal()
+		switch {
+		case val.GetInteger() != nil:
+			return int(val.GetInteger().Ival), false
+		case val.GetFloat() != nil:
+			valFloat, err := strconv.ParseFloat(val.GetFloat().Str, 64)
+			if err != nil {
+				return nil, false
+			}
+			return valFloat, false
+		case val.GetString_() != nil:
+			return val.GetString_().Str, false
+		}
+	case expr.GetTypeCast() != nil:
+		cast := expr.GetTypeCast()
+		value, autoIncrement := defaultValue(cast.GetArg())
+		if lastName(cast.GetTypeName().GetNames()) == "bool" {
+			if str, ok := value.(string); ok {
+				valBool, err := strconv.ParseBool(str)
+				if err == nil {
+					return valBool, false
+				}
+			}
+		}
+		return value, autoIncrement
+	case expr.GetFuncCall() != nil:
+		return nil, lastName(expr.GetFuncCall().GetFuncname()) == "nextval"
+	}
+	return nil, false
+}
+
+func collationName(clause *pg_query.CollateClause) string {
+	if clause == nil {
+		return ""
+	}
+	return lastName(clause.Collname)
+}
+
+// inDefaultSchema tells whether the relation is unqualified or qualified with
+// the default schema, the only one crawled.
+func inDefaultSchema(relation *pg_query.RangeVar) bool {
+	schema := relation.GetSchemaname()
+	return schema == "" || schema == DefaultSchema
+}
+
+// lastName returns the unqualified name of a qualified name list.
+func lastName(names []*pg_query.Node) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return names[len(names)-1].GetString_().GetStr()
+}
+
+func setOption(field *config.Field, option field_option.FieldOption) {
+	if !hasOption(field, option) {
+		field.Options = append(field.Options, option)
+	}
+}
+
+func removeOption(field *config.Field, option field_option.FieldOption) {
+	options := make([]field_option.FieldOption, 0, len(field.Options))
+	for _, opt := range field.Options {
+		if opt != option {
+			options = append(options, opt)
+		}
+	}
+	field.Options = options
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPrivilege(privileges []privilege.Privilege, priv privilege.Privilege) bool {
+	for _, p := range privileges {
+		if p == priv {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,342 @@
+package schema_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+func TestParseDDL_FullSchemaMigration(t *testing.T) {
+	result, err := schema.ParseDDL(`CREATE TABLE IF NOT EXISTS "users" (
+	"id" BIGSERIAL PRIMARY KEY,
+	"name" VARCHAR(255) NOT NULL DEFAULT 'Alfred',
+	"school" VARCHAR(100) NULL COLLATE "C",
+	"salary" DECIMAL(5, 2) DEFAULT 100.5,
+	"age" INT DEFAULT -1,
+	"active" BOOLEAN DEFAULT TRUE,
+	"created_at" TIMESTAMPTZ
+) WITH (fillfactor = 70, autovacuum_vacuum_scale_factor = 0.05);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "index_users_on_name" ON "users"("name" ASC, "school" DESC);
+
+GRANT SELECT, INSERT ON "users" TO "reporting";`)
+	assert.Nil(t, err)
+	assert.Equal(t, []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{
+					Name:    "id",
+					Type:    "bigserial",
+					Options: []field_option.FieldOption{field_option.PrimaryKey, field_option.NotNull},
+				},
+				{
+					Name:    "name",
+					Type:    "varchar",
+					Limit:   255,
+					Default: "Alfred",
+					Options: []field_option.FieldOption{field_option.NotNull},
+				},
+				{
+					Name:      "school",
+					Type:      "varchar",
+					Limit:     100,
+					Options:   []field_option.FieldOption{},
+					Collation: "C",
+				},
+				{
+					Name:    "salary",
+					Type:    "decimal",
+					Limit:   5,
+					Scale:   2,
+					Default: 100.5,
+					Options: []field_option.FieldOption{},
+				},
+				{
+					Name:    "age",
+					Type:    "int",
+					Default: -1,
+					Options: []field_option.FieldOption{},
+				},
+				{
+					Name:    "active",
+					Type:    "bool",
+					Default: true,
+					Options: []field_option.FieldOption{},
+				},
+				{
+					Name:    "created_at",
+					Type:    "timestamptz",
+					Options: []field_option.FieldOption{},
+				},
+			},
+			Index: []*config.Index{
+				{
+					Name: "index_users_on_name",
+					Fields: []*config.IndexField{
+						{Column: "name", Order: "ASC"},
+						{Column: "school", Order: "DESC"},
+					},
+					Unique: true,
+				},
+			},
+			Grants: []*config.Grant{
+				{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select, privilege.Insert}},
+			},
+			Storage: &config.Storage{
+				Fillfactor: 70,
+				Autovacuum: map[string]interface{}{"vacuum_scale_factor": "0.05"},
+			},
+		},
+	}, result)
+}
+
+func TestParseDDL_PgDump(t *testing.T) {
+	result, err := schema.ParseDDL(`--
+-- PostgreSQL database dump
+--
+\restrict abc
+
+SET statement_timeout = 0;
+SELECT pg_catalog.set_config('search_path', '', false);
+
+CREATE TABLE public.users (
+    id bigint NOT NULL,
+    email character varying(100) NOT NULL,
+    note text DEFAULT 'it''s'::text
+);
+
+ALTER TABLE public.users OWNER TO postgres;
+
+CREATE SEQUENCE public.users_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
+
+ALTER TABLE ONLY public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq'::regclass);
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_email_key UNIQUE (email);
+
+CREATE INDEX index_users_on_note ON public.users USING btree (note DESC);
+
+CREATE TABLE public.schema_migrations (
+    version bigint NOT NULL,
+    dirty boolean NOT NULL
+);
+
+GRANT ALL ON TABLE public.users TO admin;
+REVOKE TRUNCATE, TRIGGER, REFERENCES ON TABLE public.users FROM admin;
+`)
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+
+	users := result[0]
+	assert.Equal(t, []*config.Field{
+		{
+			Name:    "id",
+			Type:    "bigserial",
+			Options: []field_option.FieldOption{field_option.PrimaryKey, field_option.NotNull},
+		},
+		{
+			Name:    "email",
+			Type:    "varchar",
+			Limit:   100,
+			Options: []field_option.FieldOption{field_option.NotNull},
+		},
+		{
+			Name:    "note",
+			Type:    "text",
+			Default: "it's",
+			Options: []field_option.FieldOption{},
+		},
+	}, users.Fields)
+	assert.Equal(t, []*config.Index{
+		{
+			Name:   "users_email_key",
+			Fields: []*config.IndexField{{Column: "email", Order: "ASC"}},
+			Unique: true,
+		},
+		{
+			Name:   "index_users_on_note",
+			Fields: []*config.IndexField{{Column: "note", Order: "DESC"}},
+		},
+	}, users.Index)
+	assert.Equal(t, []*config.Grant{
+		{
+			Role: "admin",
+			Privileges: []privilege.Privilege{
+				privilege.Select, privilege.Insert, privilege.Update, privilege.Delete,
+			},
+		},
+	}, users.Grants)
+	assert.Nil(t, users.Storage)
+
+	// migration history is not part of the schema
+	crawler := schema.NewStaticSchema(result)
+	tables, err := crawler.GetTables(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"users"}, tables)
+}
+
+func TestParseDDL_Alter(t *testing.T) {
+	result, err := schema.ParseDDL(`
+CREATE TABLE "users" ("id" BIGSERIAL PRIMARY KEY, "name" VARCHAR(100), "age" INT);
+CREATE INDEX "index_users_on_age" ON "users"("age");
+CREATE UNLOGGED TABLE "logs" ("id" BIGINT);
+
+ALTER TABLE "users" ADD COLUMN "email" VARCHAR(100) NOT NULL, DROP COLUMN "age";
+ALTER TABLE "users" ALTER COLUMN "name" TYPE TEXT, ALTER COLUMN "name" SET NOT NULL, ALTER COLUMN "name" SET DEFAULT 'x';
+ALTER TABLE "users" RENAME COLUMN "email" TO "mail";
+ALTER TABLE "users" SET (fillfactor = 80);
+ALTER TABLE "users" SET TABLESPACE "fast_ssd";
+ALTER TABLE "users" RESET (fillfactor);
+ALTER TABLE "logs" SET LOGGED;
+ALTER TABLE "logs" RENAME TO "audit_logs";
+DROP TABLE IF EXISTS "missing";
+`)
+	assert.Nil(t, err)
+	assert.Equal(t, []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{
+					Name:    "id",
+					Type:    "bigserial",
+					Options: []field_option.FieldOption{field_option.PrimaryKey, field_option.NotNull},
+				},
+				{
+					Name:    "name",
+					Type:    "text",
+					Default: "x",
+					Options: []field_option.FieldOption{field_option.NotNull},
+				},
+				{
+					Name:    "mail",
+					Type:    "varchar",
+					Limit:   100,
+					Options: []field_option.FieldOption{field_option.NotNull},
+				},
+			},
+			Index:   []*config.Index{},
+			Storage: &config.Storage{Tablespace: "fast_ssd"},
+		},
+		{
+			Name: "audit_logs",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigint", Options: []field_option.FieldOption{}},
+			},
+			Index: []*config.Index{},
+		},
+	}, result)
+}
+
+func TestParseDDL_OtherSchemas(t *testing.T) {
+	result, err := schema.ParseDDL(`
+CREATE TABLE public.users (id bigint NOT NULL, name text);
+CREATE TABLE audit.users (id bigint NOT NULL, action text);
+CREATE TABLE audit.events (id bigint);
+CREATE INDEX index_users_on_action ON audit.users USING btree (action);
+ALTER TABLE ONLY audit.users ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+ALTER TABLE audit.events RENAME TO logs;
+GRANT SELECT ON TABLE audit.users TO reporting;
+DROP TABLE audit.users;
+DROP INDEX audit.index_users_on_action;
+`)
+	assert.Nil(t, err)
+	assert.Equal(t, []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigint", Options: []field_option.FieldOption{field_option.PrimaryKey, field_option.NotNull}},
+				{Name: "name", Type: "text", Options: []field_option.FieldOption{}},
+			},
+			Index: []*config.Index{},
+		},
+	}, result)
+}
+
+func TestDDLModel_SchemasCopyStorage(t *testing.T) {
+	model := schema.NewDDLModel()
+	err := model.Apply(`CREATE TABLE "users" ("id" BIGINT) WITH (fillfactor = 70, autovacuum_vacuum_threshold = 100);`)
+	assert.Nil(t, err)
+
+	result := model.Schemas()
+	result[0].Storage.Fillfactor = 50
+	result[0].Storage.Autovacuum["vacuum_threshold"] = 10
+
+	assert.Equal(t, &config.Storage{
+		Fillfactor: 70,
+		Autovacuum: map[string]interface{}{"vacuum_threshold": "100"},
+	}, model.Schemas()[0].Storage)
+}
+
+func TestParseDDL_Error(t *testing.T) {
+	testCases := []struct {
+		sql string
+		err error
+	}{
+		{
+			sql: `CREATE TABLE "users" ("id" INT); CREATE TABLE "users" ("id" INT);`,
+			err: schema.ErrTableExists,
+		},
+		{
+			sql: `ALTER TABLE "users" ADD COLUMN "id" INT;`,
+			err: schema.ErrTableNotExists,
+		},
+		{
+			sql: `CREATE TABLE "users" ("id" INT); ALTER TABLE "users" DROP COLUMN "name";`,
+			err: schema.ErrColumnNotExists,
+		},
+		{
+			sql: `CREATE TABLE "users" ("id" INT); ALTER TABLE "users" ADD COLUMN "id" INT;`,
+			err: schema.ErrColumnExists,
+		},
+		{
+			sql: `CREATE TABLE "users" ("id" INT); DROP INDEX "index_users_on_id";`,
+			err: schema.ErrIndexNotExists,
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := schema.ParseDDL(tc.sql)
+		assert.True(t, errors.Is(err, tc.err), tc.sql)
+	}
+
+	_, err := schema.ParseDDL(`CREATE TABLE "users" (`)
+	assert.NotNil(t, err)
+}
+
+func TestNewDDLSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.sql")
+	err := os.WriteFile(path, []byte(`CREATE TABLE "users" ("id" BIGSERIAL PRIMARY KEY);`), 0644)
+	assert.Nil(t, err)
+
+	crawler, err := schema.NewDDLSchema(path)
+	assert.Nil(t, err)
+
+	primaryKeys, err := crawler.GetPrimaryKeys(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]*schema.PrimaryKey{
+		"users": {Table: "users", Column: "id"},
+	}, primaryKeys)
+
+	_, err = schema.NewDDLSchema(filepath.Join(t.TempDir(), "missing.sql"))
+	assert.NotNil(t, err)
+}
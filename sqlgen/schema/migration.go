@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	UpMigrationSuffix   = ".up.sql"
+	DownMigrationSuffix = ".down.sql"
+)
+
+// ErrUnsupportedLayout is returned for the migration directories written in
+// the layout of another tool than golang-migrate, whose history cannot be
+// read back.
+var ErrUnsupportedLayout = errors.New("migration directory is not in the golang-migrate layout")
+
+// MigrationError reports the migration file which cannot be applied on top
+// of the migrations before it, e.g. after it or an earlier one was hand edited.
+type MigrationError struct {
+	File string
+	Err  error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migration %s diverges from the migration history: %s", e.File, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// NewMigrationSchema replays every up migration of the directory in version
+// order and serves the resulting tables without a database.
+func NewMigrationSchema(dir string) (*staticSchema, error) {
+	files, err := MigrationFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	model := NewDDLModel()
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		err = model.Apply(string(b))
+		if err != nil {
+			return nil, &MigrationError{File: filepath.Base(file), Err: err}
+		}
+	}
+
+	return NewStaticSchema(model.Schemas()), nil
+}
+
+// MigrationFiles returns the up migrations of the directory sorted by version,
+// a missing directory has no migrations yet. Any other .sql file than the up
+// and down migrations means the directory is in the layout of another tool.
+func MigrationFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make([]string, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".sql" || strings.HasSuffix(name, DownMigrationSuffix) {
+			continue
+		}
+		if !strings.HasSuffix(name, UpMigrationSuffix) {
+			return nil, fmt.Errorf("%w: %s is neither an up nor a down migration", ErrUnsupportedLayout, name)
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return versionLess(files[i], files[j])
+	})
+	return files, nil
+}
+
+// MigrationVersion returns the version prefix of 20060102150405_name.up.sql.
+func MigrationVersion(file string) string {
+	version, _, _ := strings.Cut(filepath.Base(file), "_")
+	return version
+}
+
+func versionLess(a, b string) bool {
+	va, vb := MigrationVersion(a), MigrationVersion(b)
+	if len(va) != len(vb) {
+		return len(va) < len(vb)
+	}
+	return va < vb
+}
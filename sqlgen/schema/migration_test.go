@@ -0,0 +1,124 @@
+package schema_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+)
+
+func writeMigrations(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		assert.Nil(t, err)
+	}
+	return dir
+}
+
+func TestNewMigrationSchema(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"20230102000000_add_email.up.sql": `BEGIN;
+ALTER TABLE "users" ADD COLUMN "email" VARCHAR(100) NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "index_users_on_email" ON "users"("email" ASC);
+COMMIT;`,
+		"20230102000000_add_email.down.sql": `ALTER TABLE "users" DROP COLUMN "email";`,
+		"20230101000000_users.up.sql": `BEGIN;
+CREATE TABLE IF NOT EXISTS "users" ("id" BIGSERIAL PRIMARY KEY);
+CREATE TABLE IF NOT EXISTS "logs" ("id" BIGINT);
+COMMIT;`,
+		"20230103000000_drop_logs.up.sql": `DROP TABLE IF EXISTS "logs";`,
+		"README.md":                       "not a migration",
+	})
+
+	crawler, err := schema.NewMigrationSchema(dir)
+	assert.Nil(t, err)
+
+	result, err := crawler.GetSchemas(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{
+					Name:    "id",
+					Type:    "bigserial",
+					Options: []field_option.FieldOption{field_option.PrimaryKey, field_option.NotNull},
+				},
+				{
+					Name:    "email",
+					Type:    "varchar",
+					Limit:   100,
+					Options: []field_option.FieldOption{field_option.NotNull},
+				},
+			},
+			Index: []*config.Index{
+				{
+					Name:   "index_users_on_email",
+					Fields: []*config.IndexField{{Column: "email", Order: "ASC"}},
+					Unique: true,
+				},
+			},
+		},
+	}, result)
+}
+
+func TestNewMigrationSchema_Diverged(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"20230101000000_users.up.sql":       `CREATE TABLE "users" ("id" BIGSERIAL PRIMARY KEY);`,
+		"20230102000000_rename.up.sql":      `ALTER TABLE "users" RENAME COLUMN "name" TO "full_name";`,
+		"20230103000000_add_email.up.sql":   `ALTER TABLE "users" ADD COLUMN "email" TEXT;`,
+		"20230103000000_add_email.down.sql": `ALTER TABLE "users" DROP COLUMN "email";`,
+	})
+
+	_, err := schema.NewMigrationSchema(dir)
+
+	var migrationErr *schema.MigrationError
+	assert.True(t, errors.As(err, &migrationErr))
+	assert.Equal(t, "20230102000000_rename.up.sql", migrationErr.File)
+	assert.True(t, errors.Is(err, schema.ErrColumnNotExists))
+}
+
+func TestNewMigrationSchema_MissingDir(t *testing.T) {
+	crawler, err := schema.NewMigrationSchema(filepath.Join(t.TempDir(), "migration"))
+	assert.Nil(t, err)
+
+	result, err := crawler.GetSchemas(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, result)
+}
+
+func TestMigrationFiles(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"20230102000000_b.up.sql": "",
+		"20230101000000_a.up.sql": "",
+		"9_legacy.up.sql":         "",
+		"9_legacy.down.sql":       "",
+	})
+
+	files, err := schema.MigrationFiles(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "9_legacy.up.sql"),
+		filepath.Join(dir, "20230101000000_a.up.sql"),
+		filepath.Join(dir, "20230102000000_b.up.sql"),
+	}, files)
+}
+
+func TestMigrationFiles_UnsupportedLayout(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"20230101000000_users.up.sql":   "",
+		"20230101000000_users.down.sql": "",
+		"20230102000000_email.sql":      "-- +goose Up",
+	})
+
+	_, err := schema.MigrationFiles(dir)
+	assert.True(t, errors.Is(err, schema.ErrUnsupportedLayout))
+	assert.EqualError(t, err, "migration directory is not in the golang-migrate layout: 20230102000000_email.sql is neither an up nor a down migration")
+}
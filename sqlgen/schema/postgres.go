@@ -4,21 +4,25 @@ import (
 	"context"
 	"errors"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/jackc/pgx/v4"
 	pg_query "github.com/pganalyze/pg_query_go/v2"
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
 	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
 	"gitlab.com/wartek-id/core/tools/dbgen/types/field_type"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
 
 	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
 )
 
 const (
-	SchemaMigrationTable = "schema_migrations"
+	SchemaMigrationTable = filter.DefaultMigrationTable
 )
 
 type PgInterface interface {
@@ -50,42 +54,145 @@ type postgresSchema struct {
 	pool   PgInterface
 	schema string
 
+	// concurrency is the number of catalog queries run at the same time,
+	// only a pool can serve more than one.
+	concurrency int
+
+	// tableFilter selects the crawled tables, nil skips the migration table only.
+	tableFilter *filter.TableFilter
+
+	// quirks of postgres compatible databases
+	autoIncrementPattern string
+	skipHiddenColumns    bool
+	excludedGrantees     []string
+	skipStorages         bool
+
+	tablesLoaded bool
+	tables       []string
+
+	columnsLoaded bool
+	columns       map[string][]*TableStructure
+
 	indicesLoaded bool
 	indices       map[string]*Indices
+	primaryKeys   map[string]*PrimaryKey
+
+	grantsLoaded bool
+	grants       map[string][]*config.Grant
 
-	primaryKeysLoaded bool
-	primaryKeys       map[string]*PrimaryKey
+	storagesLoaded bool
+	storages       map[string]*config.Storage
 }
 
 func NewPostgresSchema(pool PgInterface) *postgresSchema {
 	return &postgresSchema{
-		pool:   pool,
-		schema: DefaultSchema,
+		pool:                 pool,
+		schema:               DefaultSchema,
+		concurrency:          1,
+		autoIncrementPattern: RegexAutoIncrement,
 	}
 }
 
-func (s *postgresSchema) GetSchemas() ([]*config.Schema, error) {
-	schemas := make([]*config.Schema, 0)
+// Close closes the pool the schema was crawled with, if it can be closed.
+func (s *postgresSchema) Close() error {
+	if closer, ok := s.pool.(interface{ Close() }); ok {
+		closer.Close()
+	}
+	return nil
+}
+
+// WithTableFilter only crawls the tables matched by the filter.
+func (s *postgresSchema) WithTableFilter(f *filter.TableFilter) *postgresSchema {
+	s.tableFilter = f
+	return s
+}
 
-	tables, err := s.GetTables()
+// WithConcurrency runs up to n catalog queries at the same time, the pool
+// must allow as many connections.
+func (s *postgresSchema) WithConcurrency(n int) *postgresSchema {
+	if n < 1 {
+		n = 1
+	}
+	s.concurrency = n
+	return s
+}
+
+// Load fetches the whole catalog, every loader issues a single query
+// for all tables regardless of how many tables the schema has.
+func (s *postgresSchema) Load(ctx context.Context) error {
+	loaders := []func(context.Context) error{
+		s.LoadTables,
+		s.LoadColumns,
+		s.LoadIndices,
+		s.LoadGrants,
+		s.LoadStorages,
+	}
+
+	if s.concurrency <= 1 {
+		for _, load := range loaders {
+			err := load(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, len(loaders))
+	sem := make(chan struct{}, s.concurrency)
+	wg := sync.WaitGroup{}
+	for i, load := range loaders {
+		wg.Add(1)
+		go func(i int, load func(context.Context) error) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			errs[i] = load(ctx)
+		}(i, load)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *postgresSchema) GetSchemas(ctx context.Context) ([]*config.Schema, error) {
+	err := s.Load(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, table := range tables {
-		fields, err := s.GetFields(table)
+	schemas := make([]*config.Schema, 0, len(s.tables))
+	for _, table := range s.tables {
+		fields, err := s.GetFields(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		indices, err := s.GetTablesIndices(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		grants, err := s.GetTableGrants(ctx, table)
 		if err != nil {
 			return nil, err
 		}
 
-		indices, err := s.GetTablesIndices(table)
+		storage, err := s.GetTableStorage(ctx, table)
 		if err != nil {
 			return nil, err
 		}
 		schema := &config.Schema{
-			Name:   table,
-			Fields: fields,
-			Index:  indices,
+			Name:    table,
+			Fields:  fields,
+			Index:   indices,
+			Grants:  grants,
+			Storage: storage,
 		}
 
 		schemas = append(schemas, schema)
@@ -94,68 +201,183 @@ func (s *postgresSchema) GetSchemas() ([]*config.Schema, error) {
 	return schemas, nil
 }
 
-func (s *postgresSchema) GetTables() ([]string, error) {
-	query, _, err := goqu.Dialect("postgres").From("information_schema.tables").
-		Where(
-			goqu.C("table_schema").Eq(s.schema),
-			goqu.C("table_type").Eq("BASE TABLE"),
-		).Select("table_name").ToSQL()
+func (s *postgresSchema) GetTables(ctx context.Context) ([]string, error) {
+	err := s.LoadTables(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return s.tables, nil
+}
 
-	tables := make([]string, 0)
-	rows, err := s.pool.Query(context.Background(), query)
+func (s *postgresSchema) LoadTables(ctx context.Context) error {
+	if s.tablesLoaded {
+		return nil
+	}
+
+	query, _, err := goqu.Dialect("postgres").
+		From(goqu.T("pg_class").Schema("pg_catalog").As("c")).
+		Join(
+			goqu.T("pg_namespace").Schema("pg_catalog").As("n"),
+			goqu.On(goqu.I("n.oid").Eq(goqu.I("c.relnamespace"))),
+		).
+		Where(
+			goqu.I("n.nspname").Eq(s.schema),
+			goqu.I("c.relkind").In("r", "p"),
+		).
+		Select("c.relname").
+		Order(goqu.I("c.relname").Asc()).
+		ToSQL()
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
 
+	tables := make([]string, 0)
 	for rows.Next() {
 		var table string
 		err := rows.Scan(&table)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		if table == SchemaMigrationTable {
+		if !s.tableFilter.Match(table) {
 			continue
 		}
 
 		tables = append(tables, table)
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-	return tables, nil
+	s.tables = tables
+	s.tablesLoaded = true
+	return nil
 }
 
-func (s *postgresSchema) GetFields(name string) ([]*config.Field, error) {
-	query, _, err := goqu.Dialect("postgres").From("information_schema.columns").
-		Where(
-			goqu.C("table_schema").Eq(s.schema),
-			goqu.C("table_name").Eq(name),
-		).Select(
-		"column_name", "column_default", "is_nullable",
-		"data_type", "character_maximum_length", "numeric_precision",
-		"numeric_scale").ToSQL()
+func (s *postgresSchema) GetFields(ctx context.Context, name string) ([]*config.Field, error) {
+	err := s.LoadColumns(ctx)
 	if err != nil {
 		return nil, err
 	}
-	rows, err := s.pool.Query(context.Background(), query)
+
+	err = s.LoadIndices(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	fields := make([]*config.Field, 0)
+	columns := s.columns[name]
+	fields := make([]*config.Field, 0, len(columns))
+	for _, column := range columns {
+		fields = append(fields, s.getField(name, column))
+	}
+	return fields, nil
+}
+
+// LoadColumns fetches the columns of every table in a single query.
+func (s *postgresSchema) LoadColumns(ctx context.Context) error {
+	if s.columnsLoaded {
+		return nil
+	}
+
+	query, err := s.columnsQuery()
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns := make(map[string][]*TableStructure)
 	for rows.Next() {
-		table := TableStructure{}
-		err := rows.Scan(&table.ColumnName, &table.ColumnDefault, &table.IsNullable,
-			&table.DataType, &table.CharMaxLen, &table.NumPrecision, &table.NumScale)
+		column := &TableStructure{}
+		err := rows.Scan(&column.TableName, &column.ColumnName, &column.ColumnDefault, &column.IsNullable,
+			&column.DataType, &column.CharMaxLen, &column.NumPrecision, &column.NumScale, &column.CollationName)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		fields = append(fields, s.getField(name, &table))
+		columns[column.TableName] = append(columns[column.TableName], column)
 	}
-	return fields, nil
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	s.columns = columns
+	s.columnsLoaded = true
+	return nil
+}
+
+// columnsQuery reads pg_attribute, reporting the types as information_schema
+// does, databases hiding columns from pg_catalog use information_schema.
+func (s *postgresSchema) columnsQuery() (string, error) {
+	if s.skipHiddenColumns {
+		query, _, err := goqu.Dialect("postgres").From("information_schema.columns").
+			Where(
+				goqu.C("table_schema").Eq(s.schema),
+				goqu.C("is_hidden").Eq("NO"),
+			).
+			Select(
+				"table_name", "column_name", "column_default", "is_nullable",
+				"data_type", "character_maximum_length", "numeric_precision",
+				"numeric_scale", "collation_name").
+			Order(goqu.C("table_name").Asc(), goqu.C("ordinal_position").Asc()).
+			ToSQL()
+		return query, err
+	}
+
+	query, _, err := goqu.Dialect("postgres").
+		From(goqu.T("pg_attribute").Schema("pg_catalog").As("a")).
+		Join(
+			goqu.T("pg_class").Schema("pg_catalog").As("c"),
+			goqu.On(goqu.I("c.oid").Eq(goqu.I("a.attrelid"))),
+		).
+		Join(
+			goqu.T("pg_namespace").Schema("pg_catalog").As("n"),
+			goqu.On(goqu.I("n.oid").Eq(goqu.I("c.relnamespace"))),
+		).
+		LeftJoin(
+			goqu.T("pg_attrdef").Schema("pg_catalog").As("d"),
+			goqu.On(
+				goqu.I("d.adrelid").Eq(goqu.I("a.attrelid")),
+				goqu.I("d.adnum").Eq(goqu.I("a.attnum")),
+			),
+		).
+		LeftJoin(
+			goqu.T("pg_collation").Schema("pg_catalog").As("co"),
+			goqu.On(
+				goqu.I("co.oid").Eq(goqu.I("a.attcollation")),
+				goqu.I("co.collname").Neq("default"),
+			),
+		).
+		Where(
+			goqu.I("n.nspname").Eq(s.schema),
+			goqu.I("c.relkind").In("r", "p"),
+			goqu.I("a.attnum").Gt(0),
+			goqu.I("a.attisdropped").IsFalse(),
+		).
+		Select(
+			"c.relname",
+			"a.attname",
+			goqu.Func("pg_catalog.pg_get_expr", goqu.I("d.adbin"), goqu.I("d.adrelid")),
+			goqu.Case().When(goqu.I("a.attnotnull"), "NO").Else("YES"),
+			goqu.Func("pg_catalog.format_type", goqu.I("a.atttypid"), nil),
+			goqu.Func("information_schema._pg_char_max_length", goqu.I("a.atttypid"), goqu.I("a.atttypmod")),
+			goqu.Func("information_schema._pg_numeric_precision", goqu.I("a.atttypid"), goqu.I("a.atttypmod")),
+			goqu.Func("information_schema._pg_numeric_scale", goqu.I("a.atttypid"), goqu.I("a.atttypmod")),
+			"co.collname",
+		).
+		Order(goqu.I("c.relname").Asc(), goqu.I("a.attnum").Asc()).
+		ToSQL()
+	return query, err
 }
 
 func (s *postgresSchema) getField(name string, table *TableStructure) *config.Field {
@@ -176,10 +398,11 @@ func (s *postgresSchema) getField(name string, table *TableStructure) *config.Fi
 	}
 
 	field := &config.Field{
-		Name:    table.ColumnName,
-		Type:    ft,
-		Default: s.ParseDefaultValue(table.ColumnDefault.String),
-		Options: s.GetOptions(name, table),
+		Name:      table.ColumnName,
+		Type:      ft,
+		Default:   s.ParseDefaultValue(table.ColumnDefault.String),
+		Options:   s.GetOptions(name, table),
+		Collation: table.CollationName.String,
 	}
 
 	switch ft.Type() {
@@ -200,7 +423,7 @@ func (s *postgresSchema) getField(name string, table *TableStructure) *config.Fi
 }
 
 func (s *postgresSchema) isAutoIncrement(defaultValue string) bool {
-	match, err := regexp.MatchString(RegexAutoIncrement, defaultValue)
+	match, err := regexp.MatchString(s.autoIncrementPattern, defaultValue)
 	if err != nil {
 		return false
 	}
@@ -212,12 +435,14 @@ func (s *postgresSchema) ParseDefaultValue(value string) interface{} {
 		return nil
 	}
 
-	valSplit := strings.Split(value, "::")
+	// cockroach annotates the type with ::: instead of casting it
+	valSplit := strings.Split(strings.Replace(value, ":::", "::", 1), "::")
 	if len(valSplit) > 1 {
 		valValue, valType := valSplit[0], valSplit[1]
 
 		switch valType {
-		case "character varying", "text", "timestamp without time zone", "timestamp with time zone":
+		case "character varying", "text", "timestamp without time zone", "timestamp with time zone",
+			"STRING", "TIMESTAMP", "TIMESTAMPTZ":
 			// trim ' prefix and suffix
 			trimVal := strings.TrimPrefix(strings.TrimSuffix(valValue, "'"), "'")
 			// replace escaped '' with '
@@ -240,7 +465,7 @@ func (s *postgresSchema) ParseDefaultValue(value string) interface{} {
 
 func (s *postgresSchema) GetOptions(name string, table *TableStructure) []field_option.FieldOption {
 	options := make([]field_option.FieldOption, 0)
-	pk, _ := s.GetPrimaryKey(name)
+	pk := s.primaryKeys[name]
 	if pk != nil && pk.Column == table.ColumnName {
 		options = append(options, field_option.PrimaryKey)
 	}
@@ -252,190 +477,347 @@ func (s *postgresSchema) GetOptions(name string, table *TableStructure) []field_
 	return options
 }
 
-func (s *postgresSchema) GetPrimaryKeys() (map[string]*PrimaryKey, error) {
-	err := s.LoadPrimaryKeys()
+func (s *postgresSchema) GetPrimaryKeys(ctx context.Context) (map[string]*PrimaryKey, error) {
+	err := s.LoadIndices(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return s.primaryKeys, nil
 }
 
-func (s *postgresSchema) GetPrimaryKey(table string) (*PrimaryKey, error) {
-	primaryKeys, err := s.GetPrimaryKeys()
+func (s *postgresSchema) GetPrimaryKey(ctx context.Context, table string) (*PrimaryKey, error) {
+	primaryKeys, err := s.GetPrimaryKeys(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return primaryKeys[table], nil
 }
 
-func (s *postgresSchema) LoadPrimaryKeys() error {
-	if s.primaryKeysLoaded {
+func (s *postgresSchema) GetTablesIndices(ctx context.Context, name string) ([]*config.Index, error) {
+	indices, err := s.GetIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tablePk, err := s.GetPrimaryKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*config.Index, 0)
+	tableIndices := indices[name]
+	if tableIndices == nil {
+		return result, nil
+	}
+
+	for name, index := range tableIndices.Indices {
+		if tablePk == nil || name != tablePk.Name {
+			result = append(result, index)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (s *postgresSchema) GetIndices(ctx context.Context) (map[string]*Indices, error) {
+	err := s.LoadIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.indices, nil
+}
+
+// LoadIndices fetches the indices of every table in a single query, the
+// primary keys are the indices backing the primary key constraints.
+func (s *postgresSchema) LoadIndices(ctx context.Context) error {
+	if s.indicesLoaded {
 		return nil
 	}
 
-	indices, err := s.GetIndices()
+	query, _, err := goqu.Dialect("postgres").
+		From(goqu.T("pg_index").Schema("pg_catalog").As("x")).
+		Join(
+			goqu.T("pg_class").Schema("pg_catalog").As("i"),
+			goqu.On(goqu.I("i.oid").Eq(goqu.I("x.indexrelid"))),
+		).
+		Join(
+			goqu.T("pg_class").Schema("pg_catalog").As("t"),
+			goqu.On(goqu.I("t.oid").Eq(goqu.I("x.indrelid"))),
+		).
+		Join(
+			goqu.T("pg_namespace").Schema("pg_catalog").As("n"),
+			goqu.On(goqu.I("n.oid").Eq(goqu.I("t.relnamespace"))),
+		).
+		Where(goqu.I("n.nspname").Eq(s.schema)).
+		Select(
+			"t.relname", "i.relname",
+			goqu.Func("pg_catalog.pg_get_indexdef", goqu.I("x.indexrelid")),
+			"x.indisprimary",
+		).
+		ToSQL()
 	if err != nil {
 		return err
 	}
-
-	pkConstraint, err := s.GetPKConstraint()
+	rows, err := s.pool.Query(ctx, query)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
+	indices := make(map[string]*Indices)
 	primaryKeys := make(map[string]*PrimaryKey)
-	for tablename, constraintname := range pkConstraint {
-		tableIndices := indices[tablename]
-		if indices == nil {
-			continue
+	for rows.Next() {
+		var tablename, indexname, indexdef string
+		var primary bool
+		err := rows.Scan(&tablename, &indexname, &indexdef, &primary)
+		if err != nil {
+			return err
 		}
 
-		constraint, err := tableIndices.GetByConstraintName(constraintname)
+		index, err := parseIndexDef(indexdef)
 		if err != nil {
-			continue
+			return err
+		}
+
+		if indices[tablename] == nil {
+			indices[tablename] = &Indices{
+				Table:   tablename,
+				Indices: make(map[string]*config.Index),
+			}
 		}
+		indices[tablename].Indices[indexname] = index
 
-		primaryKeys[tablename] = &PrimaryKey{
-			Table:  tablename,
-			Name:   constraintname,
-			Column: constraint.GetColumns()[0],
+		if primary && len(index.Fields) > 0 {
+			primaryKeys[tablename] = &PrimaryKey{
+				Table:  tablename,
+				Name:   indexname,
+				Column: index.GetColumns()[0],
+			}
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
+	s.indices = indices
 	s.primaryKeys = primaryKeys
-	s.primaryKeysLoaded = true
+	s.indicesLoaded = true
+
 	return nil
 }
 
-func (s *postgresSchema) GetPKConstraint() (map[string]string, error) {
-	query, _, err := goqu.Dialect("postgres").
-		From("information_schema.table_constraints").
-		Where(
-			goqu.C("constraint_schema").Eq(s.schema),
-			goqu.C("constraint_type").Eq("PRIMARY KEY"),
-		).Select("table_name", "constraint_name").ToSQL()
-
+func parseIndexDef(indexdef string) (*config.Index, error) {
+	idxdef, err := pg_query.Parse(indexdef)
 	if err != nil {
 		return nil, err
 	}
 
-	constraints := make(map[string]string)
-	rows, err := s.pool.Query(context.Background(), query)
-	if err != nil {
-		return nil, err
+	if len(idxdef.Stmts) == 0 {
+		return nil, errors.New("invalid statement")
 	}
 
-	for rows.Next() {
-		var tablename, constraint string
-		err := rows.Scan(&tablename, &constraint)
-		if err != nil {
-			return nil, err
-		}
+	idxStmt := idxdef.Stmts[0].GetStmt().GetIndexStmt()
+	index := &config.Index{
+		Name:   idxStmt.GetIdxname(),
+		Fields: []*config.IndexField{},
+		Unique: idxStmt.Unique,
+	}
 
-		constraints[tablename] = constraint
+	for _, field := range idxStmt.GetIndexParams() {
+		ordering := field.GetIndexElem().Ordering
+		index.Fields = append(index.Fields, &config.IndexField{
+			Column: field.GetIndexElem().GetName(),
+			Order:  OrderMapper[ordering],
+		})
 	}
 
-	return constraints, nil
+	return index, nil
 }
 
-func (s *postgresSchema) GetTablesIndices(name string) ([]*config.Index, error) {
-	indices, err := s.GetIndices()
+func (s *postgresSchema) GetTableGrants(ctx context.Context, name string) ([]*config.Grant, error) {
+	grants, err := s.GetGrants(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return grants[name], nil
+}
 
-	tablePk, err := s.GetPrimaryKey(name)
+func (s *postgresSchema) GetGrants(ctx context.Context) (map[string][]*config.Grant, error) {
+	err := s.LoadGrants(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return s.grants, nil
+}
 
-	result := make([]*config.Index, 0)
-	tableIndices := indices[name]
-	if tableIndices == nil {
-		return result, nil
+func (s *postgresSchema) LoadGrants(ctx context.Context) error {
+	if s.grantsLoaded {
+		return nil
 	}
 
-	for name, index := range tableIndices.Indices {
-		if tablePk == nil || name != tablePk.Name {
-			result = append(result, index)
+	// privileges where grantor is the grantee belong to the table owner
+	ownerCondition := goqu.C("grantor").Neq(goqu.C("grantee"))
+	if len(s.excludedGrantees) > 0 {
+		ownerCondition = goqu.C("grantee").NotIn(s.excludedGrantees)
+	}
+
+	query, _, err := goqu.Dialect("postgres").
+		From("information_schema.role_table_grants").
+		Where(
+			goqu.C("table_schema").Eq(s.schema),
+			ownerCondition,
+		).
+		Select("table_name", "grantee", "privilege_type").
+		Order(goqu.C("table_name").Asc(), goqu.C("grantee").Asc(), goqu.C("privilege_type").Asc()).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	grants := make(map[string][]*config.Grant)
+	for rows.Next() {
+		var tablename, grantee, privilegeType string
+		err := rows.Scan(&tablename, &grantee, &privilegeType)
+		if err != nil {
+			return err
 		}
+
+		priv, err := privilege.Parse(privilegeType)
+		if err != nil {
+			continue
+		}
+
+		tableGrants := grants[tablename]
+		if len(tableGrants) == 0 || tableGrants[len(tableGrants)-1].Role != grantee {
+			tableGrants = append(tableGrants, &config.Grant{Role: grantee})
+		}
+
+		grant := tableGrants[len(tableGrants)-1]
+		grant.Privileges = append(grant.Privileges, priv)
+		grants[tablename] = tableGrants
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	return result, nil
+	s.grants = grants
+	s.grantsLoaded = true
+
+	return nil
 }
 
-func (s *postgresSchema) GetIndices() (map[string]*Indices, error) {
-	err := s.LoadIndices()
+func (s *postgresSchema) GetTableStorage(ctx context.Context, name string) (*config.Storage, error) {
+	storages, err := s.GetStorages(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return s.indices, nil
+	return storages[name], nil
 }
 
-func (s *postgresSchema) LoadIndices() error {
-	if s.indicesLoaded {
+func (s *postgresSchema) GetStorages(ctx context.Context) (map[string]*config.Storage, error) {
+	err := s.LoadStorages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.storages, nil
+}
+
+func (s *postgresSchema) LoadStorages(ctx context.Context) error {
+	if s.storagesLoaded {
+		return nil
+	}
+
+	if s.skipStorages {
+		s.storages = make(map[string]*config.Storage)
+		s.storagesLoaded = true
 		return nil
 	}
 
 	query, _, err := goqu.Dialect("postgres").
-		From("pg_catalog.pg_indexes").
-		Where(goqu.C("schemaname").Eq(s.schema)).
-		Select("tablename", "indexname", "indexdef").
+		From(goqu.T("pg_class").Schema("pg_catalog").As("c")).
+		Join(
+			goqu.T("pg_namespace").Schema("pg_catalog").As("n"),
+			goqu.On(goqu.I("n.oid").Eq(goqu.I("c.relnamespace"))),
+		).
+		LeftJoin(
+			goqu.T("pg_tablespace").Schema("pg_catalog").As("t"),
+			goqu.On(goqu.I("t.oid").Eq(goqu.I("c.reltablespace"))),
+		).
+		Where(
+			goqu.I("n.nspname").Eq(s.schema),
+			goqu.I("c.relkind").Eq("r"),
+		).
+		Select(
+			"c.relname", "c.reloptions",
+			goqu.Cast(goqu.I("c.relpersistence"), "TEXT"), "t.spcname",
+		).
 		ToSQL()
 	if err != nil {
 		return err
 	}
-	rows, err := s.pool.Query(context.Background(), query)
+	rows, err := s.pool.Query(ctx, query)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	indices := make(map[string]*Indices)
+	storages := make(map[string]*config.Storage)
 	for rows.Next() {
-		var tablename, indexname, indexdef string
-		err := rows.Scan(&tablename, &indexname, &indexdef)
+		table := TableStorage{}
+		err := rows.Scan(&table.TableName, &table.Options, &table.Persistence, &table.Tablespace)
 		if err != nil {
 			return err
 		}
 
-		idxdef, err := pg_query.Parse(indexdef)
-		if err != nil {
-			return err
+		storage := s.getStorage(&table)
+		if storage != nil {
+			storages[table.TableName] = storage
 		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-		if len(idxdef.Stmts) == 0 {
-			return errors.New("invalid statement")
-		}
+	s.storages = storages
+	s.storagesLoaded = true
 
-		if indices[tablename] == nil {
-			indices[tablename] = &Indices{
-				Table:   tablename,
-				Indices: make(map[string]*config.Index),
-			}
-		}
+	return nil
+}
 
-		container := indices[tablename].Indices
-		idxStmt := idxdef.Stmts[0].GetStmt().GetIndexStmt()
-		index := config.Index{
-			Name:   idxStmt.GetIdxname(),
-			Fields: []*config.IndexField{},
-			Unique: idxStmt.Unique,
-		}
+func (s *postgresSchema) getStorage(table *TableStorage) *config.Storage {
+	storage := &config.Storage{
+		Tablespace: table.Tablespace.String,
+		Unlogged:   table.Persistence == "u",
+	}
 
-		for _, field := range idxStmt.GetIndexParams() {
-			ordering := field.GetIndexElem().Ordering
-			index.Fields = append(index.Fields, &config.IndexField{
-				Column: field.GetIndexElem().GetName(),
-				Order:  OrderMapper[ordering],
-			})
+	for _, option := range table.Options {
+		name, value, found := strings.Cut(option, "=")
+		if !found {
+			continue
 		}
 
-		container[indexname] = &index
-		indices[tablename].Indices = container
+		switch {
+		case name == "fillfactor":
+			storage.Fillfactor, _ = strconv.Atoi(value)
+		case strings.HasPrefix(name, config.AutovacuumParameterPrefix):
+			if storage.Autovacuum == nil {
+				storage.Autovacuum = make(map[string]interface{})
+			}
+			storage.Autovacuum[strings.TrimPrefix(name, config.AutovacuumParameterPrefix)] = value
+		}
 	}
 
-	s.indices = indices
-	s.indicesLoaded = true
-
-	return nil
+	if storage.Fillfactor == 0 && len(storage.Autovacuum) == 0 &&
+		storage.Tablespace == "" && !storage.Unlogged {
+		return nil
+	}
+	return storage
 }
@@ -2,14 +2,20 @@ package schema_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/pashagolub/pgxmock"
 	"github.com/stretchr/testify/assert"
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
 	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
 	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
 )
 
 func TestPostgres_GetSchemas(t *testing.T) {
@@ -20,7 +26,9 @@ func TestPostgres_GetSchemas(t *testing.T) {
 		fieldErr    error
 		indexResult *pgxmock.Rows
 		indexErr    error
-		constResult *pgxmock.Rows
+		grantResult *pgxmock.Rows
+		grantErr    error
+		storeResult *pgxmock.Rows
 		result      []*config.Schema
 		err         error
 	}{
@@ -29,23 +37,26 @@ func TestPostgres_GetSchemas(t *testing.T) {
 				"table_name",
 			}).AddRow("example"),
 			fieldResult: pgxmock.NewRows([]string{
-				"column_name", "column_default", "is_nullable", "data_type", "character_maximum_length",
-				"numeric_precision", "numeric_scale",
+				"relname", "attname", "column_default", "is_nullable", "data_type", "character_maximum_length",
+				"numeric_precision", "numeric_scale", "collname",
 			}).AddRow(
-				"id", "nextval('some_id_sec'::regclass)", "NO", "bigint", nil, 64, nil,
+				"example", "id", "nextval('some_id_sec'::regclass)", "NO", "bigint", nil, 64, nil, nil,
 			).AddRow(
-				"name", "'Alfred'::character varying", "NO", "character varying", "200", nil, nil,
+				"example", "name", "'Alfred'::character varying", "NO", "character varying", "200", nil, nil, "und-x-icu",
 			).AddRow(
-				"price", "100.5", "YES", "numeric", nil, 64, 2,
+				"example", "price", "100.5", "YES", "numeric", nil, 64, 2, nil,
 			),
 			indexResult: pgxmock.NewRows([]string{
-				"tablename", "indexname", "indexdef",
+				"relname", "relname", "indexdef", "indisprimary",
 			}).AddRow(
-				"example", "example_pkey", "CREATE UNIQUE INDEX example_pkey ON public.example USING btree (id)",
+				"example", "example_pkey", "CREATE UNIQUE INDEX example_pkey ON public.example USING btree (id)", true,
 			),
-			constResult: pgxmock.NewRows([]string{
-				"table_name", "constraint_name",
-			}).AddRow("example", "example_pkey"),
+			grantResult: pgxmock.NewRows([]string{
+				"table_name", "grantee", "privilege_type",
+			}).AddRow("example", "reporting", "SELECT"),
+			storeResult: pgxmock.NewRows([]string{
+				"relname", "reloptions", "relpersistence", "spcname",
+			}).AddRow("example", []string{"fillfactor=70"}, "p", nil),
 			result: []*config.Schema{
 				{
 					Name: "example",
@@ -60,11 +71,12 @@ func TestPostgres_GetSchemas(t *testing.T) {
 							},
 						},
 						{
-							Name:    "name",
-							Type:    "varchar",
-							Limit:   200,
-							Default: "Alfred",
-							Options: []field_option.FieldOption{field_option.NotNull},
+							Name:      "name",
+							Type:      "varchar",
+							Limit:     200,
+							Default:   "Alfred",
+							Options:   []field_option.FieldOption{field_option.NotNull},
+							Collation: "und-x-icu",
 						},
 						{
 							Name:    "price",
@@ -76,6 +88,10 @@ func TestPostgres_GetSchemas(t *testing.T) {
 						},
 					},
 					Index: []*config.Index{},
+					Grants: []*config.Grant{
+						{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+					},
+					Storage: &config.Storage{Fillfactor: 70},
 				},
 			},
 		},
@@ -95,12 +111,26 @@ func TestPostgres_GetSchemas(t *testing.T) {
 				"table_name",
 			}).AddRow("example"),
 			fieldResult: pgxmock.NewRows([]string{
-				"column_name", "column_default", "is_nullable", "data_type", "character_maximum_length",
-				"numeric_precision", "numeric_scale",
+				"relname", "attname", "column_default", "is_nullable", "data_type", "character_maximum_length",
+				"numeric_precision", "numeric_scale", "collname",
 			}),
 			indexErr: errors.New("error get index"),
 			err:      errors.New("error get index"),
 		},
+		"error get grant": {
+			tableResult: pgxmock.NewRows([]string{
+				"table_name",
+			}).AddRow("example"),
+			fieldResult: pgxmock.NewRows([]string{
+				"relname", "attname", "column_default", "is_nullable", "data_type", "character_maximum_length",
+				"numeric_precision", "numeric_scale", "collname",
+			}),
+			indexResult: pgxmock.NewRows([]string{
+				"relname", "relname", "indexdef", "indisprimary",
+			}),
+			grantErr: errors.New("error get grant"),
+			err:      errors.New("error get grant"),
+		},
 	}
 
 	for name, tc := range testCases {
@@ -110,36 +140,44 @@ func TestPostgres_GetSchemas(t *testing.T) {
 			defer mock.Close(context.Background())
 
 			if tc.tableResult != nil {
-				mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"tables\"").
+				mock.ExpectQuery("SELECT \"c\".\"relname\" FROM \"pg_catalog\".\"pg_class\"").
 					WillReturnRows(tc.tableResult)
 			}
 			if tc.tableErr != nil {
-				mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"tables\"").
+				mock.ExpectQuery("SELECT \"c\".\"relname\" FROM \"pg_catalog\".\"pg_class\"").
 					WillReturnError(tc.tableErr)
 			}
 			if tc.fieldResult != nil {
-				mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"columns\"").
+				mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_attribute\"").
 					WillReturnRows(tc.fieldResult)
 			}
 			if tc.fieldErr != nil {
-				mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"columns\"").
+				mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_attribute\"").
 					WillReturnError(tc.fieldErr)
 			}
 			if tc.indexResult != nil {
-				mock.ExpectQuery("SELECT [^(FROM)]+FROM \"pg_catalog\".\"pg_indexes\"").
+				mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_index\"").
 					WillReturnRows(tc.indexResult)
 			}
 			if tc.indexErr != nil {
-				mock.ExpectQuery("SELECT [^(FROM)]+FROM \"pg_catalog\".\"pg_indexes\"").
+				mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_index\"").
 					WillReturnError(tc.indexErr)
 			}
-			if tc.constResult != nil {
-				mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"table_constraints\"").
-					WillReturnRows(tc.constResult)
+			if tc.grantResult != nil {
+				mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"role_table_grants\"").
+					WillReturnRows(tc.grantResult)
+			}
+			if tc.grantErr != nil {
+				mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"role_table_grants\"").
+					WillReturnError(tc.grantErr)
+			}
+			if tc.storeResult != nil {
+				mock.ExpectQuery("SELECT .+\"c\".\"reloptions\".+ FROM \"pg_catalog\".\"pg_class\"").
+					WillReturnRows(tc.storeResult)
 			}
 
 			sc := schema.NewPostgresSchema(mock)
-			result, err := sc.GetSchemas()
+			result, err := sc.GetSchemas(context.Background())
 			assert.Equal(t, tc.err, err)
 			assert.ElementsMatch(t, tc.result, result)
 		})
@@ -152,34 +190,28 @@ func TestPostgres_GetField(t *testing.T) {
 	defer mock.Close(context.Background())
 
 	fieldsResults := pgxmock.NewRows([]string{
-		"column_name", "column_default", "is_nullable", "data_type", "character_maximum_length",
-		"numeric_precision", "numeric_scale",
+		"relname", "attname", "column_default", "is_nullable", "data_type", "character_maximum_length",
+		"numeric_precision", "numeric_scale", "collname",
 	}).AddRow(
-		"id", "nextval('some_id_sec'::regclass)", "NO", "bigint", nil, 64, nil,
+		"example", "id", "nextval('some_id_sec'::regclass)", "NO", "bigint", nil, 64, nil, nil,
 	).AddRow(
-		"name", "'Alfred'::character varying", "NO", "character varying", "200", nil, nil,
+		"example", "name", "'Alfred'::character varying", "NO", "character varying", "200", nil, nil, "und-x-icu",
 	).AddRow(
-		"price", "100.5", "YES", "numeric", nil, 64, 2,
+		"example", "price", "100.5", "YES", "numeric", nil, 64, 2, nil,
 	)
-	mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"columns\"").
+	mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_attribute\"").
 		WillReturnRows(fieldsResults)
 
 	indicesResults := pgxmock.NewRows([]string{
-		"tablename", "indexname", "indexdef",
+		"relname", "relname", "indexdef", "indisprimary",
 	}).AddRow(
-		"example", "example_pkey", "CREATE UNIQUE INDEX example_pkey ON public.example USING btree (id)",
+		"example", "example_pkey", "CREATE UNIQUE INDEX example_pkey ON public.example USING btree (id)", true,
 	)
-	mock.ExpectQuery("SELECT [^(FROM)]+FROM \"pg_catalog\".\"pg_indexes\"").
+	mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_index\"").
 		WillReturnRows(indicesResults)
 
-	constraintResult := pgxmock.NewRows([]string{
-		"table_name", "constraint_name",
-	}).AddRow("example", "example_pkey")
-	mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"table_constraints\"").
-		WillReturnRows(constraintResult)
-
 	sc := schema.NewPostgresSchema(mock)
-	result, err := sc.GetFields("example")
+	result, err := sc.GetFields(context.Background(), "example")
 	assert.Nil(t, err)
 	assert.ElementsMatch(t, []*config.Field{
 		{
@@ -192,11 +224,12 @@ func TestPostgres_GetField(t *testing.T) {
 			},
 		},
 		{
-			Name:    "name",
-			Type:    "varchar",
-			Limit:   200,
-			Default: "Alfred",
-			Options: []field_option.FieldOption{field_option.NotNull},
+			Name:      "name",
+			Type:      "varchar",
+			Limit:     200,
+			Default:   "Alfred",
+			Options:   []field_option.FieldOption{field_option.NotNull},
+			Collation: "und-x-icu",
 		},
 		{
 			Name:    "price",
@@ -220,7 +253,7 @@ func TestPostgres_GetTables(t *testing.T) {
 			AddRow("histories").
 			AddRow("schema_migrations"))
 	sc := schema.NewPostgresSchema(mock)
-	result, err := sc.GetTables()
+	result, err := sc.GetTables(context.Background())
 	assert.Nil(t, err)
 	assert.ElementsMatch(t, []string{"users", "histories"}, result)
 
@@ -229,19 +262,70 @@ func TestPostgres_GetTables(t *testing.T) {
 	}
 }
 
+func TestPostgres_GetTablesFiltered(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	mock.ExpectQuery("SELECT *").
+		WillReturnRows(pgxmock.NewRows([]string{"table_name"}).
+			AddRow("users").
+			AddRow("pgbouncer_stats").
+			AddRow("schema_migrations").
+			AddRow("goose_db_version"))
+
+	tableFilter, err := filter.NewTableFilter(nil, []string{"pgbouncer_*"}, "goose_db_version")
+	assert.Nil(t, err)
+
+	sc := schema.NewPostgresSchema(mock).WithTableFilter(tableFilter)
+	result, err := sc.GetTables(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"users", "schema_migrations"}, result)
+}
+
+func TestPostgres_GetTablesCanceled(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	mock.ExpectQuery("SELECT *").
+		WillDelayFor(time.Second).
+		WillReturnRows(pgxmock.NewRows([]string{"table_name"}).AddRow("users"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	sc := schema.NewPostgresSchema(mock)
+	_, err = sc.GetTables(ctx)
+	assert.True(t, errors.Is(err, pgxmock.ErrCancelled))
+}
+
+func TestPostgres_Close(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.Nil(t, err)
+
+	mock.ExpectClose()
+	err = schema.NewPostgresSchema(mock).Close()
+	assert.Nil(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestPostgres_GetIndices(t *testing.T) {
 	mock, err := pgxmock.NewConn()
 	assert.Nil(t, err)
 	defer mock.Close(context.Background())
 
 	indicesResults := pgxmock.NewRows([]string{
-		"tablename", "indexname", "indexdef",
+		"relname", "relname", "indexdef", "indisprimary",
 	}).AddRow(
-		"example", "example_pkey", "CREATE UNIQUE INDEX example_pkey ON public.example USING btree (id)",
+		"example", "example_pkey", "CREATE UNIQUE INDEX example_pkey ON public.example USING btree (id)", true,
 	).AddRow(
-		"example", "index_example_on_name_email", "CREATE INDEX index_example_on_name_email ON public.example(name ASC, email DESC)",
+		"example", "index_example_on_name_email", "CREATE INDEX index_example_on_name_email ON public.example(name ASC, email DESC)", false,
 	)
-	mock.ExpectQuery("SELECT [^(FROM)]+FROM \"pg_catalog\".\"pg_indexes\"").
+	mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_index\"").
 		WillReturnRows(indicesResults)
 
 	expectedResult := map[string]*schema.Indices{
@@ -266,7 +350,7 @@ func TestPostgres_GetIndices(t *testing.T) {
 		},
 	}
 	sc := schema.NewPostgresSchema(mock)
-	result, err := sc.GetIndices()
+	result, err := sc.GetIndices(context.Background())
 	assert.Nil(t, err)
 	assert.Equal(t, expectedResult, result)
 }
@@ -277,19 +361,14 @@ func TestPostgres_GetPrimaryKeys(t *testing.T) {
 	defer mock.Close(context.Background())
 
 	indicesResults := pgxmock.NewRows([]string{
-		"tablename", "indexname", "indexdef",
+		"relname", "relname", "indexdef", "indisprimary",
 	}).AddRow(
-		"example", "example_pkey", "CREATE UNIQUE INDEX example_pkey ON public.example USING btree (id)",
+		"example", "example_pkey", "CREATE UNIQUE INDEX example_pkey ON public.example USING btree (id)", true,
 	).AddRow(
-		"example", "index_example_on_name_email", "CREATE INDEX index_example_on_name_email ON public.example(name ASC, email DESC)",
+		"example", "index_example_on_name_email", "CREATE INDEX index_example_on_name_email ON public.example(name ASC, email DESC)", false,
 	)
-	mock.ExpectQuery("SELECT [^(FROM)]+FROM \"pg_catalog\".\"pg_indexes\"").
+	mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_index\"").
 		WillReturnRows(indicesResults)
-	constraintResult := pgxmock.NewRows([]string{
-		"table_name", "constraint_name",
-	}).AddRow("example", "example_pkey")
-	mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"table_constraints\"").
-		WillReturnRows(constraintResult)
 
 	expectedResult := map[string]*schema.PrimaryKey{
 		"example": {
@@ -299,11 +378,111 @@ func TestPostgres_GetPrimaryKeys(t *testing.T) {
 		},
 	}
 	sc := schema.NewPostgresSchema(mock)
-	result, err := sc.GetPrimaryKeys()
+	result, err := sc.GetPrimaryKeys(context.Background())
 	assert.Nil(t, err)
 	assert.Equal(t, expectedResult, result)
 }
 
+func TestPostgres_GetGrants(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	grantResults := pgxmock.NewRows([]string{
+		"table_name", "grantee", "privilege_type",
+	}).AddRow(
+		"example", "app", "INSERT",
+	).AddRow(
+		"example", "app", "SELECT",
+	).AddRow(
+		"example", "reporting", "SELECT",
+	).AddRow(
+		"histories", "reporting", "SELECT",
+	)
+	mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"role_table_grants\"").
+		WillReturnRows(grantResults)
+
+	expectedResult := map[string][]*config.Grant{
+		"example": {
+			{Role: "app", Privileges: []privilege.Privilege{privilege.Insert, privilege.Select}},
+			{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+		},
+		"histories": {
+			{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+		},
+	}
+	sc := schema.NewPostgresSchema(mock)
+	result, err := sc.GetGrants(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResult, result)
+}
+
+func TestPostgres_GetStorages(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	storageResults := pgxmock.NewRows([]string{
+		"relname", "reloptions", "relpersistence", "spcname",
+	}).AddRow(
+		"example", []string{"fillfactor=70", "autovacuum_enabled=false", "toast_tuple_target=128"}, "p", nil,
+	).AddRow(
+		"histories", nil, "u", "fast",
+	).AddRow(
+		"users", nil, "p", nil,
+	)
+	mock.ExpectQuery("SELECT .+\"c\".\"reloptions\".+ FROM \"pg_catalog\".\"pg_class\"").
+		WillReturnRows(storageResults)
+
+	expectedResult := map[string]*config.Storage{
+		"example": {
+			Fillfactor: 70,
+			Autovacuum: map[string]interface{}{"enabled": "false"},
+		},
+		"histories": {
+			Tablespace: "fast",
+			Unlogged:   true,
+		},
+	}
+	sc := schema.NewPostgresSchema(mock)
+	result, err := sc.GetStorages(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResult, result)
+}
+
+func TestPostgres_GetStorages_RoundTrip(t *testing.T) {
+	target := &config.Storage{}
+	err := json.Unmarshal([]byte(`{
+		"fillfactor": 70,
+		"autovacuum": {"vacuum_threshold": 1000000, "vacuum_scale_factor": 0.05, "enabled": false},
+		"tablespace": "pg_default"
+	}`), target)
+	assert.Nil(t, err)
+
+	// the database stores the parameters the migration sets
+	options := make([]string, 0)
+	for _, param := range target.Parameters() {
+		options = append(options, param.Name+"="+param.Value)
+	}
+
+	mock, err := pgxmock.NewConn()
+	assert.Nil(t, err)
+	defer mock.Close(context.Background())
+
+	storageResults := pgxmock.NewRows([]string{
+		"relname", "reloptions", "relpersistence", "spcname",
+	}).AddRow("example", options, "p", nil)
+	mock.ExpectQuery("SELECT .+\"c\".\"reloptions\".+ FROM \"pg_catalog\".\"pg_class\"").
+		WillReturnRows(storageResults)
+
+	sc := schema.NewPostgresSchema(mock)
+	result, err := sc.GetStorages(context.Background())
+	assert.Nil(t, err)
+
+	alterStorage := &step.AlterStorage{Storage: target, LastStorage: result["example"]}
+	assert.False(t, alterStorage.HasChanges())
+}
+
 func TestPostgres_ParseDefaultValue(t *testing.T) {
 	testCases := map[string]struct {
 		input  string
@@ -343,3 +522,96 @@ func TestPostgres_ParseDefaultValue(t *testing.T) {
 		})
 	}
 }
+
+// expectCatalog expects the catalog queries of a schema with the given number
+// of tables, each having an id primary key, a name column and an index.
+func expectCatalog(mock pgxmock.PgxPoolIface, tables int) {
+	tableRows := pgxmock.NewRows([]string{"relname"})
+	columnRows := pgxmock.NewRows([]string{
+		"relname", "attname", "column_default", "is_nullable", "data_type", "character_maximum_length",
+		"numeric_precision", "numeric_scale", "collname",
+	})
+	indexRows := pgxmock.NewRows([]string{"relname", "relname", "indexdef", "indisprimary"})
+	grantRows := pgxmock.NewRows([]string{"table_name", "grantee", "privilege_type"})
+	storageRows := pgxmock.NewRows([]string{"relname", "reloptions", "relpersistence", "spcname"})
+
+	for i := 0; i < tables; i++ {
+		table := fmt.Sprintf("table_%d", i)
+		tableRows.AddRow(table)
+		columnRows.
+			AddRow(table, "id", fmt.Sprintf("nextval('%s_id_seq'::regclass)", table), "NO", "bigint", nil, 64, 0, nil).
+			AddRow(table, "name", nil, "YES", "character varying", 100, nil, nil, nil)
+		indexRows.
+			AddRow(table, table+"_pkey", fmt.Sprintf("CREATE UNIQUE INDEX %s_pkey ON public.%s USING btree (id)", table, table), true).
+			AddRow(table, table+"_name", fmt.Sprintf("CREATE INDEX %s_name ON public.%s USING btree (name)", table, table), false)
+		grantRows.AddRow(table, "reporting", "SELECT")
+		storageRows.AddRow(table, nil, "p", nil)
+	}
+
+	mock.ExpectQuery("SELECT \"c\".\"relname\" FROM \"pg_catalog\".\"pg_class\"").WillReturnRows(tableRows)
+	mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_attribute\"").WillReturnRows(columnRows)
+	mock.ExpectQuery("SELECT .+ FROM \"pg_catalog\".\"pg_index\"").WillReturnRows(indexRows)
+	mock.ExpectQuery("SELECT [^(FROM)]+FROM \"information_schema\".\"role_table_grants\"").WillReturnRows(grantRows)
+	mock.ExpectQuery("SELECT .+\"c\".\"reloptions\".+ FROM \"pg_catalog\".\"pg_class\"").WillReturnRows(storageRows)
+}
+
+func TestPostgres_GetSchemasConcurrently(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.Nil(t, err)
+	defer mock.Close()
+
+	mock.MatchExpectationsInOrder(false)
+	expectCatalog(mock, 3)
+
+	sc := schema.NewPostgresSchema(mock).WithConcurrency(4)
+	result, err := sc.GetSchemas(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, result, 3)
+	assert.Equal(t, "table_0", result[0].Name)
+	assert.Equal(t, []*config.Field{
+		{
+			Name:    "id",
+			Type:    "bigserial",
+			Limit:   64,
+			Options: []field_option.FieldOption{field_option.PrimaryKey, field_option.NotNull},
+		},
+		{
+			Name:    "name",
+			Type:    "varchar",
+			Limit:   100,
+			Options: []field_option.FieldOption{},
+		},
+	}, result[0].Fields)
+	assert.Equal(t, []*config.Index{
+		{
+			Name:   "table_0_name",
+			Fields: []*config.IndexField{{Column: "name", Order: "ASC"}},
+		},
+	}, result[0].Index)
+
+	// a single query per catalog whatever the number of tables
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func BenchmarkPostgres_GetSchemas(b *testing.B) {
+	for _, concurrency := range []int{1, 4} {
+		b.Run(fmt.Sprintf("concurrency %d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				mock, err := pgxmock.NewPool()
+				if err != nil {
+					b.Fatal(err)
+				}
+				mock.MatchExpectationsInOrder(false)
+				expectCatalog(mock, 800)
+				b.StartTimer()
+
+				_, err = schema.NewPostgresSchema(mock).WithConcurrency(concurrency).GetSchemas(context.Background())
+				if err != nil {
+					b.Fatal(err)
+				}
+				mock.Close()
+			}
+		})
+	}
+}
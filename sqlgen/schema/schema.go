@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
 )
 
 var (
@@ -17,26 +20,115 @@ var (
 )
 
 type Schema interface {
-	GetSchemas() ([]*config.Schema, error)
-	GetTables() ([]string, error)
-	GetIndices() (map[string]*Indices, error)
-	GetFields(tblName string) ([]*config.Field, error)
-	GetPrimaryKeys() (map[string]*PrimaryKey, error)
+	GetSchemas(ctx context.Context) ([]*config.Schema, error)
+	GetTables(ctx context.Context) ([]string, error)
+	GetIndices(ctx context.Context) (map[string]*Indices, error)
+	GetFields(ctx context.Context, tblName string) ([]*config.Field, error)
+	GetPrimaryKeys(ctx context.Context) (map[string]*PrimaryKey, error)
+	GetGrants(ctx context.Context) (map[string][]*config.Grant, error)
+	GetStorages(ctx context.Context) (map[string]*config.Storage, error)
+	Close() error
 }
 
-func NewSchema(connString string) (Schema, error) {
-	driver := strings.Split(connString, "://")
-	switch driver[0] {
-	case "postgresql":
-		pool, err := pgxpool.Connect(context.Background(), connString)
+// ConnectOptions tune the connection used to crawl the database.
+type ConnectOptions struct {
+	// StatementTimeout aborts any catalog query running longer, zero means no timeout.
+	StatementTimeout time.Duration
+	// TableFilter selects the crawled tables, nil skips the migration table only.
+	TableFilter *filter.TableFilter
+	// Concurrency is the number of catalog queries run at the same time,
+	// bounded by the size of the connection pool. Zero or one runs them one
+	// at a time.
+	Concurrency int
+}
+
+// GetDriver returns the driver name of the connection string.
+func GetDriver(connString string) string {
+	return strings.Split(connString, "://")[0]
+}
+
+func ConnectPostgres(ctx context.Context, connString string, opts ConnectOptions) (Schema, error) {
+	pool, err := connectPool(ctx, connString, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPostgresSchema(pool).WithTableFilter(opts.TableFilter).WithConcurrency(poolConcurrency(pool, opts.Concurrency)), nil
+}
+
+func ConnectCockroach(ctx context.Context, connString string, opts ConnectOptions) (Schema, error) {
+	pool, err := connectPool(ctx, CockroachConnString(connString), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCockroachSchema(pool).WithTableFilter(opts.TableFilter).WithConcurrency(poolConcurrency(pool, opts.Concurrency)), nil
+}
+
+// connectPool sets the statement timeout as a runtime parameter, so every
+// connection of the pool starts with it.
+func connectPool(ctx context.Context, connString string, opts ConnectOptions) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(opts.StatementTimeout.Milliseconds(), 10)
+	}
+
+	return pgxpool.ConnectConfig(ctx, config)
+}
+
+// poolConcurrency bounds the concurrency by the connections of the pool, a
+// query never waiting for another to release its connection.
+func poolConcurrency(pool *pgxpool.Pool, concurrency int) int {
+	if maxConns := int(pool.Config().MaxConns); concurrency > maxConns {
+		return maxConns
+	}
+	return concurrency
+}
+
+func ConnectMySQL(ctx context.Context, connString string, opts ConnectOptions) (Schema, error) {
+	dsn, err := MySQLDSN(connString)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.StatementTimeout > 0 {
+		dsn, err = mysqlStatementTimeout(dsn, opts.StatementTimeout)
 		if err != nil {
 			return nil, err
 		}
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
 
-		return NewPostgresSchema(pool), nil
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	return nil, ErrUnsupportedDriver
+	return NewMySQLSchema(db).WithTableFilter(opts.TableFilter), nil
+}
+
+// ConnectSQLite has no statement timeout to set, the queries of a local
+// file are only bounded by the context.
+func ConnectSQLite(ctx context.Context, connString string, opts ConnectOptions) (Schema, error) {
+	db, err := sql.Open(SQLiteDriver, SQLitePath(connString))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return NewSQLiteSchema(db).WithTableFilter(opts.TableFilter), nil
 }
 
 type PrimaryKey struct {
@@ -51,6 +143,7 @@ type Indices struct {
 }
 
 type TableStructure struct {
+	TableName     string
 	ColumnName    string
 	ColumnDefault sql.NullString
 	IsNullable    string
@@ -58,6 +151,14 @@ type TableStructure struct {
 	CharMaxLen    sql.NullInt32
 	NumPrecision  sql.NullInt32
 	NumScale      sql.NullInt32
+	CollationName sql.NullString
+}
+
+type TableStorage struct {
+	TableName   string
+	Options     []string
+	Persistence string
+	Tablespace  sql.NullString
 }
 
 func (i *Indices) GetByConstraintName(name string) (*config.Index, error) {
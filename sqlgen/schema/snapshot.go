@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+const SnapshotSuffix = ".snapshot.json"
+
+var ErrSnapshotNotExists = errors.New("migration directory has migrations but no snapshot")
+
+// MarshalSnapshot encodes the schemas in a canonical form: tables, indices
+// and grants sorted by name, so the same schemas always give the same file.
+func MarshalSnapshot(schemas []*config.Schema) ([]byte, error) {
+	snapshot := make([]*config.Schema, 0, len(schemas))
+	for _, sc := range schemas {
+		canonical := *sc
+		canonical.AllowDestructive = nil
+		canonical.Large = false
+		canonical.Fields = make([]*config.Field, 0, len(sc.Fields))
+		for _, field := range sc.Fields {
+			f := *field
+			f.Using = ""
+			f.DownUsing = ""
+			f.RenamedFrom = ""
+			canonical.Fields = append(canonical.Fields, &f)
+		}
+		canonical.Index = append([]*config.Index{}, sc.Index...)
+		sort.SliceStable(canonical.Index, func(i, j int) bool {
+			return canonical.Index[i].Name < canonical.Index[j].Name
+		})
+
+		canonical.Grants = make([]*config.Grant, 0, len(sc.Grants))
+		for _, grant := range sc.Grants {
+			privileges := append([]privilege.Privilege{}, grant.Privileges...)
+			sort.Slice(privileges, func(i, j int) bool {
+				return privileges[i] < privileges[j]
+			})
+			canonical.Grants = append(canonical.Grants, &config.Grant{Role: grant.Role, Privileges: privileges})
+		}
+		sort.SliceStable(canonical.Grants, func(i, j int) bool {
+			return canonical.Grants[i].Role < canonical.Grants[j].Role
+		})
+
+		snapshot = append(snapshot, &canonical)
+	}
+	sort.SliceStable(snapshot, func(i, j int) bool {
+		return snapshot[i].Name < snapshot[j].Name
+	})
+
+	b, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// NewSnapshotSchema serves the tables of the latest snapshot in the migration
+// directory, a directory without any migration has an empty schema.
+func NewSnapshotSchema(dir string) (*staticSchema, error) {
+	path, err := LatestSnapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		files, err := MigrationFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			return nil, ErrSnapshotNotExists
+		}
+		return NewStaticSchema(make([]*config.Schema, 0)), nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := make([]*config.Schema, 0)
+	err = json.Unmarshal(b, &schemas)
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticSchema(schemas), nil
+}
+
+// LatestSnapshot returns the snapshot with the highest version in the
+// directory, or an empty path when there is none.
+func LatestSnapshot(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	latest := ""
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), SnapshotSuffix) {
+			continue
+		}
+		if latest == "" || !versionLess(entry.Name(), latest) {
+			latest = entry.Name()
+		}
+	}
+
+	if latest == "" {
+		return "", nil
+	}
+	return filepath.Join(dir, latest), nil
+}
@@ -0,0 +1,81 @@
+package schema_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+func TestMarshalSnapshot(t *testing.T) {
+	schemas := []*config.Schema{
+		{
+			Name:   "users",
+			Fields: []*config.Field{{Name: "id", Type: "bigserial"}},
+			Index: []*config.Index{
+				{Name: "index_users_on_name"},
+				{Name: "index_users_on_email"},
+			},
+			Grants: []*config.Grant{
+				{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}},
+				{Role: "app", Privileges: []privilege.Privilege{privilege.Update, privilege.Insert}},
+			},
+		},
+		{
+			Name:   "documents",
+			Fields: []*config.Field{{Name: "id", Type: "bigserial"}},
+		},
+	}
+
+	b, err := schema.MarshalSnapshot(schemas)
+	assert.Nil(t, err)
+
+	reordered := []*config.Schema{schemas[1], schemas[0]}
+	again, err := schema.MarshalSnapshot(reordered)
+	assert.Nil(t, err)
+	assert.Equal(t, string(b), string(again))
+
+	// the given schemas are left untouched
+	assert.Equal(t, "index_users_on_name", schemas[0].Index[0].Name)
+	assert.Equal(t, privilege.Update, schemas[0].Grants[1].Privileges[0])
+
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, "20230101000000_init"+schema.SnapshotSuffix), []byte("[]"), 0644)
+	assert.Nil(t, err)
+	err = os.WriteFile(filepath.Join(dir, "20230102000000_users"+schema.SnapshotSuffix), b, 0644)
+	assert.Nil(t, err)
+
+	crawler, err := schema.NewSnapshotSchema(dir)
+	assert.Nil(t, err)
+
+	result, err := crawler.GetSchemas(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "documents", result[0].Name)
+	assert.Equal(t, "users", result[1].Name)
+	assert.Equal(t, "index_users_on_email", result[1].Index[0].Name)
+	assert.Equal(t, &config.Grant{
+		Role:       "app",
+		Privileges: []privilege.Privilege{privilege.Insert, privilege.Update},
+	}, result[1].Grants[0])
+}
+
+func TestNewSnapshotSchema_Empty(t *testing.T) {
+	crawler, err := schema.NewSnapshotSchema(filepath.Join(t.TempDir(), "migration"))
+	assert.Nil(t, err)
+
+	result, err := crawler.GetSchemas(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, result)
+
+	dir := writeMigrations(t, map[string]string{
+		"20230101000000_users.up.sql": `CREATE TABLE "users" ("id" BIGSERIAL PRIMARY KEY);`,
+	})
+	_, err = schema.NewSnapshotSchema(dir)
+	assert.True(t, errors.Is(err, schema.ErrSnapshotNotExists))
+}
@@ -0,0 +1,471 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_type"
+
+	_ "github.com/doug-martin/goqu/v9/dialect/sqlite3"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	SQLiteDriver         = "sqlite"
+	SQLiteIndexOrigin    = "c"
+	SQLiteIntegerType    = "integer"
+	SQLiteInternalPrefix = "sqlite_"
+)
+
+var SQLiteFieldTypeMapper = map[string]field_type.FieldType{
+	"integer":   field_type.Int,
+	"boolean":   field_type.Boolean,
+	"character": field_type.Varchar,
+	"real":      field_type.Float,
+	"double":    field_type.Float,
+	"numeric":   field_type.Decimal,
+	"datetime":  field_type.Timestamp,
+}
+
+type SQLiteTableInfo struct {
+	Cid          int
+	Name         string
+	Type         string
+	NotNull      bool
+	DefaultValue sql.NullString
+	PrimaryKey   int
+}
+
+type sqliteSchema struct {
+	db SqlInterface
+
+	// tableFilter selects the crawled tables, nil skips the migration table only.
+	tableFilter *filter.TableFilter
+
+	tablesLoaded bool
+	tables       []string
+
+	indicesLoaded bool
+	indices       map[string]*Indices
+
+	primaryKeysLoaded bool
+	primaryKeys       map[string]*PrimaryKey
+}
+
+func NewSQLiteSchema(db SqlInterface) *sqliteSchema {
+	return &sqliteSchema{
+		db: db,
+	}
+}
+
+// WithTableFilter only crawls the tables matched by the filter.
+func (s *sqliteSchema) WithTableFilter(f *filter.TableFilter) *sqliteSchema {
+	s.tableFilter = f
+	return s
+}
+
+// Close closes the database the schema was crawled with, if it can be closed.
+func (s *sqliteSchema) Close() error {
+	if closer, ok := s.db.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// SQLitePath returns the database file of sqlite://path/to/file.db
+func SQLitePath(connString string) string {
+	return strings.SplitN(connString, "://", 2)[1]
+}
+
+func (s *sqliteSchema) GetSchemas(ctx context.Context) ([]*config.Schema, error) {
+	schemas := make([]*config.Schema, 0)
+
+	tables, err := s.GetTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, table := range tables {
+		fields, err := s.GetFields(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		indices, err := s.GetTablesIndices(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		schema := &config.Schema{
+			Name:   table,
+			Fields: fields,
+			Index:  indices,
+		}
+
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, nil
+}
+
+func (s *sqliteSchema) GetTables(ctx context.Context) ([]string, error) {
+	if s.tablesLoaded {
+		return s.tables, nil
+	}
+
+	query, _, err := goqu.Dialect("sqlite3").From("sqlite_master").
+		Where(
+			goqu.C("type").Eq("table"),
+			goqu.C("name").NotLike(SQLiteInternalPrefix+"%"),
+		).
+		Select("name").
+		Order(goqu.C("name").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make([]string, 0)
+	for rows.Next() {
+		var table string
+		err := rows.Scan(&table)
+		if err != nil {
+			return nil, err
+		}
+
+		if !s.tableFilter.Match(table) {
+			continue
+		}
+
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	s.tables = tables
+	s.tablesLoaded = true
+	return tables, nil
+}
+
+func (s *sqliteSchema) GetFields(ctx context.Context, name string) ([]*config.Field, error) {
+	infos, err := s.getTableInfo(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]*config.Field, 0, len(infos))
+	for _, info := range infos {
+		fields = append(fields, s.getField(info))
+	}
+	return fields, nil
+}
+
+func (s *sqliteSchema) getTableInfo(ctx context.Context, name string) ([]*SQLiteTableInfo, error) {
+	query, _, err := goqu.Dialect("sqlite3").
+		From(goqu.Func("pragma_table_info", name)).
+		Select("cid", "name", "type", "notnull", "dflt_value", "pk").
+		Order(goqu.C("cid").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := make([]*SQLiteTableInfo, 0)
+	for rows.Next() {
+		info := &SQLiteTableInfo{}
+		err := rows.Scan(&info.Cid, &info.Name, &info.Type, &info.NotNull, &info.DefaultValue, &info.PrimaryKey)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, rows.Err()
+}
+
+func (s *sqliteSchema) getField(info *SQLiteTableInfo) *config.Field {
+	dataType, limit, scale := s.ParseType(info.Type)
+
+	ft := SQLiteFieldTypeMapper[dataType]
+	if ft == "" {
+		ft = field_type.ParseString(dataType)
+	}
+
+	options := make([]field_option.FieldOption, 0)
+	if info.PrimaryKey == 1 {
+		options = append(options, field_option.PrimaryKey)
+
+		// INTEGER PRIMARY KEY is an alias of the 64 bit rowid
+		if dataType == SQLiteIntegerType {
+			ft = field_type.BigSerial
+		}
+	}
+
+	if info.NotNull {
+		options = append(options, field_option.NotNull)
+	}
+
+	field := &config.Field{
+		Name:    info.Name,
+		Type:    ft,
+		Limit:   limit,
+		Scale:   scale,
+		Options: options,
+	}
+
+	if info.DefaultValue.Valid {
+		field.Default = s.ParseDefaultValue(info.DefaultValue.String)
+	}
+
+	return field
+}
+
+// ParseType splits a declared type such as DECIMAL(5, 2) into
+// its lower cased name, limit and scale.
+func (s *sqliteSchema) ParseType(declared string) (string, int, int) {
+	name, args, found := strings.Cut(declared, "(")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !found {
+		return name, 0, 0
+	}
+
+	params := strings.Split(strings.TrimSuffix(strings.TrimSpace(args), ")"), ",")
+	limit, _ := strconv.Atoi(strings.TrimSpace(params[0]))
+	scale := 0
+	if len(params) > 1 {
+		scale, _ = strconv.Atoi(strings.TrimSpace(params[1]))
+	}
+
+	return name, limit, scale
+}
+
+func (s *sqliteSchema) ParseDefaultValue(value string) interface{} {
+	if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) > 1 {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+
+	valInt, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		return int(valInt)
+	}
+
+	valFloat, err := strconv.ParseFloat(value, 64)
+	if err == nil {
+		return valFloat
+	}
+	return value
+}
+
+func (s *sqliteSchema) GetPrimaryKeys(ctx context.Context) (map[string]*PrimaryKey, error) {
+	err := s.LoadPrimaryKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.primaryKeys, nil
+}
+
+func (s *sqliteSchema) LoadPrimaryKeys(ctx context.Context) error {
+	if s.primaryKeysLoaded {
+		return nil
+	}
+
+	tables, err := s.GetTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	primaryKeys := make(map[string]*PrimaryKey)
+	for _, table := range tables {
+		infos, err := s.getTableInfo(ctx, table)
+		if err != nil {
+			return err
+		}
+
+		for _, info := range infos {
+			if info.PrimaryKey == 1 {
+				primaryKeys[table] = &PrimaryKey{
+					Table:  table,
+					Column: info.Name,
+				}
+			}
+		}
+	}
+
+	s.primaryKeys = primaryKeys
+	s.primaryKeysLoaded = true
+	return nil
+}
+
+func (s *sqliteSchema) GetTablesIndices(ctx context.Context, name string) ([]*config.Index, error) {
+	indices, err := s.GetIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*config.Index, 0)
+	tableIndices := indices[name]
+	if tableIndices == nil {
+		return result, nil
+	}
+
+	for _, index := range tableIndices.Indices {
+		result = append(result, index)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (s *sqliteSchema) GetIndices(ctx context.Context) (map[string]*Indices, error) {
+	err := s.LoadIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.indices, nil
+}
+
+// LoadIndices loads the indices created by CREATE INDEX, indices backing
+// the primary key and unique constraints are part of the table definition.
+func (s *sqliteSchema) LoadIndices(ctx context.Context) error {
+	if s.indicesLoaded {
+		return nil
+	}
+
+	tables, err := s.GetTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	indices := make(map[string]*Indices)
+	for _, table := range tables {
+		tableIndices, err := s.getIndexList(ctx, table)
+		if err != nil {
+			return err
+		}
+
+		container := make(map[string]*config.Index)
+		for _, index := range tableIndices {
+			index.Fields, err = s.getIndexFields(ctx, index.Name)
+			if err != nil {
+				return err
+			}
+			container[index.Name] = index
+		}
+
+		indices[table] = &Indices{
+			Table:   table,
+			Indices: container,
+		}
+	}
+
+	s.indices = indices
+	s.indicesLoaded = true
+	return nil
+}
+
+func (s *sqliteSchema) getIndexList(ctx context.Context, table string) ([]*config.Index, error) {
+	query, _, err := goqu.Dialect("sqlite3").
+		From(goqu.Func("pragma_index_list", table)).
+		Where(goqu.C("origin").Eq(SQLiteIndexOrigin)).
+		Select("name", "unique").
+		Order(goqu.C("name").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indices := make([]*config.Index, 0)
+	for rows.Next() {
+		index := &config.Index{}
+		err := rows.Scan(&index.Name, &index.Unique)
+		if err != nil {
+			return nil, err
+		}
+		indices = append(indices, index)
+	}
+
+	return indices, rows.Err()
+}
+
+func (s *sqliteSchema) getIndexFields(ctx context.Context, index string) ([]*config.IndexField, error) {
+	query, _, err := goqu.Dialect("sqlite3").
+		From(goqu.Func("pragma_index_xinfo", index)).
+		Where(goqu.C("key").Eq(1)).
+		Select("name", "desc").
+		Order(goqu.C("seqno").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := make([]*config.IndexField, 0)
+	for rows.Next() {
+		var column sql.NullString
+		var desc bool
+		err := rows.Scan(&column, &desc)
+		if err != nil {
+			return nil, err
+		}
+
+		// expression key parts have no column
+		if !column.Valid {
+			continue
+		}
+
+		order := "ASC"
+		if desc {
+			order = "DESC"
+		}
+		fields = append(fields, &config.IndexField{
+			Column: column.String,
+			Order:  order,
+		})
+	}
+
+	return fields, rows.Err()
+}
+
+// GetGrants returns no grants as sqlite has no privilege system.
+func (s *sqliteSchema) GetGrants(ctx context.Context) (map[string][]*config.Grant, error) {
+	return make(map[string][]*config.Grant), nil
+}
+
+// GetStorages returns no storage parameters as they are postgres specific.
+func (s *sqliteSchema) GetStorages(ctx context.Context) (map[string]*config.Storage, error) {
+	return make(map[string]*config.Storage), nil
+}
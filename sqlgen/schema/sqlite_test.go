@@ -0,0 +1,134 @@
+package schema_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/schema"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+)
+
+func newSQLiteDB(t *testing.T, statements ...string) *sql.DB {
+	db, err := sql.Open(schema.SQLiteDriver, ":memory:")
+	assert.Nil(t, err)
+
+	// every connection of an in-memory database is a separate database
+	db.SetMaxOpenConns(1)
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		assert.Nil(t, err)
+	}
+	return db
+}
+
+func TestSQLite_GetSchemas(t *testing.T) {
+	db := newSQLiteDB(t,
+		`CREATE TABLE "example" (
+			"id" INTEGER PRIMARY KEY,
+			"name" VARCHAR(200) NOT NULL DEFAULT 'Alfred',
+			"price" DECIMAL(10, 2) DEFAULT 100.5,
+			"email" VARCHAR(100) UNIQUE
+		)`,
+		`CREATE INDEX "index_on_name" ON "example" ("name" DESC, "price")`,
+		`CREATE TABLE "schema_migrations" ("version" BIGINT)`,
+	)
+	defer db.Close()
+
+	sc := schema.NewSQLiteSchema(db)
+	result, err := sc.GetSchemas(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []*config.Schema{
+		{
+			Name: "example",
+			Fields: []*config.Field{
+				{
+					Name:    "id",
+					Type:    "bigserial",
+					Options: []field_option.FieldOption{field_option.PrimaryKey},
+				},
+				{
+					Name:    "name",
+					Type:    "varchar",
+					Limit:   200,
+					Default: "Alfred",
+					Options: []field_option.FieldOption{field_option.NotNull},
+				},
+				{
+					Name:    "price",
+					Type:    "decimal",
+					Limit:   10,
+					Scale:   2,
+					Default: 100.5,
+					Options: []field_option.FieldOption{},
+				},
+				{
+					Name:    "email",
+					Type:    "varchar",
+					Limit:   100,
+					Options: []field_option.FieldOption{},
+				},
+			},
+			Index: []*config.Index{
+				{
+					Name: "index_on_name",
+					Fields: []*config.IndexField{
+						{Column: "name", Order: "DESC"},
+						{Column: "price", Order: "ASC"},
+					},
+				},
+			},
+		},
+	}, result)
+}
+
+func TestSQLite_GetPrimaryKeys(t *testing.T) {
+	db := newSQLiteDB(t,
+		`CREATE TABLE "users" ("id" INTEGER PRIMARY KEY, "name" TEXT)`,
+		`CREATE TABLE "logs" ("message" TEXT)`,
+	)
+	defer db.Close()
+
+	sc := schema.NewSQLiteSchema(db)
+	result, err := sc.GetPrimaryKeys(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]*schema.PrimaryKey{
+		"users": {Table: "users", Column: "id"},
+	}, result)
+}
+
+func TestSQLite_ParseType(t *testing.T) {
+	testCases := map[string]struct {
+		name  string
+		limit int
+		scale int
+	}{
+		"INTEGER":         {name: "integer"},
+		"VARCHAR(100)":    {name: "varchar", limit: 100},
+		"DECIMAL(10, 2)":  {name: "decimal", limit: 10, scale: 2},
+		"decimal ( 5,1 )": {name: "decimal", limit: 5, scale: 1},
+	}
+
+	sc := schema.NewSQLiteSchema(nil)
+	for declared, tc := range testCases {
+		name, limit, scale := sc.ParseType(declared)
+		assert.Equal(t, tc.name, name, declared)
+		assert.Equal(t, tc.limit, limit, declared)
+		assert.Equal(t, tc.scale, scale, declared)
+	}
+}
+
+func TestSQLite_GetGrants(t *testing.T) {
+	sc := schema.NewSQLiteSchema(nil)
+	grants, err := sc.GetGrants(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, grants)
+}
+
+func TestConnectSQLite(t *testing.T) {
+	sc, err := schema.ConnectSQLite(context.Background(), "sqlite://"+t.TempDir()+"/local.db", schema.ConnectOptions{})
+	assert.Nil(t, err)
+	assert.NotNil(t, sc)
+}
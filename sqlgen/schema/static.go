@@ -0,0 +1,121 @@
+package schema
+
+import (
+	"context"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/filter"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+)
+
+// staticSchema serves already built schemas, such as the ones modelled
+// from DDL statements, through the crawler interface.
+type staticSchema struct {
+	all     []*config.Schema
+	schemas []*config.Schema
+}
+
+func NewStaticSchema(schemas []*config.Schema) *staticSchema {
+	s := &staticSchema{
+		all: schemas,
+	}
+	return s.WithTableFilter(nil)
+}
+
+// WithTableFilter only serves the tables matched by the filter.
+func (s *staticSchema) WithTableFilter(f *filter.TableFilter) *staticSchema {
+	s.schemas = make([]*config.Schema, 0, len(s.all))
+	for _, sc := range s.all {
+		if f.Match(sc.Name) {
+			s.schemas = append(s.schemas, sc)
+		}
+	}
+	return s
+}
+
+func (s *staticSchema) GetSchemas(ctx context.Context) ([]*config.Schema, error) {
+	return s.schemas, nil
+}
+
+func (s *staticSchema) GetTables(ctx context.Context) ([]string, error) {
+	tables := make([]string, 0, len(s.schemas))
+	for _, sc := range s.schemas {
+		tables = append(tables, sc.Name)
+	}
+	return tables, nil
+}
+
+func (s *staticSchema) GetIndices(ctx context.Context) (map[string]*Indices, error) {
+	indices := make(map[string]*Indices)
+	for _, sc := range s.schemas {
+		container := make(map[string]*config.Index)
+		for _, index := range sc.Index {
+			container[index.Name] = index
+		}
+
+		indices[sc.Name] = &Indices{
+			Table:   sc.Name,
+			Indices: container,
+		}
+	}
+	return indices, nil
+}
+
+func (s *staticSchema) GetFields(ctx context.Context, name string) ([]*config.Field, error) {
+	for _, sc := range s.schemas {
+		if sc.Name == name {
+			return sc.Fields, nil
+		}
+	}
+	return make([]*config.Field, 0), nil
+}
+
+func (s *staticSchema) GetPrimaryKeys(ctx context.Context) (map[string]*PrimaryKey, error) {
+	primaryKeys := make(map[string]*PrimaryKey)
+	for _, sc := range s.schemas {
+		for _, field := range sc.Fields {
+			if hasOption(field, field_option.PrimaryKey) {
+				primaryKeys[sc.Name] = &PrimaryKey{
+					Table:  sc.Name,
+					Column: field.Name,
+				}
+				break
+			}
+		}
+	}
+	return primaryKeys, nil
+}
+
+func (s *staticSchema) GetGrants(ctx context.Context) (map[string][]*config.Grant, error) {
+	grants := make(map[string][]*config.Grant)
+	for _, sc := range s.schemas {
+		if len(sc.Grants) > 0 {
+			grants[sc.Name] = sc.Grants
+		}
+	}
+	return grants, nil
+}
+
+func (s *staticSchema) GetStorages(ctx context.Context) (map[string]*config.Storage, error) {
+	storages := make(map[string]*config.Storage)
+	for _, sc := range s.schemas {
+		if sc.Storage != nil {
+			storages[sc.Name] = sc.Storage
+		}
+	}
+	return storages, nil
+}
+
+func hasOption(field *config.Field, option field_option.FieldOption) bool {
+	for _, opt := range field.Options {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Close releases nothing as the schemas are held in memory.
+func (s *staticSchema) Close() error {
+	return nil
+}
@@ -16,12 +16,13 @@ type AlterColumn struct {
 	Field               *config.Field
 	LastField           *config.Field
 	ChangedType         bool
+	ChangedCollation    bool
 	ChangedDefaultValue bool
 	ChangedOptions      []OptionAction
 }
 
 func (c *AlterColumn) HasChanges() bool {
-	return c.ChangedType || c.IsOptionsChanged()
+	return c.ChangedType || c.ChangedCollation || c.IsOptionsChanged()
 }
 
 func (c *AlterColumn) IsOptionsChanged() bool {
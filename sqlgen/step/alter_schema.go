@@ -2,6 +2,7 @@ package step
 
 import (
 	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
 )
 
 type AlterSchema struct {
@@ -10,8 +11,24 @@ type AlterSchema struct {
 	AlteredColumns []*AlterColumn
 	DroppedColumns []*config.Field
 
+	// ExpandedColumns are new columns filled from an existing one and kept
+	// in sync with it, ContractedColumns the existing columns of finished
+	// transitions, dropped once the application moved to the new column.
+	ExpandedColumns   []*ColumnTransition
+	ContractedColumns []*ColumnTransition
+
 	AddedIndices   []*config.Index
 	DroppedIndices []*config.Index
+
+	GrantedPrivileges []*config.Grant
+	RevokedPrivileges []*config.Grant
+
+	Storage *AlterStorage
+
+	// Schema and LastSchema are the whole target and existing table,
+	// used by dialects which rebuild the table instead of altering it.
+	Schema     *config.Schema
+	LastSchema *config.Schema
 }
 
 func NewAlterSchema(name string) *AlterSchema {
@@ -23,8 +40,28 @@ func NewAlterSchema(name string) *AlterSchema {
 	}
 }
 
+// PrimaryKey returns the primary key columns of the existing table, empty
+// when it has none.
+func (s *AlterSchema) PrimaryKey() []*config.Field {
+	primaryKey := make([]*config.Field, 0)
+	if s.LastSchema == nil {
+		return primaryKey
+	}
+	for _, field := range s.LastSchema.Fields {
+		if hasOption(field, field_option.PrimaryKey) {
+			primaryKey = append(primaryKey, field)
+		}
+	}
+	return primaryKey
+}
+
 func (s *AlterSchema) HasChanges() bool {
-	return s.FieldChanged() || s.IndicesChanged()
+	return s.FieldChanged() || s.TransitionsChanged() || s.IndicesChanged() || s.GrantsChanged() || s.IsStorageChanged()
+}
+
+func (s *AlterSchema) TransitionsChanged() bool {
+	return s.IsColumnsExpanded() ||
+		s.IsColumnsContracted()
 }
 
 func (s *AlterSchema) FieldChanged() bool {
@@ -38,6 +75,11 @@ func (s *AlterSchema) IndicesChanged() bool {
 		s.IsIndicesDropped()
 }
 
+func (s *AlterSchema) GrantsChanged() bool {
+	return s.IsPrivilegesGranted() ||
+		s.IsPrivilegesRevoked()
+}
+
 func (s *AlterSchema) IsColumnsAdded() bool {
 	return len(s.AddedColumns) != 0
 }
@@ -50,6 +92,14 @@ func (s *AlterSchema) IsColumnsDropped() bool {
 	return len(s.DroppedColumns) != 0
 }
 
+func (s *AlterSchema) IsColumnsExpanded() bool {
+	return len(s.ExpandedColumns) != 0
+}
+
+func (s *AlterSchema) IsColumnsContracted() bool {
+	return len(s.ContractedColumns) != 0
+}
+
 func (s *AlterSchema) IsIndicesAdded() bool {
 	return len(s.AddedIndices) != 0
 }
@@ -57,3 +107,15 @@ func (s *AlterSchema) IsIndicesAdded() bool {
 func (s *AlterSchema) IsIndicesDropped() bool {
 	return len(s.DroppedIndices) != 0
 }
+
+func (s *AlterSchema) IsPrivilegesGranted() bool {
+	return len(s.GrantedPrivileges) != 0
+}
+
+func (s *AlterSchema) IsPrivilegesRevoked() bool {
+	return len(s.RevokedPrivileges) != 0
+}
+
+func (s *AlterSchema) IsStorageChanged() bool {
+	return s.Storage != nil && s.Storage.HasChanges()
+}
@@ -0,0 +1,54 @@
+package step
+
+import "gitlab.com/wartek-id/core/tools/dbgen/config"
+
+type AlterStorage struct {
+	Storage     *config.Storage
+	LastStorage *config.Storage
+}
+
+func (s *AlterStorage) HasChanges() bool {
+	return len(s.SetParameters()) != 0 ||
+		len(s.ResetParameters()) != 0 ||
+		s.IsTablespaceChanged() ||
+		s.IsUnloggedChanged()
+}
+
+// SetParameters returns parameters which are added or changed.
+func (s *AlterStorage) SetParameters() []config.StorageParameter {
+	params := make([]config.StorageParameter, 0)
+	for _, param := range s.Storage.Parameters() {
+		value, ok := s.LastStorage.GetParameter(param.Name)
+		if !ok || value != param.Value {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// ResetParameters returns parameters which are no longer set.
+func (s *AlterStorage) ResetParameters() []config.StorageParameter {
+	params := make([]config.StorageParameter, 0)
+	for _, param := range s.LastStorage.Parameters() {
+		if _, ok := s.Storage.GetParameter(param.Name); !ok {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+func (s *AlterStorage) IsTablespaceChanged() bool {
+	return s.Storage.GetTablespace() != s.LastStorage.GetTablespace()
+}
+
+func (s *AlterStorage) IsUnloggedChanged() bool {
+	return s.Storage.IsUnlogged() != s.LastStorage.IsUnlogged()
+}
+
+// Reverse returns the storage changes needed to rollback s.
+func (s *AlterStorage) Reverse() *AlterStorage {
+	return &AlterStorage{
+		Storage:     s.LastStorage,
+		LastStorage: s.Storage,
+	}
+}
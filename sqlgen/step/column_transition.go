@@ -0,0 +1,40 @@
+package step
+
+import (
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+)
+
+// ColumnTransition moves the values of an existing column into a new one
+// without downtime. The expand phase adds the new column, keeps both in sync
+// with a trigger and backfills the existing rows, the application is then
+// deployed to use the new column, and the contract phase drops the existing
+// one.
+type ColumnTransition struct {
+	From *config.Field
+	To   *config.Field
+}
+
+// Nullable returns the field without the options which would fail on the
+// existing rows, they are set once the column is filled.
+func Nullable(field *config.Field) *config.Field {
+	nullable := *field
+	nullable.Options = make([]field_option.FieldOption, 0, len(field.Options))
+	for _, option := range field.Options {
+		if option != field_option.NotNull && option != field_option.PrimaryKey {
+			nullable.Options = append(nullable.Options, option)
+		}
+	}
+	return &nullable
+}
+
+// HasExpandedColumns reports whether the plan starts column transitions,
+// whose existing rows are backfilled by separate migrations.
+func (p *MigrationPlanner) HasExpandedColumns() bool {
+	for _, as := range p.AlterSchema {
+		if as.IsColumnsExpanded() {
+			return true
+		}
+	}
+	return false
+}
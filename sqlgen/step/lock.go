@@ -0,0 +1,183 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_type"
+)
+
+// LockMode is the Postgres table lock taken by a statement, from the weakest
+// to the strongest.
+type LockMode int
+
+const (
+	// ShareUpdateExclusive lets reads and writes go on.
+	ShareUpdateExclusive LockMode = iota
+	// Share blocks writes.
+	Share
+	// AccessExclusive blocks reads and writes.
+	AccessExclusive
+)
+
+func (m LockMode) String() string {
+	switch m {
+	case Share:
+		return "SHARE"
+	case AccessExclusive:
+		return "ACCESS EXCLUSIVE"
+	}
+	return "SHARE UPDATE EXCLUSIVE"
+}
+
+// Lock is the lock a statement takes on a table. Rewrite statements copy
+// every row into a new table and Long statements scan every row, both
+// holding their lock until done.
+type Lock struct {
+	Table   string
+	Mode    LockMode
+	Rewrite bool
+	Long    bool
+	Reasons []string
+}
+
+// BlocksWrites reports whether writes to the table wait for the lock.
+func (l *Lock) BlocksWrites() bool {
+	return l.Mode >= Share
+}
+
+// Disruptive locks hold the table for a time growing with its size, either
+// rewriting it or blocking its writes while scanning it.
+func (l *Lock) Disruptive() bool {
+	return l.Rewrite || (l.Long && l.BlocksWrites())
+}
+
+func (l *Lock) String() string {
+	impact := ""
+	switch {
+	case l.Rewrite:
+		impact = ", rewrites the table"
+	case l.Long && l.BlocksWrites():
+		impact = ", holds the lock while scanning the table"
+	case l.Long:
+		impact = ", scans the table without blocking writes"
+	}
+
+	if len(l.Reasons) == 0 {
+		return fmt.Sprintf("%s: %s%s", l.Table, l.Mode, impact)
+	}
+	return fmt.Sprintf("%s: %s%s (%s)", l.Table, l.Mode, impact, strings.Join(l.Reasons, ", "))
+}
+
+// AlterLock returns the lock of the ALTER TABLE statement changing the
+// columns and the storage of the table, nil when there is none.
+func (s *AlterSchema) AlterLock() *Lock {
+	if !s.FieldChanged() && !s.IsStorageChanged() {
+		return nil
+	}
+
+	lock := &Lock{Table: s.Name, Mode: ShareUpdateExclusive, Reasons: make([]string, 0)}
+	if s.FieldChanged() {
+		lock.Mode = AccessExclusive
+	}
+
+	for _, column := range s.AlteredColumns {
+		if column.ChangedType && rewritesType(column.LastField, column.Field) {
+			lock.Rewrite = true
+			lock.Reasons = append(lock.Reasons, fmt.Sprintf("change type of %s to %s", column.Name, TypeName(column.Field)))
+		}
+		for _, option := range column.ChangedOptions {
+			if option == SetNotNull {
+				lock.Long = true
+				lock.Reasons = append(lock.Reasons, fmt.Sprintf("set %s not null", column.Name))
+			}
+		}
+	}
+
+	if s.IsStorageChanged() {
+		if s.Storage.IsTablespaceChanged() {
+			lock.Mode = AccessExclusive
+			lock.Rewrite = true
+			lock.Reasons = append(lock.Reasons, "move to another tablespace")
+		}
+		if s.Storage.IsUnloggedChanged() {
+			lock.Mode = AccessExclusive
+			lock.Rewrite = true
+			lock.Reasons = append(lock.Reasons, "change logging")
+		}
+	}
+	return lock
+}
+
+// RollbackLock returns the lock of the ALTER TABLE statement rolling back
+// the changes of the table, nil when there is none.
+func (s *AlterSchema) RollbackLock() *Lock {
+	reversed := &AlterSchema{
+		Name:           s.Name,
+		AddedColumns:   s.DroppedColumns,
+		AlteredColumns: make([]*AlterColumn, 0, len(s.AlteredColumns)),
+		DroppedColumns: s.AddedColumns,
+	}
+	for _, column := range s.AlteredColumns {
+		options := make([]OptionAction, 0, len(column.ChangedOptions))
+		for _, option := range column.ChangedOptions {
+			if option == SetNotNull {
+				options = append(options, DropNotNull)
+			} else {
+				options = append(options, SetNotNull)
+			}
+		}
+		reversed.AlteredColumns = append(reversed.AlteredColumns, &AlterColumn{
+			Name:                column.Name,
+			Field:               column.LastField,
+			LastField:           column.Field,
+			ChangedType:         column.ChangedType,
+			ChangedCollation:    column.ChangedCollation,
+			ChangedDefaultValue: column.ChangedDefaultValue,
+			ChangedOptions:      options,
+		})
+	}
+	if s.Storage != nil {
+		reversed.Storage = s.Storage.Reverse()
+	}
+	return reversed.AlterLock()
+}
+
+// CreateIndexLock returns the lock taken while building the index, which
+// blocks writes unless the index is built concurrently.
+func CreateIndexLock(table string, index *config.Index, concurrently bool) *Lock {
+	lock := &Lock{Table: table, Mode: Share, Long: true, Reasons: []string{"create index " + index.Name}}
+	if concurrently {
+		lock.Mode = ShareUpdateExclusive
+	}
+	return lock
+}
+
+// DropIndexLock returns the lock taken while dropping the index.
+func DropIndexLock(table string, index *config.Index, concurrently bool) *Lock {
+	lock := &Lock{Table: table, Mode: AccessExclusive, Reasons: []string{"drop index " + index.Name}}
+	if concurrently {
+		lock.Mode = ShareUpdateExclusive
+	}
+	return lock
+}
+
+// DropTableLock returns the lock taken while dropping the table.
+func DropTableLock(table string) *Lock {
+	return &Lock{Table: table, Mode: AccessExclusive, Reasons: []string{"drop table"}}
+}
+
+// rewritesType tells the type changes which copy every row from the binary
+// compatible ones, which only relax the limits of the type.
+func rewritesType(from, to *config.Field) bool {
+	switch {
+	case (from.Type == field_type.Varchar || from.Type == field_type.Text) && to.Type == field_type.Text:
+		return false
+	case (from.Type == field_type.Varchar || from.Type == field_type.Text) && to.Type == field_type.Varchar:
+		return to.Limit > 0 && (from.Type == field_type.Text || from.Limit == 0 || to.Limit < from.Limit)
+	case from.Type == field_type.Decimal && to.Type == field_type.Decimal:
+		return to.Scale != from.Scale || (to.Limit > 0 && (from.Limit == 0 || to.Limit < from.Limit))
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package step_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
+)
+
+func TestAlterSchema_AlterLock(t *testing.T) {
+	testCases := map[string]struct {
+		as     *step.AlterSchema
+		result string
+	}{
+		"no change": {
+			as: step.NewAlterSchema("users"),
+		},
+		"add column": {
+			as:     &step.AlterSchema{Name: "users", AddedColumns: []*config.Field{{Name: "email", Type: "text"}}},
+			result: "users: ACCESS EXCLUSIVE",
+		},
+		"widen varchar": {
+			as: &step.AlterSchema{Name: "users", AlteredColumns: []*step.AlterColumn{{
+				Name:        "name",
+				LastField:   &config.Field{Name: "name", Type: "varchar", Limit: 100},
+				Field:       &config.Field{Name: "name", Type: "varchar", Limit: 200},
+				ChangedType: true,
+			}}},
+			result: "users: ACCESS EXCLUSIVE",
+		},
+		"varchar to text": {
+			as: &step.AlterSchema{Name: "users", AlteredColumns: []*step.AlterColumn{{
+				Name:        "bio",
+				LastField:   &config.Field{Name: "bio", Type: "varchar", Limit: 100},
+				Field:       &config.Field{Name: "bio", Type: "text"},
+				ChangedType: true,
+			}}},
+			result: "users: ACCESS EXCLUSIVE",
+		},
+		"int to bigint": {
+			as: &step.AlterSchema{Name: "users", AlteredColumns: []*step.AlterColumn{{
+				Name:        "age",
+				LastField:   &config.Field{Name: "age", Type: "int"},
+				Field:       &config.Field{Name: "age", Type: "bigint"},
+				ChangedType: true,
+			}}},
+			result: "users: ACCESS EXCLUSIVE, rewrites the table (change type of age to bigint)",
+		},
+		"set not null": {
+			as: &step.AlterSchema{Name: "users", AlteredColumns: []*step.AlterColumn{{
+				Name:           "email",
+				LastField:      &config.Field{Name: "email", Type: "text"},
+				Field:          &config.Field{Name: "email", Type: "text"},
+				ChangedOptions: []step.OptionAction{step.SetNotNull},
+			}}},
+			result: "users: ACCESS EXCLUSIVE, holds the lock while scanning the table (set email not null)",
+		},
+		"storage parameters": {
+			as: &step.AlterSchema{Name: "users", Storage: &step.AlterStorage{
+				Storage:     &config.Storage{Fillfactor: 70},
+				LastStorage: &config.Storage{},
+			}},
+			result: "users: SHARE UPDATE EXCLUSIVE",
+		},
+		"tablespace": {
+			as: &step.AlterSchema{Name: "users", Storage: &step.AlterStorage{
+				Storage:     &config.Storage{Tablespace: "fast"},
+				LastStorage: &config.Storage{},
+			}},
+			result: "users: ACCESS EXCLUSIVE, rewrites the table (move to another tablespace)",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			lock := tc.as.AlterLock()
+			if tc.result == "" {
+				assert.Nil(t, lock)
+				return
+			}
+			assert.Equal(t, tc.result, lock.String())
+		})
+	}
+}
+
+func TestAlterSchema_RollbackLock(t *testing.T) {
+	as := &step.AlterSchema{Name: "users", AlteredColumns: []*step.AlterColumn{{
+		Name:           "name",
+		LastField:      &config.Field{Name: "name", Type: "varchar", Limit: 100},
+		Field:          &config.Field{Name: "name", Type: "varchar", Limit: 200},
+		ChangedType:    true,
+		ChangedOptions: []step.OptionAction{step.SetNotNull},
+	}}}
+
+	assert.Equal(t, "users: ACCESS EXCLUSIVE, holds the lock while scanning the table (set name not null)", as.AlterLock().String())
+	assert.Equal(t, "users: ACCESS EXCLUSIVE, rewrites the table (change type of name to varchar(100))", as.RollbackLock().String())
+}
+
+func TestIndexLocks(t *testing.T) {
+	index := &config.Index{Name: "index_users_on_email"}
+
+	lock := step.CreateIndexLock("users", index, false)
+	assert.True(t, lock.Disruptive())
+	assert.Equal(t, "users: SHARE, holds the lock while scanning the table (create index index_users_on_email)", lock.String())
+
+	lock = step.CreateIndexLock("users", index, true)
+	assert.False(t, lock.Disruptive())
+	assert.Equal(t, "users: SHARE UPDATE EXCLUSIVE, scans the table without blocking writes (create index index_users_on_email)", lock.String())
+
+	lock = step.DropIndexLock("users", index, false)
+	assert.False(t, lock.Disruptive())
+	assert.Equal(t, "users: ACCESS EXCLUSIVE (drop index index_users_on_email)", lock.String())
+
+	assert.Equal(t, "users: ACCESS EXCLUSIVE (drop table)", step.DropTableLock("users").String())
+}
@@ -1,6 +1,10 @@
 package step
 
-import "gitlab.com/wartek-id/core/tools/dbgen/config"
+import (
+	"sort"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+)
 
 type MigrationPlanner struct {
 	CreateTable []*config.Schema
@@ -15,3 +19,20 @@ func NewMigrationPlanner() *MigrationPlanner {
 		AlterSchema: make(map[string]*AlterSchema),
 	}
 }
+
+// AlteredTables returns the altered tables sorted by name.
+func (p *MigrationPlanner) AlteredTables() []*AlterSchema {
+	return SortAlterSchemas(p.AlterSchema)
+}
+
+// SortAlterSchemas returns the altered tables sorted by name.
+func SortAlterSchemas(alterSchemas map[string]*AlterSchema) []*AlterSchema {
+	sorted := make([]*AlterSchema, 0, len(alterSchemas))
+	for _, as := range alterSchemas {
+		sorted = append(sorted, as)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,238 @@
+package step
+
+import (
+	"fmt"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_type"
+)
+
+// Risk tells what a change may do to the existing data.
+type Risk int
+
+const (
+	// Safe changes keep every existing value.
+	Safe Risk = iota
+	// Risky changes keep the data but may fail, e.g. on duplicated or null
+	// values, or change how it is served.
+	Risky
+	// Destructive changes lose data.
+	Destructive
+)
+
+func (r Risk) String() string {
+	switch r {
+	case Risky:
+		return "risky"
+	case Destructive:
+		return "destructive"
+	}
+	return "safe"
+}
+
+// Change is a single step of the plan classified by its risk.
+type Change struct {
+	Table  string
+	Column string
+	Action string
+	Risk   Risk
+	Reason string
+
+	// Acknowledged destructive changes are allowed by the target schema.
+	Acknowledged bool
+}
+
+func (c *Change) String() string {
+	object := c.Table
+	if c.Column != "" {
+		object = fmt.Sprintf("%s.%s", c.Table, c.Column)
+	}
+
+	if c.Reason == "" {
+		return fmt.Sprintf("%s: %s", object, c.Action)
+	}
+	return fmt.Sprintf("%s: %s, %s", object, c.Action, c.Reason)
+}
+
+var integerWidths = map[field_type.FieldType]int{
+	field_type.SmallInt:    2,
+	field_type.SmallSerial: 2,
+	field_type.Int:         4,
+	field_type.Serial:      4,
+	field_type.BigInt:      8,
+	field_type.BigSerial:   8,
+}
+
+// Changes classifies every step of the plan: created tables first, then
+// dropped tables unless skipped, then the altered tables by name.
+func (p *MigrationPlanner) Changes(skipDropTable bool) []*Change {
+	changes := make([]*Change, 0)
+	for _, sc := range p.CreateTable {
+		changes = append(changes, &Change{Table: sc.Name, Action: "create table", Risk: Safe})
+	}
+
+	if !skipDropTable {
+		for _, sc := range p.DropTable {
+			changes = append(changes, &Change{
+				Table:  sc.Name,
+				Action: "drop table",
+				Risk:   Destructive,
+				Reason: "every row is lost",
+			})
+		}
+	}
+
+	for _, as := range p.AlteredTables() {
+		changes = append(changes, as.Changes()...)
+	}
+	return changes
+}
+
+// Changes classifies the column, index, grant and storage changes of the table.
+func (s *AlterSchema) Changes() []*Change {
+	changes := make([]*Change, 0)
+	add := func(column, action string, risk Risk, reason string) {
+		changes = append(changes, &Change{
+			Table:        s.Name,
+			Column:       column,
+			Action:       action,
+			Risk:         risk,
+			Reason:       reason,
+			Acknowledged: risk == Destructive && s.Schema != nil && s.Schema.AllowsDestructive(column),
+		})
+	}
+
+	for _, field := range s.AddedColumns {
+		if field.IsNotNull() && field.Default == nil && !isSerial(field.Type) && !hasOption(field, field_option.PrimaryKey) {
+			add(field.Name, "add column", Risky, "fails when the table has rows as it is not null without default")
+			continue
+		}
+		add(field.Name, "add column", Safe, "")
+	}
+
+	for _, column := range s.AlteredColumns {
+		if column.ChangedType {
+			risk, reason := typeChangeRisk(column.LastField, column.Field)
+			add(column.Name, fmt.Sprintf("change type %s to %s", TypeName(column.LastField), TypeName(column.Field)), risk, reason)
+		}
+
+		if column.ChangedCollation {
+			add(column.Name, "change collation", Risky, "changes the ordering and comparison of existing values")
+		}
+
+		for _, option := range column.ChangedOptions {
+			if option == SetNotNull {
+				add(column.Name, "set not null", Risky, "fails when the column has null values")
+			} else {
+				add(column.Name, "drop not null", Safe, "")
+			}
+		}
+	}
+
+	for _, field := range s.DroppedColumns {
+		add(field.Name, "drop column", Destructive, "every value of the column is lost")
+	}
+
+	for _, transition := range s.ExpandedColumns {
+		add(transition.To.Name, fmt.Sprintf("expand from %s", transition.From.Name), Safe, "")
+	}
+
+	for _, transition := range s.ContractedColumns {
+		add(transition.From.Name, fmt.Sprintf("contract into %s", transition.To.Name), Risky, "fails the application still using the column")
+	}
+
+	for _, index := range s.DroppedIndices {
+		add("", fmt.Sprintf("drop index %s", index.Name), Safe, "")
+	}
+
+	for _, index := range s.AddedIndices {
+		if index.Unique {
+			add("", fmt.Sprintf("create unique index %s", index.Name), Risky, "fails when existing values are duplicated")
+			continue
+		}
+		add("", fmt.Sprintf("create index %s", index.Name), Safe, "")
+	}
+
+	for _, grant := range s.RevokedPrivileges {
+		add("", fmt.Sprintf("revoke from %s", grant.Role), Risky, "the role loses access to the table")
+	}
+
+	for _, grant := range s.GrantedPrivileges {
+		add("", fmt.Sprintf("grant to %s", grant.Role), Safe, "")
+	}
+
+	if s.IsStorageChanged() {
+		if s.Storage.IsUnloggedChanged() && s.Storage.Storage.IsUnlogged() {
+			add("", "set unlogged", Risky, "the table is truncated after a crash")
+		} else {
+			add("", "alter storage", Safe, "")
+		}
+	}
+
+	return changes
+}
+
+// TypeName returns the type of the field with its limit and scale, e.g. varchar(255).
+func TypeName(field *config.Field) string {
+	switch {
+	case field.Type.HasScale() && field.Limit > 0:
+		return fmt.Sprintf("%s(%d,%d)", field.Type, field.Limit, field.Scale)
+	case field.Type.HasLimit() && field.Limit > 0:
+		return fmt.Sprintf("%s(%d)", field.Type, field.Limit)
+	}
+	return string(field.Type)
+}
+
+// typeChangeRisk tells narrowing conversions, which fail the migration or
+// truncate the values not fitting the new type depending on the cast, from
+// widening ones and the others which may fail.
+func typeChangeRisk(from, to *config.Field) (Risk, string) {
+	fromWidth, fromInteger := integerWidths[from.Type]
+	toWidth, toInteger := integerWidths[to.Type]
+
+	switch {
+	case fromInteger && toInteger:
+		if toWidth < fromWidth {
+			return Destructive, fmt.Sprintf("values out of the range of %s fail the migration or are truncated, depending on the cast", to.Type)
+		}
+		return Safe, ""
+
+	case from.Type == field_type.Varchar && to.Type == field_type.Text,
+		fromInteger && to.Type == field_type.Decimal && to.Limit == 0:
+		return Safe, ""
+
+	case (from.Type == field_type.Varchar || from.Type == field_type.Text) && to.Type == field_type.Varchar:
+		if to.Limit > 0 && (from.Type == field_type.Text || from.Limit == 0 || to.Limit < from.Limit) {
+			return Destructive, fmt.Sprintf("values longer than %d characters fail the migration or are truncated, depending on the cast", to.Limit)
+		}
+		return Safe, ""
+
+	case from.Type == field_type.Decimal && to.Type == field_type.Decimal:
+		if to.Limit > 0 && (from.Limit == 0 || to.Limit-to.Scale < from.Limit-from.Scale) {
+			return Destructive, "values out of the new precision fail the migration or are truncated, depending on the cast"
+		}
+		if to.Limit > 0 && to.Scale < from.Scale {
+			return Destructive, fmt.Sprintf("values are rounded to %d decimal places", to.Scale)
+		}
+		return Safe, ""
+
+	case (from.Type == field_type.Decimal || from.Type == field_type.Float) && toInteger:
+		return Destructive, "the fractional part of the values is lost"
+	}
+
+	return Risky, "fails when existing values cannot be converted"
+}
+
+func isSerial(ft field_type.FieldType) bool {
+	return ft == field_type.SmallSerial || ft == field_type.Serial || ft == field_type.BigSerial
+}
+
+func hasOption(field *config.Field, option field_option.FieldOption) bool {
+	for _, opt := range field.Options {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package step_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/diff"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+func TestMigrationPlanner_Changes(t *testing.T) {
+	from := []*config.Schema{
+		{Name: "logs"},
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigint"},
+				{Name: "name", Type: "varchar", Limit: 255},
+				{Name: "bio", Type: "varchar", Limit: 100},
+				{Name: "age", Type: "bigint"},
+				{Name: "email", Type: "text"},
+				{Name: "salary", Type: "decimal", Limit: 10, Scale: 2},
+				{Name: "legacy", Type: "text"},
+			},
+			Grants: []*config.Grant{{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select, privilege.Insert}}},
+		},
+	}
+
+	target := []*config.Schema{
+		{Name: "accounts"},
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "id", Type: "bigint"},
+				{Name: "name", Type: "varchar", Limit: 45},
+				{Name: "bio", Type: "text"},
+				{Name: "age", Type: "int"},
+				{Name: "email", Type: "text", Options: []field_option.FieldOption{field_option.NotNull}},
+				{Name: "salary", Type: "int"},
+				{Name: "nickname", Type: "varchar", Limit: 50, Options: []field_option.FieldOption{field_option.NotNull}},
+				{Name: "active", Type: "bool", Default: true, Options: []field_option.FieldOption{field_option.NotNull}},
+			},
+			Index: []*config.Index{
+				{Name: "index_users_on_email", Fields: []*config.IndexField{{Column: "email"}}, Unique: true},
+			},
+			Grants:           []*config.Grant{{Role: "reporting", Privileges: []privilege.Privilege{privilege.Select}}},
+			AllowDestructive: []string{"legacy"},
+		},
+	}
+
+	plan, err := diff.NewSchema(from, target).GeneratePlan()
+	assert.Nil(t, err)
+
+	result := make([]string, 0)
+	for _, change := range plan.Changes(false) {
+		result = append(result, change.Risk.String()+" "+change.String())
+	}
+	assert.Equal(t, []string{
+		"safe accounts: create table",
+		"destructive logs: drop table, every row is lost",
+		"risky users.nickname: add column, fails when the table has rows as it is not null without default",
+		"safe users.active: add column",
+		"destructive users.name: change type varchar(255) to varchar(45), values longer than 45 characters fail the migration or are truncated, depending on the cast",
+		"safe users.bio: change type varchar(100) to text",
+		"destructive users.age: change type bigint to int, values out of the range of int fail the migration or are truncated, depending on the cast",
+		"risky users.email: set not null, fails when the column has null values",
+		"destructive users.salary: change type decimal(10,2) to int, the fractional part of the values is lost",
+		"destructive users.legacy: drop column, every value of the column is lost",
+		"risky users: create unique index index_users_on_email, fails when existing values are duplicated",
+		"risky users: revoke from reporting, the role loses access to the table",
+	}, result)
+
+	for _, change := range plan.Changes(true) {
+		assert.NotEqual(t, "drop table", change.Action)
+		assert.Equal(t, change.Column == "legacy", change.Acknowledged, change.String())
+	}
+}
+
+func TestMigrationPlanner_ChangesTransitions(t *testing.T) {
+	from := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "mail", Type: "text"},
+				{Name: "nick", Type: "text"},
+				{Name: "name", Type: "text"},
+			},
+		},
+	}
+	target := []*config.Schema{
+		{
+			Name: "users",
+			Fields: []*config.Field{
+				{Name: "email", Type: "text", RenamedFrom: "mail"},
+				{Name: "name", Type: "text"},
+				{Name: "nickname", Type: "text", RenamedFrom: "nick"},
+			},
+		},
+	}
+
+	plan, err := diff.NewSchema(from, target).GeneratePlan()
+	assert.Nil(t, err)
+
+	result := make([]string, 0)
+	for _, change := range plan.Changes(false) {
+		result = append(result, change.Risk.String()+" "+change.String())
+	}
+	assert.Equal(t, []string{
+		"safe users.email: expand from mail",
+		"safe users.nickname: expand from nick",
+	}, result)
+
+	from[0].Fields = append(from[0].Fields, &config.Field{Name: "email", Type: "text"}, &config.Field{Name: "nickname", Type: "text"})
+	plan, err = diff.NewSchema(from, target).GeneratePlan()
+	assert.Nil(t, err)
+
+	result = make([]string, 0)
+	for _, change := range plan.Changes(false) {
+		result = append(result, change.Risk.String()+" "+change.String())
+	}
+	assert.Equal(t, []string{
+		"risky users.mail: contract into email, fails the application still using the column",
+		"risky users.nick: contract into nickname, fails the application still using the column",
+	}, result)
+}
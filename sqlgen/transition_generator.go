@@ -0,0 +1,240 @@
+package sqlgen
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dialect"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/exp"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/sb"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
+)
+
+// DefaultBackfillBatchSize is the number of rows updated per transaction
+// when backfilling an expanded column.
+const DefaultBackfillBatchSize = 1000
+
+// TransitionGenerator generates the phases of a column transition: the
+// expand phase adds the new column with a trigger keeping both columns in
+// sync, the backfill fills the existing rows in batches, and the contract
+// phase drops the trigger and the existing column.
+type TransitionGenerator interface {
+	Dialect() string
+	DialectOptions() *dialect.DialectOption
+	ExpressionSQLGenerator() exp.ExpressionSQLGenerator
+	Expand(b sb.SQLBuilder, tblName string, transition *step.ColumnTransition)
+	RollbackExpand(b sb.SQLBuilder, tblName string, transition *step.ColumnTransition)
+	Backfill(b sb.SQLBuilder, tblName string, primaryKey []*config.Field, transition *step.ColumnTransition, batchSize int)
+	Contract(b sb.SQLBuilder, tblName string, transition *step.ColumnTransition)
+	RollbackContract(b sb.SQLBuilder, tblName string, transition *step.ColumnTransition)
+}
+
+type transitionGenerator struct {
+	dialect        string
+	esg            exp.ExpressionSQLGenerator
+	dialectOptions *dialect.DialectOption
+	atg            AlterTableGenerator
+}
+
+func NewTransitionGenerator(dialect string, do *dialect.DialectOption) TransitionGenerator {
+	return &transitionGenerator{
+		dialect:        dialect,
+		dialectOptions: do,
+		esg:            exp.NewExpressionSQLGenerator(dialect, do),
+		atg:            NewAlterTableGenerator(dialect, do),
+	}
+}
+
+func (tg *transitionGenerator) Dialect() string {
+	return tg.dialect
+}
+
+func (tg *transitionGenerator) DialectOptions() *dialect.DialectOption {
+	return tg.dialectOptions
+}
+
+func (tg *transitionGenerator) ExpressionSQLGenerator() exp.ExpressionSQLGenerator {
+	return tg.esg
+}
+
+func (tg *transitionGenerator) Expand(b sb.SQLBuilder, tblName string, transition *step.ColumnTransition) {
+	tg.atg.Generate(b, &step.AlterSchema{Name: tblName, AddedColumns: []*config.Field{step.Nullable(transition.To)}})
+	b.WriteNewLine()
+	b.WriteNewLine()
+	tg.syncTrigger(b, tblName, transition)
+}
+
+func (tg *transitionGenerator) RollbackExpand(b sb.SQLBuilder, tblName string, transition *step.ColumnTransition) {
+	tg.dropSyncTrigger(b, tblName, transition)
+	b.WriteNewLine()
+	tg.atg.Generate(b, &step.AlterSchema{Name: tblName, DroppedColumns: []*config.Field{transition.To}})
+}
+
+// Backfill copies the existing column into the new one a batch of rows at a
+// time, committing every batch so the rows are not locked until the end. The
+// batches walk the primary key from the last key of the previous batch, so
+// every row is read once. The block commits by itself, it cannot run inside
+// a transaction.
+func (tg *transitionGenerator) Backfill(b sb.SQLBuilder, tblName string, primaryKey []*config.Field, transition *step.ColumnTransition, batchSize int) {
+	if batchSize <= 0 {
+		batchSize = DefaultBackfillBatchSize
+	}
+
+	columns := make([]string, 0, len(primaryKey))
+	descending := make([]string, 0, len(primaryKey))
+	variables := make([]string, 0, len(primaryKey))
+	declarations := make([]string, 0, len(primaryKey))
+	for i, field := range primaryKey {
+		column := tg.quote(field.Name)
+		variable := fmt.Sprintf("_last_key_%d", i+1)
+		columns = append(columns, column)
+		descending = append(descending, column+" DESC")
+		variables = append(variables, variable)
+		declarations = append(declarations, fmt.Sprintf("\t%s %s.%s%%TYPE;\n", variable, tg.quote(tblName), column))
+	}
+	key, last := tuple(columns), tuple(variables)
+
+	b.WriteString(fmt.Sprintf(`DO $$
+DECLARE
+%[1]sBEGIN
+	LOOP
+		WITH batch AS (
+			SELECT %[2]s FROM %[3]s WHERE %[4]s IS NULL OR %[5]s > %[6]s ORDER BY %[2]s LIMIT %[7]d
+		), updated AS (
+			UPDATE %[3]s SET %[8]s = %[9]s WHERE %[5]s IN (SELECT %[2]s FROM batch) AND %[8]s IS DISTINCT FROM %[9]s
+		)
+		SELECT %[2]s INTO %[10]s FROM batch ORDER BY %[11]s LIMIT 1;
+		EXIT WHEN NOT FOUND;
+		COMMIT;
+	END LOOP;
+END $$;`, strings.Join(declarations, ""), strings.Join(columns, ", "), tg.quote(tblName), variables[0], key, last,
+		batchSize, tg.quote(transition.To.Name), tg.forward(tblName, transition, ""), strings.Join(variables, ", "), strings.Join(descending, ", ")))
+}
+
+func (tg *transitionGenerator) Contract(b sb.SQLBuilder, tblName string, transition *step.ColumnTransition) {
+	tg.dropSyncTrigger(b, tblName, transition)
+	b.WriteNewLine()
+	tg.atg.Generate(b, &step.AlterSchema{Name: tblName, DroppedColumns: []*config.Field{transition.From}})
+}
+
+// RollbackContract adds the existing column back, kept in sync again, and
+// fills it from the new column when the values can be converted back.
+func (tg *transitionGenerator) RollbackContract(b sb.SQLBuilder, tblName string, transition *step.ColumnTransition) {
+	tg.atg.Generate(b, &step.AlterSchema{Name: tblName, AddedColumns: []*config.Field{step.Nullable(transition.From)}})
+	b.WriteNewLine()
+	b.WriteNewLine()
+	tg.syncTrigger(b, tblName, transition)
+
+	backward, ok := tg.backward(transition, "")
+	if !ok {
+		return
+	}
+	b.WriteNewLine()
+	b.WriteNewLine()
+	b.WriteString(fmt.Sprintf("UPDATE %s SET %s = %s;", tg.quote(tblName), tg.quote(transition.From.Name), backward))
+}
+
+// syncTrigger keeps the columns in sync whichever one is written, so the
+// application can be deployed while the transition is on. Values which cannot
+// be converted back are only synced from the existing column.
+func (tg *transitionGenerator) syncTrigger(b sb.SQLBuilder, tblName string, transition *step.ColumnTransition) {
+	from := tg.column("NEW", transition.From.Name)
+	to := tg.column("NEW", transition.To.Name)
+	forward := tg.forward(tblName, transition, "NEW")
+	backward, ok := tg.backward(transition, "NEW")
+
+	name := tg.quote(tg.triggerName(tblName, transition))
+	b.WriteString(fmt.Sprintf("CREATE OR REPLACE %s%s() RETURNS trigger AS $$\nBEGIN\n", tg.dialectOptions.FunctionFragment, name))
+	b.WriteString("\tIF TG_OP = 'INSERT' THEN\n")
+	b.WriteString(fmt.Sprintf("\t\tIF %s IS NULL THEN\n\t\t\t%s := %s;\n", to, to, forward))
+	if ok {
+		b.WriteString(fmt.Sprintf("\t\tELSIF %s IS NULL THEN\n\t\t\t%s := %s;\n", from, from, backward))
+	}
+	b.WriteString("\t\tEND IF;\n")
+	b.WriteString(fmt.Sprintf("\tELSIF %s IS DISTINCT FROM %s THEN\n\t\t%s := %s;\n", from, tg.column("OLD", transition.From.Name), to, forward))
+	if ok {
+		b.WriteString(fmt.Sprintf("\tELSIF %s IS DISTINCT FROM %s AND %s IS DISTINCT FROM %s THEN\n\t\t%s := %s;\n",
+			to, tg.column("OLD", transition.To.Name), to, forward, from, backward))
+	}
+	b.WriteString("\tEND IF;\n\tRETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;")
+	b.WriteNewLine()
+	b.WriteNewLine()
+
+	b.Write(tg.dialectOptions.CreateClause)
+	b.Write(tg.dialectOptions.TriggerFragment)
+	b.WriteString(name)
+	b.WriteString(" BEFORE INSERT OR UPDATE")
+	b.Write(tg.dialectOptions.OnFragment)
+	b.WriteString(tg.quote(tblName))
+	b.WriteString(fmt.Sprintf(" FOR EACH ROW EXECUTE %s%s();", tg.dialectOptions.FunctionFragment, name))
+}
+
+func (tg *transitionGenerator) dropSyncTrigger(b sb.SQLBuilder, tblName string, transition *step.ColumnTransition) {
+	name := tg.quote(tg.triggerName(tblName, transition))
+
+	b.Write(tg.dialectOptions.DropClause)
+	b.Write(tg.dialectOptions.TriggerFragment)
+	b.Write(tg.dialectOptions.IfExistsFragment)
+	b.WriteString(name)
+	b.Write(tg.dialectOptions.OnFragment)
+	b.WriteString(tg.quote(tblName))
+	b.WriteRunes(tg.dialectOptions.SemiColonRune)
+	b.WriteNewLine()
+
+	b.Write(tg.dialectOptions.DropClause)
+	b.Write(tg.dialectOptions.FunctionFragment)
+	b.Write(tg.dialectOptions.IfExistsFragment)
+	b.WriteString(name)
+	b.WriteRunes(tg.dialectOptions.LeftParenRune, tg.dialectOptions.RightParenRune, tg.dialectOptions.SemiColonRune)
+	b.WriteNewLine()
+}
+
+// forward converts the existing column of the row into the new one, with the
+// USING expression of the new field when it has one.
+func (tg *transitionGenerator) forward(tblName string, transition *step.ColumnTransition, row string) string {
+	if transition.To.Using != "" {
+		if row == "" {
+			return transition.To.Using
+		}
+		return fmt.Sprintf("(SELECT %s FROM (SELECT %s.*) AS %s)", transition.To.Using, row, tg.quote(tblName))
+	}
+
+	cast := tg.dialectOptions.Cast(transition.From.Type, transition.To.Type)
+	return castValue(tg.ExpressionSQLGenerator(), cast, tg.column(row, transition.From.Name), transition.To)
+}
+
+// backward converts the new column of the row into the existing one, when
+// the dialect has a conversion.
+func (tg *transitionGenerator) backward(transition *step.ColumnTransition, row string) (string, bool) {
+	cast := tg.dialectOptions.Cast(transition.To.Type, transition.From.Type)
+	if cast.Kind == dialect.CastImpossible {
+		return "", false
+	}
+	return castValue(tg.ExpressionSQLGenerator(), cast, tg.column(row, transition.To.Name), transition.From), true
+}
+
+func (tg *transitionGenerator) triggerName(tblName string, transition *step.ColumnTransition) string {
+	return tblName + tg.dialectOptions.SyncTriggerInfix + transition.To.Name
+}
+
+func (tg *transitionGenerator) column(row, name string) string {
+	if row == "" {
+		return tg.quote(name)
+	}
+	return row + "." + tg.quote(name)
+}
+
+// tuple groups the values of a composite key into a row.
+func tuple(values []string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return "(" + strings.Join(values, ", ") + ")"
+}
+
+func (tg *transitionGenerator) quote(name string) string {
+	b := sb.NewSQLBuilder()
+	tg.ExpressionSQLGenerator().LiteralExpression(b, name)
+	return b.String()
+}
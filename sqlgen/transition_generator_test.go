@@ -0,0 +1,127 @@
+package sqlgen_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/config"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/dialect"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/sb"
+	"gitlab.com/wartek-id/core/tools/dbgen/sqlgen/step"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/field_option"
+)
+
+func TestTransitionGenerator_Dialect(t *testing.T) {
+	dial := "postgres"
+	do := dialect.DefaultDialectOption()
+
+	sqlGen := sqlgen.NewTransitionGenerator(dial, do)
+	assert.Equal(t, dial, sqlGen.Dialect())
+}
+
+func TestTransitionGenerator_DialectOptions(t *testing.T) {
+	dial := "postgres"
+	do := dialect.DefaultDialectOption()
+
+	sqlGen := sqlgen.NewTransitionGenerator(dial, do)
+	assert.Equal(t, do, sqlGen.DialectOptions())
+}
+
+func TestTransitionGenerator_ExpressionSQLGenerator(t *testing.T) {
+	dial := "postgres"
+	do := dialect.DefaultDialectOption()
+
+	sqlGen := sqlgen.NewTransitionGenerator(dial, do)
+	assert.NotNil(t, sqlGen.ExpressionSQLGenerator())
+}
+
+func TestTransitionGenerator_Generate(t *testing.T) {
+	sqlGen := sqlgen.NewTransitionGenerator("postgres", dialect.DefaultDialectOption())
+	rename := &step.ColumnTransition{
+		From: &config.Field{Name: "mail", Type: "varchar", Limit: 100},
+		To:   &config.Field{Name: "email", Type: "varchar", Limit: 100, Options: []field_option.FieldOption{field_option.NotNull}},
+	}
+	retype := &step.ColumnTransition{
+		From: &config.Field{Name: "active", Type: "bool"},
+		To:   &config.Field{Name: "status", Type: "int", RenamedFrom: "active"},
+	}
+	id := &config.Field{Name: "id", Type: "bigserial", Options: []field_option.FieldOption{field_option.PrimaryKey}}
+	tenantID := &config.Field{Name: "tenant_id", Type: "bigint", Options: []field_option.FieldOption{field_option.PrimaryKey}}
+	using := &step.ColumnTransition{
+		From: &config.Field{Name: "created", Type: "bigint"},
+		To:   &config.Field{Name: "created_at", Type: "timestamp", Using: `to_timestamp("created")`},
+	}
+
+	testCases := map[string]struct {
+		generate func(sb.SQLBuilder)
+		result   string
+	}{
+		"expand rename": {
+			generate: func(b sb.SQLBuilder) { sqlGen.Expand(b, "users", rename) },
+			result: "ALTER TABLE IF EXISTS \"users\"\n\tADD COLUMN \"email\" VARCHAR(100);\n\n" +
+				"CREATE OR REPLACE FUNCTION \"users_sync_email\"() RETURNS trigger AS $$\nBEGIN\n" +
+				"\tIF TG_OP = 'INSERT' THEN\n" +
+				"\t\tIF NEW.\"email\" IS NULL THEN\n\t\t\tNEW.\"email\" := NEW.\"mail\";\n" +
+				"\t\tELSIF NEW.\"mail\" IS NULL THEN\n\t\t\tNEW.\"mail\" := NEW.\"email\";\n" +
+				"\t\tEND IF;\n" +
+				"\tELSIF NEW.\"mail\" IS DISTINCT FROM OLD.\"mail\" THEN\n\t\tNEW.\"email\" := NEW.\"mail\";\n" +
+				"\tELSIF NEW.\"email\" IS DISTINCT FROM OLD.\"email\" AND NEW.\"email\" IS DISTINCT FROM NEW.\"mail\" THEN\n\t\tNEW.\"mail\" := NEW.\"email\";\n" +
+				"\tEND IF;\n\tRETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;\n\n" +
+				"CREATE TRIGGER \"users_sync_email\" BEFORE INSERT OR UPDATE ON \"users\" FOR EACH ROW EXECUTE FUNCTION \"users_sync_email\"();",
+		},
+		"rollback expand": {
+			generate: func(b sb.SQLBuilder) { sqlGen.RollbackExpand(b, "users", rename) },
+			result: "DROP TRIGGER IF EXISTS \"users_sync_email\" ON \"users\";\n" +
+				"DROP FUNCTION IF EXISTS \"users_sync_email\"();\n\n" +
+				"ALTER TABLE IF EXISTS \"users\"\n\tDROP COLUMN \"email\";",
+		},
+		"backfill retype": {
+			generate: func(b sb.SQLBuilder) { sqlGen.Backfill(b, "users", []*config.Field{id}, retype, 500) },
+			result: "DO $$\nDECLARE\n\t_last_key_1 \"users\".\"id\"%TYPE;\nBEGIN\n\tLOOP\n" +
+				"\t\tWITH batch AS (\n" +
+				"\t\t\tSELECT \"id\" FROM \"users\" WHERE _last_key_1 IS NULL OR \"id\" > _last_key_1 ORDER BY \"id\" LIMIT 500\n" +
+				"\t\t), updated AS (\n" +
+				"\t\t\tUPDATE \"users\" SET \"status\" = CASE WHEN \"active\" THEN 1 ELSE 0 END WHERE \"id\" IN (SELECT \"id\" FROM batch) AND \"status\" IS DISTINCT FROM CASE WHEN \"active\" THEN 1 ELSE 0 END\n" +
+				"\t\t)\n" +
+				"\t\tSELECT \"id\" INTO _last_key_1 FROM batch ORDER BY \"id\" DESC LIMIT 1;\n" +
+				"\t\tEXIT WHEN NOT FOUND;\n\t\tCOMMIT;\n\tEND LOOP;\nEND $$;",
+		},
+		"backfill composite primary key": {
+			generate: func(b sb.SQLBuilder) { sqlGen.Backfill(b, "users", []*config.Field{tenantID, id}, rename, 0) },
+			result: "DO $$\nDECLARE\n\t_last_key_1 \"users\".\"tenant_id\"%TYPE;\n\t_last_key_2 \"users\".\"id\"%TYPE;\nBEGIN\n\tLOOP\n" +
+				"\t\tWITH batch AS (\n" +
+				"\t\t\tSELECT \"tenant_id\", \"id\" FROM \"users\" WHERE _last_key_1 IS NULL OR (\"tenant_id\", \"id\") > (_last_key_1, _last_key_2) ORDER BY \"tenant_id\", \"id\" LIMIT 1000\n" +
+				"\t\t), updated AS (\n" +
+				"\t\t\tUPDATE \"users\" SET \"email\" = \"mail\" WHERE (\"tenant_id\", \"id\") IN (SELECT \"tenant_id\", \"id\" FROM batch) AND \"email\" IS DISTINCT FROM \"mail\"\n" +
+				"\t\t)\n" +
+				"\t\tSELECT \"tenant_id\", \"id\" INTO _last_key_1, _last_key_2 FROM batch ORDER BY \"tenant_id\" DESC, \"id\" DESC LIMIT 1;\n" +
+				"\t\tEXIT WHEN NOT FOUND;\n\t\tCOMMIT;\n\tEND LOOP;\nEND $$;",
+		},
+		"contract": {
+			generate: func(b sb.SQLBuilder) { sqlGen.Contract(b, "users", rename) },
+			result: "DROP TRIGGER IF EXISTS \"users_sync_email\" ON \"users\";\n" +
+				"DROP FUNCTION IF EXISTS \"users_sync_email\"();\n\n" +
+				"ALTER TABLE IF EXISTS \"users\"\n\tDROP COLUMN \"mail\";",
+		},
+		"rollback contract without backward cast": {
+			generate: func(b sb.SQLBuilder) { sqlGen.RollbackContract(b, "users", using) },
+			result: "ALTER TABLE IF EXISTS \"users\"\n\tADD COLUMN \"created\" BIGINT;\n\n" +
+				"CREATE OR REPLACE FUNCTION \"users_sync_created_at\"() RETURNS trigger AS $$\nBEGIN\n" +
+				"\tIF TG_OP = 'INSERT' THEN\n" +
+				"\t\tIF NEW.\"created_at\" IS NULL THEN\n\t\t\tNEW.\"created_at\" := (SELECT to_timestamp(\"created\") FROM (SELECT NEW.*) AS \"users\");\n" +
+				"\t\tEND IF;\n" +
+				"\tELSIF NEW.\"created\" IS DISTINCT FROM OLD.\"created\" THEN\n\t\tNEW.\"created_at\" := (SELECT to_timestamp(\"created\") FROM (SELECT NEW.*) AS \"users\");\n" +
+				"\tEND IF;\n\tRETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;\n\n" +
+				"CREATE TRIGGER \"users_sync_created_at\" BEFORE INSERT OR UPDATE ON \"users\" FOR EACH ROW EXECUTE FUNCTION \"users_sync_created_at\"();",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b := sb.NewSQLBuilder()
+			tc.generate(b)
+			assert.Equal(t, tc.result, b.String())
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package privilege
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type Privilege string
+
+const (
+	Select     Privilege = "SELECT"
+	Insert     Privilege = "INSERT"
+	Update     Privilege = "UPDATE"
+	Delete     Privilege = "DELETE"
+	Truncate   Privilege = "TRUNCATE"
+	References Privilege = "REFERENCES"
+	Trigger    Privilege = "TRIGGER"
+)
+
+var SupportedPrivilege = []Privilege{
+	Select,
+	Insert,
+	Update,
+	Delete,
+	Truncate,
+	References,
+	Trigger,
+}
+
+func (p *Privilege) UnmarshalJSON(data []byte) error {
+	var strPriv string
+	err := json.Unmarshal(data, &strPriv)
+	if err != nil {
+		return err
+	}
+
+	priv, err := Parse(strPriv)
+	if err != nil {
+		return err
+	}
+	*p = priv
+	return nil
+}
+
+func Parse(strPriv string) (Privilege, error) {
+	priv := Privilege(strings.ToUpper(strings.TrimSpace(strPriv)))
+	for _, supported := range SupportedPrivilege {
+		if priv == supported {
+			return priv, nil
+		}
+	}
+	return "", fmt.Errorf("invalid \"%s\" as privilege", strPriv)
+}
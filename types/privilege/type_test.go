@@ -0,0 +1,40 @@
+package privilege_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/wartek-id/core/tools/dbgen/types/privilege"
+)
+
+func TestPrivilege_UnmarshallJSON(t *testing.T) {
+	testCases := map[string]struct {
+		input   []byte
+		wantErr error
+		result  privilege.Privilege
+	}{
+		"success": {
+			input:  []byte("\"SELECT\""),
+			result: privilege.Select,
+		},
+		"lower case": {
+			input:  []byte("\"insert\""),
+			result: privilege.Insert,
+		},
+		"invalid privilege": {
+			input:   []byte("\"connect\""),
+			wantErr: fmt.Errorf("invalid \"connect\" as privilege"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var result privilege.Privilege
+			err := json.Unmarshal(tc.input, &result)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+}